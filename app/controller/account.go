@@ -0,0 +1,114 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/errors"
+	j "github.com/thedevsir/frame-backend/services/jwt"
+	"github.com/thedevsir/frame-backend/services/mail"
+	"github.com/thedevsir/frame-backend/services/request"
+)
+
+var _SendAccountDeletionMail = mail.SendAccountDeletionMail
+
+type (
+	DeleteAccountSchema struct {
+		Password string `json:"password" validate:"required"`
+		Code     string `json:"code"`
+	}
+	RestoreAccountSchema struct {
+		Token string `json:"token" validate:"required"`
+	}
+)
+
+// DeleteAccount godoc
+// @Summary Delete the account
+// @Description Closes the account and signs it out everywhere, it is deleted for good once the grace period is over unless it is restored with the emailed link.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param password body string true "Password"
+// @Param code body string false "Two-factor code or recovery code"
+// @Success 200 {object} response.Message
+// @Router /users/auth/account [delete]
+// @Security ApiKeyAuth
+func DeleteAccount(c echo.Context) (err error) {
+
+	params := new(DeleteAccountSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	user := request.AuthenticatedUser(c)
+
+	account, err := repository.CheckUserPassword(user.ID, params.Password)
+	if err != nil {
+		return err
+	}
+
+	if account.TwoFactor {
+		if params.Code == "" {
+			return errors.ErrInvalidCode
+		}
+		if err = checkTwoFactorCode(account, params.Code, request.ClientIP(c)); err != nil {
+			return err
+		}
+	}
+
+	deleteAt := time.Now().Add(config.AccountDeletionGrace)
+	if err = repository.ScheduleAccountDeletion(user.ID, deleteAt); err != nil {
+		return err
+	}
+
+	ID, err := repository.CreateEmailToken(model.EmailTokenRestore, user.ID, config.AccountDeletionGrace)
+	if err != nil {
+		return err
+	}
+
+	token, err := mail.MakeRestoreToken(ID, user.ID, account.Username, account.Email, []byte(config.SigningKey))
+	if err == nil {
+		go _SendAccountDeletionMail(account.Username, account.Email, token, deleteAt)
+	}
+
+	return errors.ErrSuccess
+}
+
+// RestoreAccount godoc
+// @Summary Restore a deleted account
+// @Description Calls the deletion off while the grace period lasts, the account has to sign in again.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param token body string true "Token"
+// @Success 200 {object} response.Message
+// @Router /users/account/restore [post]
+func RestoreAccount(c echo.Context) (err error) {
+
+	params := new(RestoreAccountSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	data, err := j.ParseEmailToken(params.Token, []byte(config.SigningKey))
+	if err != nil {
+		return err
+	}
+
+	if data.Action != model.EmailTokenRestore {
+		return errors.ErrAccessDenied
+	}
+
+	if _, err = repository.ConsumeEmailToken(data.ID, data.Action, data.UserID); err != nil {
+		return err
+	}
+
+	if err = repository.RestoreAccount(data.UserID); err != nil {
+		return err
+	}
+
+	return errors.ErrSuccess
+}
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/errors"
+	j "github.com/thedevsir/frame-backend/services/jwt"
+	"github.com/thedevsir/frame-backend/services/mail"
+	"github.com/thedevsir/frame-backend/services/ratelimit"
+	"github.com/thedevsir/frame-backend/services/request"
+	r "github.com/thedevsir/frame-backend/services/response"
+)
+
+var _SendAccountLockedMail = mail.SendAccountLockedMail
+
+type UnlockAccountSchema struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// checkAbuse rejects an address that made too many failed attempts,
+// telling it when to try again the way rate limited routes do.
+func checkAbuse(c echo.Context, username string) error {
+
+	wait, err := repository.CheckAbuse(request.ClientIP(c), username)
+	if wait > 0 {
+		c.Response().Header().Set("Retry-After", ratelimit.RetryAfter(wait))
+	}
+	return err
+}
+
+// failedSignin counts a failure against the lock, the owner of an account
+// is mailed an unlock link when a run of locks starts. user is nil when the
+// name belongs to no account.
+func failedSignin(key string, user *model.User) {
+
+	userID := ""
+	if user != nil {
+		userID = user.Id.Hex()
+	}
+
+	lock, locked, err := repository.RecordFailedSignin(key, userID)
+	if err != nil || !locked || user == nil || !user.IsActive {
+		return
+	}
+
+	ID, err := repository.CreateEmailToken(model.EmailTokenUnlock, userID, config.LockoutMaxDelay)
+	if err != nil {
+		return
+	}
+
+	token, err := mail.MakeUnlockToken(ID, userID, user.Username, user.Email, []byte(config.SigningKey))
+	if err == nil {
+		go _SendAccountLockedMail(user.Username, user.Email, token, *lock.LockedUntil)
+	}
+}
+
+// UnlockAccount godoc
+// @Summary Unlock an account
+// @Description Lifts the lock failed signins put on the account, with the link it was emailed.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param token body string true "Token"
+// @Success 200 {object} response.Message
+// @Router /users/signin/unlock [post]
+func UnlockAccount(c echo.Context) (err error) {
+
+	params := new(UnlockAccountSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	data, err := j.ParseEmailToken(params.Token, []byte(config.SigningKey))
+	if err != nil {
+		return err
+	}
+
+	if data.Action != model.EmailTokenUnlock {
+		return errors.ErrAccessDenied
+	}
+
+	if _, err = repository.ConsumeEmailToken(data.ID, data.Action, data.UserID); err != nil {
+		return err
+	}
+
+	if err = repository.UnlockAccount(data.UserID); err != nil && err != errors.ErrAccountNotLocked {
+		return err
+	}
+
+	return errors.ErrSuccess
+}
+
+// AdminGetAccountLock godoc
+// @Summary Get user's lock
+// @Description The failed signins counted against the account and until when it is locked.
+// @Tags adminUser
+// @Accept json
+// @Produce json
+// @Security AdminApiKeyAuth
+// @Param id path string true "user ID"
+// @Success 200 {object} response.Message
+// @Router /admin/auth/users/lock/{id} [get]
+func AdminGetAccountLock(c echo.Context) (err error) {
+
+	lock, err := repository.GetAccountLock(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusOK, lock, c)
+}
+
+// AdminUnlockAccount godoc
+// @Summary Clear user's lock
+// @Tags adminUser
+// @Accept json
+// @Produce json
+// @Security AdminApiKeyAuth
+// @Param id path string true "user ID"
+// @Success 200 {object} response.Message
+// @Router /admin/auth/users/lock/{id} [delete]
+func AdminUnlockAccount(c echo.Context) (err error) {
+
+	if err = repository.UnlockAccount(c.Param("id")); err != nil {
+		return err
+	}
+
+	return errors.ErrSuccess
+}
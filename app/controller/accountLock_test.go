@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/ratelimit"
+	"github.com/thedevsir/frame-backend/services/test"
+)
+
+func TestAccountLock(t *testing.T) {
+
+	user, _ := userBeforeTest()
+	defer userAfterTest()
+
+	for _, collection := range []string{model.AccountLockCollection, model.AuthAttemptCollection, model.EmailTokenCollection, model.SessionCollection} {
+		database.Connection.Model(collection).RemoveAll(nil)
+		defer database.Connection.Model(collection).RemoveAll(nil)
+	}
+
+	ratelimit.Store = ratelimit.NewMemory()
+
+	config.AbuseIPUsername = 100
+	config.LockoutThreshold = 2
+	config.LockoutDelay = time.Minute
+	config.LockoutMaxDelay = time.Hour
+
+	sent := make(chan string, 1)
+	_SendAccountLockedMail = func(username, email, token string, lockedUntil time.Time) error {
+		sent <- token
+		return nil
+	}
+
+	signin := func(username, password string) error {
+		c, _ := test.MakeRequest(echo.POST, `{"username":"`+username+`","password":"`+password+`"}`)
+		return Signin(c)
+	}
+
+	withID := func(c echo.Context) echo.Context {
+		c.SetParamNames("id")
+		c.SetParamValues(user.Id.Hex())
+		return c
+	}
+
+	t.Run("UnknownUsername", func(t *testing.T) {
+
+		// a name of no account answers like the name of one
+		assert.Equal(t, errors.ErrInvalidCredentials, signin("nobody", "12345678"))
+		assert.Equal(t, errors.ErrInvalidCredentials, signin("nobody", "12345678"))
+		assert.Equal(t, errors.ErrAccountLocked, signin("nobody", "12345678"))
+	})
+
+	var token string
+
+	t.Run("Lock", func(t *testing.T) {
+
+		assert.Equal(t, errors.ErrInvalidCredentials, signin(user.Username, "87654321"))
+		assert.Equal(t, errors.ErrInvalidCredentials, signin(user.Email, "87654321"))
+		token = <-sent
+
+		// the right password waits for the lock too
+		assert.Equal(t, errors.ErrAccountLocked, signin(user.Username, "12345678"))
+	})
+
+	t.Run("AdminGetAccountLock", func(t *testing.T) {
+
+		c, rec := test.MakeRequest(echo.GET, "")
+		if assert.NoError(t, AdminGetAccountLock(withID(c))) {
+			assert.Contains(t, rec.Body.String(), `"failures":2`)
+		}
+	})
+
+	t.Run("UnlockAccount", func(t *testing.T) {
+
+		submit := func(token string) error {
+			c, _ := test.MakeRequest(echo.POST, `{"token":"`+token+`"}`)
+			return UnlockAccount(c)
+		}
+
+		assert.Equal(t, errors.ErrSuccess, submit(token))
+		assert.Equal(t, errors.ErrTokenIsNotValid, submit(token))
+		assert.NoError(t, signin(user.Username, "12345678"))
+	})
+
+	t.Run("AdminUnlockAccount", func(t *testing.T) {
+
+		signin(user.Username, "87654321")
+		signin(user.Username, "87654321")
+		<-sent
+
+		c, _ := test.MakeRequest(echo.DELETE, "")
+		assert.Equal(t, errors.ErrSuccess, AdminUnlockAccount(withID(c)))
+
+		c, _ = test.MakeRequest(echo.DELETE, "")
+		assert.Equal(t, errors.ErrAccountNotLocked, AdminUnlockAccount(withID(c)))
+	})
+
+	t.Run("Abuse", func(t *testing.T) {
+
+		defer func(limit int) { config.AbuseIPUsername = limit }(config.AbuseIPUsername)
+		config.AbuseIPUsername = 1
+
+		signin("abuser", "12345678")
+
+		c, rec := test.MakeRequest(echo.POST, `{"username":"abuser","password":"12345678"}`)
+		assert.Equal(t, errors.ErrAttemptsReached, Signin(c))
+		assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+	})
+}
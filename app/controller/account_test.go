@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/test"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestAccountDeletion(t *testing.T) {
+
+	user, tokenParsed := userBeforeTest()
+	defer userAfterTest()
+
+	for _, collection := range []string{model.EmailTokenCollection, model.SessionCollection} {
+		database.Connection.Model(collection).RemoveAll(nil)
+		defer database.Connection.Model(collection).RemoveAll(nil)
+	}
+
+	config.AccountDeletionGrace = time.Hour
+
+	sent := make(chan string, 1)
+	_SendAccountDeletionMail = func(username, email, token string, deleteAt time.Time) error {
+		sent <- token
+		return nil
+	}
+
+	var token string
+
+	t.Run("DeleteAccount", func(t *testing.T) {
+
+		t.Run("WrongPassword", func(t *testing.T) {
+			c, _ := test.MakeRequest(echo.DELETE, `{"password":"87654321"}`)
+			c.Set("user", tokenParsed)
+			assert.Equal(t, errors.ErrInvalidCredentials, DeleteAccount(c))
+		})
+
+		t.Run("TwoFactorCodeRequired", func(t *testing.T) {
+			userCollection.Update(bson.M{"_id": user.Id}, bson.M{"$set": bson.M{"twoFactor": true}})
+			defer userCollection.Update(bson.M{"_id": user.Id}, bson.M{"$set": bson.M{"twoFactor": false}})
+
+			c, _ := test.MakeRequest(echo.DELETE, `{"password":"12345678"}`)
+			c.Set("user", tokenParsed)
+			assert.Equal(t, errors.ErrInvalidCode, DeleteAccount(c))
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			c, _ := test.MakeRequest(echo.DELETE, `{"password":"12345678"}`)
+			c.Set("user", tokenParsed)
+			if assert.Equal(t, errors.ErrSuccess, DeleteAccount(c)) {
+				token = <-sent
+			}
+
+			account, _ := repository.GetAccountInfo(user.Id.Hex())
+			assert.False(t, account.IsActive)
+			assert.NotNil(t, account.DeleteAt)
+		})
+	})
+
+	t.Run("RestoreAccount", func(t *testing.T) {
+
+		submit := func(token string) error {
+			c, _ := test.MakeRequest(echo.POST, `{"token":"`+token+`"}`)
+			return RestoreAccount(c)
+		}
+
+		t.Run("WrongAction", func(t *testing.T) {
+			reset, _ := emailToken(model.EmailTokenReset, user)
+			assert.Equal(t, errors.ErrAccessDenied, submit(reset))
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			assert.Equal(t, errors.ErrSuccess, submit(token))
+			assert.Equal(t, errors.ErrTokenIsNotValid, submit(token))
+
+			_, err := repository.CheckUserPassword(user.Id.Hex(), "12345678")
+			assert.Nil(t, err)
+		})
+	})
+}
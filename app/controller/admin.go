@@ -7,9 +7,9 @@ import (
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
 	"github.com/thedevsir/frame-backend/app/repository"
-	"github.com/thedevsir/frame-backend/config"
 	"github.com/thedevsir/frame-backend/services/auth"
 	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/keyring"
 	"github.com/thedevsir/frame-backend/services/request"
 	r "github.com/thedevsir/frame-backend/services/response"
 )
@@ -55,7 +55,7 @@ func AdminSignin(c echo.Context) (err error) {
 			ExpiresAt: time.Now().Add(time.Hour * 24).Unix(),
 		},
 	}
-	jwtToken, err := token.Create([]byte(config.AdminSigningKey))
+	jwtToken, err := token.Create(keyring.Admin)
 	if err != nil {
 		return err
 	}
@@ -7,6 +7,7 @@ import (
 	"github.com/thedevsir/frame-backend/app/repository"
 	"github.com/thedevsir/frame-backend/services/errors"
 	"github.com/thedevsir/frame-backend/services/paginate"
+	"github.com/thedevsir/frame-backend/services/password"
 	"github.com/thedevsir/frame-backend/services/request"
 	r "github.com/thedevsir/frame-backend/services/response"
 )
@@ -14,13 +15,13 @@ import (
 type (
 	CreateAdminSchema struct {
 		Username string `json:"username" validate:"required,min=3,max=50,alphanum"`
-		Password string `json:"password" validate:"required,min=8,max=50"`
+		Password string `json:"password" validate:"required"`
 	}
 	ChangeAdminUsernameSchema struct {
 		Username string `json:"username" validate:"required,min=3,max=50,alphanum"`
 	}
 	ChangeAdminPasswordSchema struct {
-		Password string `json:"password" validate:"required,min=8,max=50"`
+		Password string `json:"password" validate:"required"`
 	}
 	ChangeAdminStatusSchema struct {
 		IsActive bool `json:"isActive"`
@@ -73,6 +74,10 @@ func CreateAdmin(c echo.Context) (err error) {
 		return err
 	}
 
+	if err = password.Validate(password.Input{Password: params.Password, Username: params.Username}); err != nil {
+		return err
+	}
+
 	_, err = repository.CreateAdmin(params.Username, params.Password)
 	if err != nil {
 		return err
@@ -197,6 +202,10 @@ func ChangeAdminPassword(c echo.Context) (err error) {
 		return err
 	}
 
+	if err = password.Validate(password.Input{Password: params.Password}); err != nil {
+		return err
+	}
+
 	err = repository.AdminChangePassword(adminID, params.Password)
 	if err != nil {
 		return err
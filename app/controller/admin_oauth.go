@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/oauth"
+	"github.com/thedevsir/frame-backend/services/paginate"
+	"github.com/thedevsir/frame-backend/services/request"
+	r "github.com/thedevsir/frame-backend/services/response"
+)
+
+type (
+	CreateOAuthClientSchema struct {
+		Name         string   `json:"name" validate:"required,max=100"`
+		Public       bool     `json:"public"`
+		RedirectURIs []string `json:"redirectUris" validate:"dive,url"`
+		GrantTypes   []string `json:"grantTypes" validate:"required,dive,oneof=authorization_code refresh_token client_credentials"`
+		Scopes       []string `json:"scopes" validate:"dive,required"`
+	}
+	ChangeOAuthClientStatusSchema struct {
+		IsActive bool `json:"isActive"`
+	}
+	CreatedOAuthClient struct {
+		*model.OAuthClient
+		ClientSecret string `json:"clientSecret,omitempty"`
+	}
+)
+
+// AdminGetOAuthClients godoc
+// @Summary Get all oauth clients
+// @Tags adminOAuth
+// @Produce json
+// @Security AdminApiKeyAuth
+// @Param page query number false "Page"
+// @Param limit query number false "Limit"
+// @Success 200 {object} response.Message
+// @Router /admin/auth/oauth-clients/get/all [get]
+func AdminGetOAuthClients(c echo.Context) (err error) {
+
+	if !request.IsRootAdmin(c) {
+		return errors.ErrAccessDenied
+	}
+
+	page, limit := paginate.HandleQueries(c)
+	clients, err := repository.GetOAuthClients(page, limit)
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusOK, clients, c)
+}
+
+// AdminGetOAuthClient godoc
+// @Summary Get oauth client by id
+// @Tags adminOAuth
+// @Produce json
+// @Security AdminApiKeyAuth
+// @Param id path string true "ID"
+// @Success 200 {object} response.Message
+// @Router /admin/auth/oauth-clients/get/{id} [get]
+func AdminGetOAuthClient(c echo.Context) (err error) {
+
+	if !request.IsRootAdmin(c) {
+		return errors.ErrAccessDenied
+	}
+
+	client, err := repository.GetOAuthClientByID(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusOK, client, c)
+}
+
+// AdminCreateOAuthClient godoc
+// @Summary Register an oauth client
+// @Description The client secret is only shown in this response.
+// @Tags adminOAuth
+// @Accept json
+// @Produce json
+// @Security AdminApiKeyAuth
+// @Param name body string true "Name"
+// @Param public body bool false "Public client without a secret"
+// @Param redirectUris body array false "Redirect URIs"
+// @Param grantTypes body array true "Grant types"
+// @Param scopes body array false "Scopes"
+// @Success 201 {object} response.Message
+// @Router /admin/auth/oauth-clients/create [post]
+func AdminCreateOAuthClient(c echo.Context) (err error) {
+
+	if !request.IsRootAdmin(c) {
+		return errors.ErrAccessDenied
+	}
+
+	params := new(CreateOAuthClientSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	switch {
+	case oauth.Contains(params.GrantTypes, oauth.GrantAuthorizationCode) && len(params.RedirectURIs) == 0:
+		return errors.ErrInvalidParams
+	case params.Public && oauth.Contains(params.GrantTypes, oauth.GrantClientCredentials):
+		return errors.ErrInvalidParams
+	}
+
+	client, secret, err := repository.CreateOAuthClient(params.Name, params.Public, params.RedirectURIs, params.GrantTypes, params.Scopes)
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusCreated, CreatedOAuthClient{client, secret}, c)
+}
+
+// AdminChangeOAuthClientStatus godoc
+// @Summary Set oauth client status
+// @Tags adminOAuth
+// @Accept json
+// @Produce json
+// @Security AdminApiKeyAuth
+// @Param id path string true "ID"
+// @Param isActive body bool true "IsActive"
+// @Success 200 {object} response.Message
+// @Router /admin/auth/oauth-clients/status/{id} [put]
+func AdminChangeOAuthClientStatus(c echo.Context) (err error) {
+
+	if !request.IsRootAdmin(c) {
+		return errors.ErrAccessDenied
+	}
+
+	params := new(ChangeOAuthClientStatusSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	if err = repository.ChangeOAuthClientStatus(c.Param("id"), params.IsActive); err != nil {
+		return err
+	}
+
+	return errors.ErrSuccess
+}
+
+// AdminRemoveOAuthClient godoc
+// @Summary Remove an oauth client and revoke its tokens
+// @Tags adminOAuth
+// @Produce json
+// @Security AdminApiKeyAuth
+// @Param id path string true "ID"
+// @Success 200 {object} response.Message
+// @Router /admin/auth/oauth-clients/{id} [delete]
+func AdminRemoveOAuthClient(c echo.Context) (err error) {
+
+	if !request.IsRootAdmin(c) {
+		return errors.ErrAccessDenied
+	}
+
+	if err = repository.RemoveOAuthClient(c.Param("id")); err != nil {
+		return err
+	}
+
+	return errors.ErrSuccess
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/thedevsir/frame-backend/services/auth"
 	"github.com/thedevsir/frame-backend/services/errors"
 	j "github.com/thedevsir/frame-backend/services/jwt"
+	"github.com/thedevsir/frame-backend/services/keyring"
 	"github.com/thedevsir/frame-backend/services/test"
 )
 
@@ -30,6 +31,9 @@ func adminBeforeTest() {
 
 	adminCollection = database.Connection.Model(model.AdminCollection)
 	adminCollection.RemoveAll(nil)
+
+	signer, _ := keyring.GenerateKey(keyring.ES256)
+	keyring.Admin = keyring.NewRing(signer)
 }
 
 func adminAfterTest() {
@@ -41,7 +45,6 @@ func TestAdminUser(t *testing.T) {
 	adminBeforeTest()
 	defer adminAfterTest()
 
-	secret := []byte("secret")
 	username, password := "admin", "12345678"
 	adminID, _ := repository.CreateAdmin(username, password)
 
@@ -49,7 +52,7 @@ func TestAdminUser(t *testing.T) {
 		Session: bson.NewObjectId().Hex(),
 		ID:      adminID,
 	}
-	tc, _ := token.Create(secret)
+	tc, _ := token.Create(keyring.Admin)
 
 	t.Run("AdminSignin", func(t *testing.T) {
 
@@ -93,7 +96,7 @@ func TestAdminUser(t *testing.T) {
 	t.Run("AdminLogout", func(t *testing.T) {
 
 		c, _ := test.MakeRequest(echo.DELETE, "")
-		tokenParsed, _ := j.ParseJWT(tc, secret)
+		tokenParsed, _ := j.ParseSignedJWT(tc, keyring.Admin)
 		c.Set("user", tokenParsed)
 
 		assert.Equal(t, errors.ErrSuccess, AdminSignout(c))
@@ -9,6 +9,7 @@ import (
 	"github.com/thedevsir/frame-backend/config"
 	"github.com/thedevsir/frame-backend/services/errors"
 	"github.com/thedevsir/frame-backend/services/paginate"
+	"github.com/thedevsir/frame-backend/services/password"
 	"github.com/thedevsir/frame-backend/services/request"
 	r "github.com/thedevsir/frame-backend/services/response"
 	"github.com/thedevsir/frame-backend/services/storage"
@@ -20,7 +21,7 @@ type (
 		Username string `json:"username" validate:"required,min=3,max=50,alphanum"`
 	}
 	AdminChangePasswordSchema struct {
-		Password string `json:"password" validate:"required,min=8,max=50"`
+		Password string `json:"password" validate:"required"`
 	}
 	AdminChnageEmailSchema struct {
 		Email string `json:"email" validate:"required,email"`
@@ -71,6 +72,29 @@ func AdminGetUser(c echo.Context) (err error) {
 	return r.CustomErrorJson(http.StatusOK, user, c)
 }
 
+// AdminGetUserSessions godoc
+// @Summary Get user sessions
+// @Description The open sessions of the user with their device and approximate location.
+// @Tags adminUser
+// @Accept json
+// @Produce json
+// @Security AdminApiKeyAuth
+// @Param id path string true "user ID"
+// @Param page query int false "page of pgination"
+// @Param limit query int false "limit of pgination"
+// @Success 200 {object} response.Message
+// @Router /admin/auth/users/sessions/{id} [get]
+func AdminGetUserSessions(c echo.Context) (err error) {
+
+	page, limit := paginate.HandleQueries(c)
+	sessions, err := repository.GetUserSessions(c.Param("id"), page, limit)
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusOK, sessions, c)
+}
+
 // AdminChangeUsername godoc
 // @Summary Set username
 // @Tags adminUser
@@ -99,6 +123,7 @@ func AdminChangeUsername(c echo.Context) (err error) {
 
 // AdminChangePassword godoc
 // @Summary Set user password
+// @Description Signs the user out everywhere and revokes their personal access tokens.
 // @Tags adminUser
 // @Accept json
 // @Produce json
@@ -116,10 +141,27 @@ func AdminChangePassword(c echo.Context) (err error) {
 		return err
 	}
 
+	user, err := repository.GetUserByIDFromAdmin(userID)
+	if err != nil {
+		return err
+	}
+
+	if err = password.Validate(passwordInput(params.Password, user)); err != nil {
+		return err
+	}
+
 	if err = repository.ChangePassword(userID, params.Password, true); err != nil {
 		return err
 	}
 
+	if err = repository.TerminateAllSessions(userID); err != nil {
+		return err
+	}
+
+	if err = repository.RevokeUserPersonalTokens(userID); err != nil {
+		return err
+	}
+
 	return errors.ErrSuccess
 }
 
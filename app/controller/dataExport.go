@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/job"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/request"
+	r "github.com/thedevsir/frame-backend/services/response"
+	"github.com/thedevsir/frame-backend/services/storage"
+)
+
+var _ExportData = job.ExportData
+
+// RequestDataExport godoc
+// @Summary Export my data
+// @Description Builds an archive of everything stored about the user in the background and emails a download link that expires.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 202 {object} response.Message
+// @Router /users/auth/export [post]
+func RequestDataExport(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	dataExport, err := repository.CreateDataExport(user.ID, "")
+	if err != nil {
+		return err
+	}
+
+	go _ExportData(dataExport)
+
+	return r.CustomErrorJson(http.StatusAccepted, dataExport, c)
+}
+
+// AdminRequestDataExport godoc
+// @Summary Export user's data
+// @Description Builds an archive of everything stored about the user in the background, it is downloaded from the export once ready.
+// @Tags adminUser
+// @Accept json
+// @Produce json
+// @Security AdminApiKeyAuth
+// @Param id path string true "user ID"
+// @Success 202 {object} response.Message
+// @Router /admin/auth/users/export/{id} [post]
+func AdminRequestDataExport(c echo.Context) (err error) {
+
+	userID := c.Param("id")
+	admin := request.AuthenticatedAdmin(c)
+
+	if _, err = repository.GetUserByIDFromAdmin(userID); err != nil {
+		return err
+	}
+
+	dataExport, err := repository.CreateDataExport(userID, admin.ID)
+	if err != nil {
+		return err
+	}
+
+	go _ExportData(dataExport)
+
+	return r.CustomErrorJson(http.StatusAccepted, dataExport, c)
+}
+
+// AdminGetDataExport godoc
+// @Summary Get a data export
+// @Description The export has a download link that expires once it is ready.
+// @Tags adminUser
+// @Accept json
+// @Produce json
+// @Security AdminApiKeyAuth
+// @Param id path string true "export ID"
+// @Success 200 {object} response.Message
+// @Router /admin/auth/users/exports/{id} [get]
+func AdminGetDataExport(c echo.Context) (err error) {
+
+	dataExport, err := repository.GetDataExport(c.Param("id"))
+	if err != nil {
+		return err
+	}
+
+	// the exports users ask for are only mailed to them
+	if dataExport.AdminID == "" {
+		return errors.ErrDataExportNotFound
+	}
+
+	if dataExport.Status == model.DataExportReady {
+		dataExport.URL, err = storage.GetPresignedURL(dataExport.Object(), config.MinioExportBucket, time.Until(dataExport.ExpireAt))
+		if err != nil {
+			return err
+		}
+	}
+
+	return r.CustomErrorJson(http.StatusOK, dataExport, c)
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/job"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/auth"
+	"github.com/thedevsir/frame-backend/services/errors"
+	j "github.com/thedevsir/frame-backend/services/jwt"
+	"github.com/thedevsir/frame-backend/services/keyring"
+	"github.com/thedevsir/frame-backend/services/test"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestDataExport(t *testing.T) {
+
+	user, tokenParsed := userBeforeTest()
+	defer userAfterTest()
+
+	exportCollection := database.Connection.Model(model.DataExportCollection)
+	exportCollection.RemoveAll(nil)
+	defer exportCollection.RemoveAll(nil)
+
+	// the archives are built in the background, the exports come back on
+	// a channel
+	requested := make(chan *model.DataExport, 1)
+	_ExportData = func(dataExport *model.DataExport) {
+		requested <- dataExport
+	}
+
+	signer, _ := keyring.GenerateKey(keyring.ES256)
+	keyring.Admin = keyring.NewRing(signer)
+	token := &auth.AdminToken{
+		Session: bson.NewObjectId().Hex(),
+		ID:      bson.NewObjectId().Hex(),
+	}
+	tc, _ := token.Create(keyring.Admin)
+	adminTokenParsed, _ := j.ParseSignedJWT(tc, keyring.Admin)
+
+	withID := func(c echo.Context, ID string) echo.Context {
+		c.SetParamNames("id")
+		c.SetParamValues(ID)
+		c.Set("user", adminTokenParsed)
+		return c
+	}
+
+	t.Run("RequestDataExport", func(t *testing.T) {
+
+		c, rec := test.MakeRequest(echo.POST, "")
+		c.Set("user", tokenParsed)
+		if assert.NoError(t, RequestDataExport(c)) {
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+		}
+
+		dataExport := <-requested
+		assert.Equal(t, user.Id.Hex(), dataExport.UserID)
+
+		c, _ = test.MakeRequest(echo.POST, "")
+		c.Set("user", tokenParsed)
+		assert.Equal(t, errors.ErrDataExportPending, RequestDataExport(c))
+
+		// the link of the user is only mailed to them
+		c, _ = test.MakeRequest(echo.GET, "")
+		assert.Equal(t, errors.ErrDataExportNotFound, AdminGetDataExport(withID(c, dataExport.Id.Hex())))
+	})
+
+	t.Run("AdminRequestDataExport", func(t *testing.T) {
+
+		t.Run("UserNotFound", func(t *testing.T) {
+			c, _ := test.MakeRequest(echo.POST, "")
+			assert.Equal(t, errors.ErrUserNotFound, AdminRequestDataExport(withID(c, bson.NewObjectId().Hex())))
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			c, rec := test.MakeRequest(echo.POST, "")
+			if !assert.NoError(t, AdminRequestDataExport(withID(c, user.Id.Hex()))) {
+				return
+			}
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+
+			dataExport := <-requested
+			job.ExportData(dataExport)
+
+			c, rec = test.MakeRequest(echo.GET, "")
+			if assert.NoError(t, AdminGetDataExport(withID(c, dataExport.Id.Hex()))) {
+				data := struct {
+					Message model.DataExport
+				}{}
+				json.Unmarshal(rec.Body.Bytes(), &data)
+				assert.Equal(t, model.DataExportReady, data.Message.Status)
+				assert.NotEmpty(t, data.Message.URL)
+			}
+		})
+	})
+}
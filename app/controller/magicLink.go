@@ -0,0 +1,119 @@
+package controller
+
+import (
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/errors"
+	j "github.com/thedevsir/frame-backend/services/jwt"
+	"github.com/thedevsir/frame-backend/services/mail"
+	"github.com/thedevsir/frame-backend/services/request"
+)
+
+var _SendLoginMail = mail.SendLoginMail
+
+type (
+	MagicLinkSchema struct {
+		Email string `json:"email" validate:"required,email"`
+	}
+	MagicLinkSigninSchema struct {
+		Token string `json:"token" validate:"required"`
+	}
+)
+
+// SendMagicLink godoc
+// @Summary Send a sign in link
+// @Description Emails a link that signs the user in once, attempts are limited like password signins.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param email body string true "Email"
+// @Success 200 {object} response.Message
+// @Router /users/signin/magic [post]
+func SendMagicLink(c echo.Context) (err error) {
+
+	ip := request.ClientIP(c)
+
+	params := new(MagicLinkSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	if err = checkAbuse(c, params.Email); err != nil {
+		return err
+	}
+
+	user, err := repository.CheckEmail(params.Email)
+	if user == nil && err != nil {
+		return err
+	}
+
+	if user == nil || !user.IsActive {
+		repository.SubmitAttempt(ip, params.Email)
+		return errors.ErrUserEmailNotFound
+	}
+
+	ID, err := repository.CreateEmailToken(model.EmailTokenLogin, user.Id.Hex(), config.MagicLinkLifetime)
+	if err != nil {
+		return err
+	}
+
+	token, err := mail.MakeLoginToken(ID, user.Id.Hex(), user.Username, user.Email, []byte(config.SigningKey))
+	if err == nil {
+		go _SendLoginMail(user.Username, user.Email, token)
+	}
+
+	return errors.ErrSuccess
+}
+
+// SigninMagicLink godoc
+// @Summary Sign in with a sign in link
+// @Description Opens a session like a password signin, the second factor is still asked for.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param token body string true "Token"
+// @Success 200 {object} response.Message
+// @Header 200 {string} Refresh-Token "Refresh token"
+// @Success 202 {object} response.Message
+// @Router /users/signin/magic/verify [post]
+func SigninMagicLink(c echo.Context) (err error) {
+
+	ip := request.ClientIP(c)
+
+	params := new(MagicLinkSigninSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	data, err := j.ParseEmailToken(params.Token, []byte(config.SigningKey))
+	if err != nil {
+		return err
+	}
+
+	if data.Action != model.EmailTokenLogin {
+		return errors.ErrAccessDenied
+	}
+
+	if err = checkAbuse(c, data.Email); err != nil {
+		return err
+	}
+
+	if _, err = repository.ConsumeEmailToken(data.ID, model.EmailTokenLogin, data.UserID); err != nil {
+		repository.SubmitAttempt(ip, data.Email)
+		return err
+	}
+
+	user, err := repository.GetAccountInfo(data.UserID)
+	if err != nil {
+		return err
+	}
+
+	// the link was sent to an address the account no longer has
+	if !user.IsActive || user.Email != data.Email {
+		return errors.ErrAccessDenied
+	}
+
+	return completeSignin(c, user, data.Email, false)
+}
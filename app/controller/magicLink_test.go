@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/ratelimit"
+	"github.com/thedevsir/frame-backend/services/test"
+)
+
+func TestMagicLink(t *testing.T) {
+
+	user, _ := userBeforeTest()
+	defer userAfterTest()
+
+	for _, collection := range []string{model.EmailTokenCollection, model.SessionCollection, model.AuthAttemptCollection} {
+		database.Connection.Model(collection).RemoveAll(nil)
+		defer database.Connection.Model(collection).RemoveAll(nil)
+	}
+
+	ratelimit.Store = ratelimit.NewMemory()
+
+	sent := make(chan string, 1)
+	_SendLoginMail = func(username, email, token string) error {
+		sent <- token
+		return nil
+	}
+
+	var token string
+
+	t.Run("SendMagicLink", func(t *testing.T) {
+
+		t.Run("EmailNotFound", func(t *testing.T) {
+			c, _ := test.MakeRequest(echo.POST, `{"email":"unknown@example.com"}`)
+			assert.Equal(t, errors.ErrUserEmailNotFound, SendMagicLink(c))
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			c, _ := test.MakeRequest(echo.POST, `{"email":"`+user.Email+`"}`)
+			if assert.Equal(t, errors.ErrSuccess, SendMagicLink(c)) {
+				token = <-sent
+			}
+		})
+	})
+
+	t.Run("SigninMagicLink", func(t *testing.T) {
+
+		t.Run("WrongAction", func(t *testing.T) {
+			reset, _ := emailToken(model.EmailTokenReset, user)
+			c, _ := test.MakeRequest(echo.POST, `{"token":"`+reset+`"}`)
+			assert.Equal(t, errors.ErrAccessDenied, SigninMagicLink(c))
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			c, rec := test.MakeRequest(echo.POST, `{"token":"`+token+`"}`)
+			if assert.NoError(t, SigninMagicLink(c)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.NotEmpty(t, rec.Header().Get(echo.HeaderAuthorization))
+				assert.NotEmpty(t, rec.Header().Get(HeaderRefreshToken))
+			}
+		})
+
+		t.Run("Used", func(t *testing.T) {
+			c, _ := test.MakeRequest(echo.POST, `{"token":"`+token+`"}`)
+			assert.Equal(t, errors.ErrTokenIsNotValid, SigninMagicLink(c))
+		})
+	})
+}
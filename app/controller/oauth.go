@@ -0,0 +1,551 @@
+package controller
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/oauth"
+	"github.com/thedevsir/frame-backend/services/request"
+	r "github.com/thedevsir/frame-backend/services/response"
+)
+
+type (
+	AuthorizeSchema struct {
+		ResponseType        string `query:"response_type" json:"response_type" validate:"required"`
+		ClientID            string `query:"client_id" json:"client_id" validate:"required"`
+		RedirectURI         string `query:"redirect_uri" json:"redirect_uri" validate:"required"`
+		Scope               string `query:"scope" json:"scope"`
+		State               string `query:"state" json:"state"`
+		CodeChallenge       string `query:"code_challenge" json:"code_challenge"`
+		CodeChallengeMethod string `query:"code_challenge_method" json:"code_challenge_method"`
+		Nonce               string `query:"nonce" json:"nonce"`
+	}
+	ApproveAuthorizationSchema struct {
+		AuthorizeSchema
+		Approve bool `json:"approve"`
+	}
+	AuthorizationConsent struct {
+		Client *model.OAuthClient `json:"client"`
+		Scopes []string           `json:"scopes"`
+	}
+	TokenResponse struct {
+		AccessToken  string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		ExpiresIn    int64  `json:"expires_in"`
+		RefreshToken string `json:"refresh_token,omitempty"`
+		Scope        string `json:"scope,omitempty"`
+		IDToken      string `json:"id_token,omitempty"`
+	}
+	IntrospectionResponse struct {
+		Active    bool   `json:"active"`
+		Scope     string `json:"scope,omitempty"`
+		ClientID  string `json:"client_id,omitempty"`
+		Subject   string `json:"sub,omitempty"`
+		TokenType string `json:"token_type,omitempty"`
+		ExpiresAt int64  `json:"exp,omitempty"`
+		IssuedAt  int64  `json:"iat,omitempty"`
+	}
+)
+
+// oauthError writes an RFC 6749 error response, anything else than an
+// oauth.Error is reported as server_error.
+func oauthError(c echo.Context, err error) error {
+
+	e, ok := err.(*oauth.Error)
+	if !ok {
+		e = oauth.ErrServerError
+	}
+
+	switch e {
+	case oauth.ErrInvalidClient:
+		c.Response().Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+	case oauth.ErrInvalidToken, oauth.ErrInsufficientScope:
+		c.Response().Header().Set("WWW-Authenticate", `Bearer error="`+e.Code+`"`)
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().Header().Set("Pragma", "no-cache")
+
+	return c.JSON(e.Status, e)
+}
+
+// authenticateOAuthClient reads client credentials from the basic auth
+// header or the request body. Public clients only send their client_id.
+func authenticateOAuthClient(c echo.Context) (*model.OAuthClient, error) {
+
+	clientID, secret, ok := c.Request().BasicAuth()
+	if ok {
+		// RFC 6749 section 2.3.1, credentials are form encoded first
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID, secret = c.FormValue("client_id"), c.FormValue("client_secret")
+	}
+
+	if clientID == "" {
+		return nil, oauth.ErrInvalidClient
+	}
+
+	if secret == "" {
+		client, err := repository.GetOAuthClient(clientID)
+		if err != nil || !client.Public {
+			return nil, oauth.ErrInvalidClient
+		}
+		return client, nil
+	}
+
+	client, err := repository.AuthenticateOAuthClient(clientID, secret)
+	if err != nil {
+		return nil, oauth.ErrInvalidClient
+	}
+
+	return client, nil
+}
+
+// requestedScopes falls back to every scope of the client when none is
+// requested.
+func requestedScopes(scope string, allowed []string) ([]string, error) {
+
+	scopes := oauth.ParseScope(scope)
+	if len(scopes) == 0 {
+		return allowed, nil
+	}
+
+	if !oauth.Covers(allowed, scopes) {
+		return nil, oauth.ErrInvalidScope
+	}
+
+	return scopes, nil
+}
+
+// checkAuthorizeRequest validates an authorization request. An unknown client
+// or redirect URI is returned as a plain error since the user must never be
+// sent to an unverified URI, other problems are an oauth.Error that goes back
+// to the client through the redirect.
+func checkAuthorizeRequest(params *AuthorizeSchema) (*model.OAuthClient, []string, error) {
+
+	client, err := repository.GetOAuthClient(params.ClientID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !oauth.MatchRedirectURI(client.RedirectURIs, params.RedirectURI) {
+		return nil, nil, errors.ErrRedirectURIInvalid
+	}
+
+	switch {
+	case params.ResponseType != oauth.ResponseTypeCode:
+		return nil, nil, oauth.ErrUnsupportedResponseType
+	case !oauth.Contains(client.GrantTypes, oauth.GrantAuthorizationCode):
+		return nil, nil, oauth.ErrUnauthorizedClient
+	case params.CodeChallenge == "" || params.CodeChallengeMethod != oauth.ChallengeMethodS256:
+		return nil, nil, &oauth.Error{
+			Status:      http.StatusBadRequest,
+			Code:        oauth.ErrInvalidRequest.Code,
+			Description: "code_challenge with the S256 method is required",
+		}
+	}
+
+	scopes, err := requestedScopes(params.Scope, client.Scopes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return client, scopes, nil
+}
+
+// authorizeRedirect hands the redirect back to the frontend, which is the
+// one holding the user's browser.
+func authorizeRedirect(c echo.Context, redirect string) error {
+	return r.CustomErrorJson(http.StatusOK, map[string]string{"redirect": redirect}, c)
+}
+
+func authorizeError(c echo.Context, params *AuthorizeSchema, e *oauth.Error) error {
+
+	redirect, err := oauth.ErrorRedirectURL(params.RedirectURI, params.State, e)
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return authorizeRedirect(c, redirect)
+}
+
+func issueAuthorizationCode(c echo.Context, params *AuthorizeSchema, user request.User, scopes []string) error {
+
+	// auth_time of the ID token is when the user signed in, not now
+	session, err := repository.SessionFindByID(user.SID)
+	if err != nil {
+		return err
+	}
+
+	code, err := repository.CreateOAuthCode(params.ClientID, user.ID, params.RedirectURI, scopes, params.CodeChallenge, params.CodeChallengeMethod, params.Nonce, session.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"code": {code}}
+	if params.State != "" {
+		query.Set("state", params.State)
+	}
+
+	redirect, err := oauth.RedirectURL(params.RedirectURI, query)
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return authorizeRedirect(c, redirect)
+}
+
+// Authorize godoc
+// @Summary Start an authorization code flow
+// @Description Issues a code right away when the user already consented to the scopes, otherwise responds with the consent to ask for.
+// @Tags oauth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param response_type query string true "code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Redirect URI"
+// @Param scope query string false "Scope"
+// @Param state query string false "State"
+// @Param code_challenge query string true "PKCE challenge"
+// @Param code_challenge_method query string true "S256"
+// @Param nonce query string false "Nonce of the ID token"
+// @Success 200 {object} response.Message
+// @Router /oauth/authorize [get]
+func Authorize(c echo.Context) (err error) {
+
+	params := new(AuthorizeSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	client, scopes, err := checkAuthorizeRequest(params)
+	if e, ok := err.(*oauth.Error); ok {
+		return authorizeError(c, params, e)
+	}
+	if err != nil {
+		return err
+	}
+
+	user := request.AuthenticatedUser(c)
+
+	account, err := repository.GetAccountInfo(user.ID)
+	if err != nil {
+		return err
+	}
+
+	if !account.IsActive {
+		return errors.ErrAccessDenied
+	}
+
+	consent, err := repository.GetOAuthConsent(user.ID, client.ClientID)
+	switch {
+	case err == errors.ErrOAuthConsentNotFound:
+	case err != nil:
+		return err
+	case oauth.Covers(consent.Scopes, scopes):
+		return issueAuthorizationCode(c, params, user, scopes)
+	}
+
+	data := map[string]AuthorizationConsent{
+		"consent": {client, scopes},
+	}
+
+	return r.CustomErrorJson(http.StatusOK, data, c)
+}
+
+// ApproveAuthorization godoc
+// @Summary Answer an authorization consent
+// @Tags oauth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param approve body bool true "Approve"
+// @Success 200 {object} response.Message
+// @Router /oauth/authorize [post]
+func ApproveAuthorization(c echo.Context) (err error) {
+
+	params := new(ApproveAuthorizationSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	client, scopes, err := checkAuthorizeRequest(&params.AuthorizeSchema)
+	if e, ok := err.(*oauth.Error); ok {
+		return authorizeError(c, &params.AuthorizeSchema, e)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !params.Approve {
+		return authorizeError(c, &params.AuthorizeSchema, oauth.ErrAccessDenied)
+	}
+
+	user := request.AuthenticatedUser(c)
+
+	account, err := repository.GetAccountInfo(user.ID)
+	if err != nil {
+		return err
+	}
+
+	if !account.IsActive {
+		return errors.ErrAccessDenied
+	}
+
+	if err = repository.SaveOAuthConsent(user.ID, client.ClientID, scopes); err != nil {
+		return err
+	}
+
+	return issueAuthorizationCode(c, &params.AuthorizeSchema, user, scopes)
+}
+
+func activeOAuthUser(userID string) error {
+
+	user, err := repository.GetAccountInfo(userID)
+	if err != nil || !user.IsActive {
+		return oauth.ErrInvalidGrant
+	}
+
+	return nil
+}
+
+func exchangeOAuthCode(c echo.Context, client *model.OAuthClient) (*TokenResponse, error) {
+
+	code, err := repository.UseOAuthCode(c.FormValue("code"), client.ClientID)
+	if err != nil {
+		return nil, oauth.ErrInvalidGrant
+	}
+
+	if code.RedirectURI != c.FormValue("redirect_uri") {
+		return nil, oauth.ErrInvalidGrant
+	}
+
+	if !oauth.VerifyCodeChallenge(c.FormValue("code_verifier"), code.CodeChallenge, code.CodeChallengeMethod) {
+		return nil, oauth.ErrInvalidGrant
+	}
+
+	if err = activeOAuthUser(code.UserID); err != nil {
+		return nil, err
+	}
+
+	response, err := issueOAuthTokens(client, code.UserID, code.Scopes)
+	if err != nil || !oauth.Contains(code.Scopes, oauth.ScopeOpenID) {
+		return response, err
+	}
+
+	response.IDToken, err = issueIDToken(client, code)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func refreshOAuthToken(c echo.Context, client *model.OAuthClient) (*TokenResponse, error) {
+
+	token, err := repository.UseOAuthRefreshToken(c.FormValue("refresh_token"), client.ClientID)
+	if err != nil {
+		return nil, oauth.ErrInvalidGrant
+	}
+
+	// A refresh may narrow the scopes but never extend them
+	scopes, err := requestedScopes(c.FormValue("scope"), token.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = activeOAuthUser(token.UserID); err != nil {
+		return nil, err
+	}
+
+	return issueOAuthTokens(client, token.UserID, scopes)
+}
+
+func clientCredentials(c echo.Context, client *model.OAuthClient) (*TokenResponse, error) {
+
+	if client.Public {
+		return nil, oauth.ErrUnauthorizedClient
+	}
+
+	scopes, err := requestedScopes(c.FormValue("scope"), client.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	access, _, err := repository.CreateOAuthTokens(client.ClientID, "", scopes, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		AccessToken: access,
+		TokenType:   oauth.TokenTypeBearer,
+		ExpiresIn:   int64(config.OAuthAccessTokenLifetime.Seconds()),
+		Scope:       oauth.FormatScope(scopes),
+	}, nil
+}
+
+func issueOAuthTokens(client *model.OAuthClient, userID string, scopes []string) (*TokenResponse, error) {
+
+	refresh := oauth.Contains(client.GrantTypes, oauth.GrantRefreshToken)
+	access, refreshToken, err := repository.CreateOAuthTokens(client.ClientID, userID, scopes, refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenResponse{
+		AccessToken:  access,
+		TokenType:    oauth.TokenTypeBearer,
+		ExpiresIn:    int64(config.OAuthAccessTokenLifetime.Seconds()),
+		RefreshToken: refreshToken,
+		Scope:        oauth.FormatScope(scopes),
+	}, nil
+}
+
+// Token godoc
+// @Summary Exchange a grant for tokens
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code, refresh_token or client_credentials"
+// @Success 200 {object} controller.TokenResponse
+// @Router /oauth/token [post]
+func Token(c echo.Context) (err error) {
+
+	client, err := authenticateOAuthClient(c)
+	if err != nil {
+		return oauthError(c, err)
+	}
+
+	grant := c.FormValue("grant_type")
+	var response *TokenResponse
+
+	switch {
+	case grant != oauth.GrantAuthorizationCode && grant != oauth.GrantRefreshToken && grant != oauth.GrantClientCredentials:
+		err = oauth.ErrUnsupportedGrantType
+	case !oauth.Contains(client.GrantTypes, grant):
+		err = oauth.ErrUnauthorizedClient
+	case grant == oauth.GrantAuthorizationCode:
+		response, err = exchangeOAuthCode(c, client)
+	case grant == oauth.GrantRefreshToken:
+		response, err = refreshOAuthToken(c, client)
+	default:
+		response, err = clientCredentials(c, client)
+	}
+
+	if err != nil {
+		return oauthError(c, err)
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	c.Response().Header().Set("Pragma", "no-cache")
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// Introspect godoc
+// @Summary Introspect a token
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Token"
+// @Success 200 {object} controller.IntrospectionResponse
+// @Router /oauth/introspect [post]
+func Introspect(c echo.Context) (err error) {
+
+	client, err := authenticateOAuthClient(c)
+	if err != nil {
+		return oauthError(c, err)
+	}
+
+	if client.Public {
+		return oauthError(c, oauth.ErrInvalidClient)
+	}
+
+	token, err := repository.FindOAuthToken(c.FormValue("token"))
+	if err != nil {
+		return c.JSON(http.StatusOK, IntrospectionResponse{Active: false})
+	}
+
+	tokenType := "access_token"
+	if token.Type == model.OAuthRefreshToken {
+		tokenType = "refresh_token"
+	}
+
+	return c.JSON(http.StatusOK, IntrospectionResponse{
+		Active:    true,
+		Scope:     oauth.FormatScope(token.Scopes),
+		ClientID:  token.ClientID,
+		Subject:   token.UserID,
+		TokenType: tokenType,
+		ExpiresAt: token.ExpireAt.Unix(),
+		IssuedAt:  token.CreatedAt.Unix(),
+	})
+}
+
+// Revoke godoc
+// @Summary Revoke a token
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Token"
+// @Success 200
+// @Router /oauth/revoke [post]
+func Revoke(c echo.Context) (err error) {
+
+	client, err := authenticateOAuthClient(c)
+	if err != nil {
+		return oauthError(c, err)
+	}
+
+	// RFC 7009, unknown tokens are not an error
+	err = repository.RevokeOAuthToken(c.FormValue("token"), client.ClientID)
+	if err != nil && err != errors.ErrOAuthGrantNotFound {
+		return oauthError(c, err)
+	}
+
+	return c.NoContent(http.StatusOK)
+}
+
+// OAuthConsents godoc
+// @Summary Get applications the user granted access to
+// @Tags oauth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Message
+// @Router /users/auth/oauth/consents [get]
+func OAuthConsents(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	consents, err := repository.GetUserOAuthConsents(user.ID)
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusOK, consents, c)
+}
+
+// RevokeOAuthConsent godoc
+// @Summary Revoke the access of an application
+// @Tags oauth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param clientId path string true "Client ID"
+// @Success 200 {object} response.Message
+// @Router /users/auth/oauth/consents/{clientId} [delete]
+func RevokeOAuthConsent(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	if err = repository.RevokeOAuthConsent(user.ID, c.Param("clientId")); err != nil {
+		return err
+	}
+
+	return errors.ErrSuccess
+}
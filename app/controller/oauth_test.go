@@ -0,0 +1,291 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/auth"
+	"github.com/thedevsir/frame-backend/services/errors"
+	j "github.com/thedevsir/frame-backend/services/jwt"
+	"github.com/thedevsir/frame-backend/services/keyring"
+	"github.com/thedevsir/frame-backend/services/oauth"
+	"github.com/thedevsir/frame-backend/services/test"
+)
+
+// oauthBeforeTest signs the user in with a real session, authorization codes
+// carry the time the session started.
+func oauthBeforeTest() (*model.User, *jwt.Token) {
+
+	user, _ := userBeforeTest()
+
+	sid, key, _, _ := repository.SessionCreate("127.0.0.1", user.Id.Hex(), ":::USER-AGENT:::", false)
+	token := &auth.UserToken{
+		Session: key,
+		SID:     sid,
+		ID:      user.Id.Hex(),
+	}
+
+	tc, _ := token.Create(keyring.User)
+	tokenParsed, _ := j.ParseSignedJWT(tc, keyring.User)
+
+	signer, _ := keyring.GenerateKey(keyring.RS256)
+	keyring.OIDC = keyring.NewRing(signer)
+
+	return user, tokenParsed
+}
+
+func oauthAfterTest() {
+	for _, collection := range []string{model.SessionCollection, model.OAuthClientCollection, model.OAuthConsentCollection, model.OAuthCodeCollection, model.OAuthTokenCollection} {
+		database.Connection.Model(collection).RemoveAll(nil)
+	}
+}
+
+func redirectParams(t *testing.T, body []byte) url.Values {
+
+	data := struct {
+		Message map[string]string
+	}{}
+	json.Unmarshal(body, &data)
+
+	redirect, err := url.Parse(data.Message["redirect"])
+	if !assert.Nil(t, err) || !assert.NotEmpty(t, data.Message["redirect"]) {
+		t.FailNow()
+	}
+	return redirect.Query()
+}
+
+func TestOAuth(t *testing.T) {
+
+	_, tokenParsed := oauthBeforeTest()
+	defer userAfterTest()
+	defer oauthAfterTest()
+
+	redirectURI := "https://app.example/callback"
+	grants := []string{oauth.GrantAuthorizationCode, oauth.GrantRefreshToken}
+	client, secret, _ := repository.CreateOAuthClient("App", false, []string{redirectURI}, grants, []string{"profile"})
+
+	verifier := strings.Repeat("v", 43)
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {client.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {"profile"},
+		"state":                 {"xyz"},
+		"code_challenge":        {oauth.CodeChallenge(verifier)},
+		"code_challenge_method": {oauth.ChallengeMethodS256},
+	}
+
+	authorize := func(query url.Values) (echo.Context, *httptest.ResponseRecorder) {
+		c, rec := test.MakeRequest(echo.GET, "")
+		c.Request().URL.RawQuery = query.Encode()
+		c.Set("user", tokenParsed)
+		return c, rec
+	}
+
+	approve := func(approve bool) (echo.Context, *httptest.ResponseRecorder) {
+		data := map[string]interface{}{"approve": approve}
+		for k := range query {
+			data[k] = query.Get(k)
+		}
+		JSONData, _ := json.Marshal(data)
+		c, rec := test.MakeRequest(echo.POST, string(JSONData))
+		c.Set("user", tokenParsed)
+		return c, rec
+	}
+
+	token := func(form url.Values) (int, map[string]interface{}) {
+		c, rec := test.MakeURLEncodedRequest(echo.POST, form)
+		c.Request().SetBasicAuth(client.ClientID, secret)
+		assert.NoError(t, Token(c))
+		body := map[string]interface{}{}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	var codes []string
+
+	t.Run("Authorize", func(t *testing.T) {
+
+		t.Run("ConsentRequired", func(t *testing.T) {
+
+			c, rec := authorize(query)
+			if assert.NoError(t, Authorize(c)) {
+				assert.Contains(t, rec.Body.String(), `"consent"`)
+			}
+		})
+
+		t.Run("UnknownRedirectURI", func(t *testing.T) {
+
+			wrong := url.Values{}
+			for k, v := range query {
+				wrong[k] = v
+			}
+			wrong.Set("redirect_uri", "https://evil.example/callback")
+			c, _ := authorize(wrong)
+			assert.Equal(t, errors.ErrRedirectURIInvalid, Authorize(c))
+		})
+
+		t.Run("MissingPKCE", func(t *testing.T) {
+
+			withoutPKCE := url.Values{}
+			for k, v := range query {
+				withoutPKCE[k] = v
+			}
+			withoutPKCE.Del("code_challenge")
+			c, rec := authorize(withoutPKCE)
+			if assert.NoError(t, Authorize(c)) {
+				params := redirectParams(t, rec.Body.Bytes())
+				assert.Equal(t, "invalid_request", params.Get("error"))
+				assert.Equal(t, "xyz", params.Get("state"))
+			}
+		})
+	})
+
+	t.Run("ApproveAuthorization", func(t *testing.T) {
+
+		t.Run("Denied", func(t *testing.T) {
+
+			c, rec := approve(false)
+			if assert.NoError(t, ApproveAuthorization(c)) {
+				assert.Equal(t, "access_denied", redirectParams(t, rec.Body.Bytes()).Get("error"))
+			}
+		})
+
+		t.Run("Success", func(t *testing.T) {
+
+			c, rec := approve(true)
+			if assert.NoError(t, ApproveAuthorization(c)) {
+				params := redirectParams(t, rec.Body.Bytes())
+				assert.Equal(t, "xyz", params.Get("state"))
+				codes = append(codes, params.Get("code"))
+			}
+		})
+
+		t.Run("AlreadyConsented", func(t *testing.T) {
+
+			c, rec := authorize(query)
+			if assert.NoError(t, Authorize(c)) {
+				codes = append(codes, redirectParams(t, rec.Body.Bytes()).Get("code"))
+			}
+		})
+	})
+
+	var refreshToken, accessToken string
+
+	t.Run("Token", func(t *testing.T) {
+
+		t.Run("WrongVerifier", func(t *testing.T) {
+
+			status, body := token(url.Values{
+				"grant_type":    {oauth.GrantAuthorizationCode},
+				"code":          {codes[0]},
+				"redirect_uri":  {redirectURI},
+				"code_verifier": {strings.Repeat("w", 43)},
+			})
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, "invalid_grant", body["error"])
+		})
+
+		t.Run("UsedCode", func(t *testing.T) {
+
+			status, _ := token(url.Values{
+				"grant_type":    {oauth.GrantAuthorizationCode},
+				"code":          {codes[0]},
+				"redirect_uri":  {redirectURI},
+				"code_verifier": {verifier},
+			})
+			assert.Equal(t, http.StatusBadRequest, status)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+
+			status, body := token(url.Values{
+				"grant_type":    {oauth.GrantAuthorizationCode},
+				"code":          {codes[1]},
+				"redirect_uri":  {redirectURI},
+				"code_verifier": {verifier},
+			})
+			assert.Equal(t, http.StatusOK, status)
+			assert.Equal(t, "profile", body["scope"])
+			accessToken, _ = body["access_token"].(string)
+			refreshToken, _ = body["refresh_token"].(string)
+			assert.NotEmpty(t, accessToken)
+			assert.NotEmpty(t, refreshToken)
+		})
+
+		t.Run("InvalidClient", func(t *testing.T) {
+
+			c, rec := test.MakeURLEncodedRequest(echo.POST, url.Values{"grant_type": {oauth.GrantRefreshToken}})
+			c.Request().SetBasicAuth(client.ClientID, "wrong")
+			assert.NoError(t, Token(c))
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		})
+
+		t.Run("UnauthorizedGrant", func(t *testing.T) {
+
+			_, body := token(url.Values{"grant_type": {oauth.GrantClientCredentials}})
+			assert.Equal(t, "unauthorized_client", body["error"])
+		})
+	})
+
+	t.Run("RefreshToken", func(t *testing.T) {
+
+		t.Run("Success", func(t *testing.T) {
+
+			status, body := token(url.Values{"grant_type": {oauth.GrantRefreshToken}, "refresh_token": {refreshToken}})
+			assert.Equal(t, http.StatusOK, status)
+			assert.NotEqual(t, refreshToken, body["refresh_token"])
+		})
+
+		t.Run("Rotated", func(t *testing.T) {
+
+			status, body := token(url.Values{"grant_type": {oauth.GrantRefreshToken}, "refresh_token": {refreshToken}})
+			assert.Equal(t, http.StatusBadRequest, status)
+			assert.Equal(t, "invalid_grant", body["error"])
+		})
+	})
+
+	t.Run("Introspect", func(t *testing.T) {
+
+		c, rec := test.MakeURLEncodedRequest(echo.POST, url.Values{"token": {accessToken}})
+		c.Request().SetBasicAuth(client.ClientID, secret)
+
+		if assert.NoError(t, Introspect(c)) {
+			body := IntrospectionResponse{}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			assert.True(t, body.Active)
+			assert.Equal(t, "profile", body.Scope)
+		}
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+
+		c, rec := test.MakeURLEncodedRequest(echo.POST, url.Values{"token": {accessToken}})
+		c.Request().SetBasicAuth(client.ClientID, secret)
+
+		if assert.NoError(t, Revoke(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			_, err := repository.FindOAuthToken(accessToken)
+			assert.Equal(t, errors.ErrOAuthGrantNotFound, err)
+		}
+	})
+
+	t.Run("RevokeOAuthConsent", func(t *testing.T) {
+
+		c, _ := test.MakeRequest(echo.DELETE, "")
+		c.Set("user", tokenParsed)
+		c.SetParamNames("clientId")
+		c.SetParamValues(client.ClientID)
+		assert.Equal(t, errors.ErrSuccess, RevokeOAuthConsent(c))
+	})
+}
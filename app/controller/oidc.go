@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/auth"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/keyring"
+	"github.com/thedevsir/frame-backend/services/oauth"
+)
+
+type (
+	OpenIDConfigurationResponse struct {
+		Issuer                            string   `json:"issuer"`
+		AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+		TokenEndpoint                     string   `json:"token_endpoint"`
+		UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+		JWKSURI                           string   `json:"jwks_uri"`
+		RevocationEndpoint                string   `json:"revocation_endpoint"`
+		IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+		ResponseTypesSupported            []string `json:"response_types_supported"`
+		SubjectTypesSupported             []string `json:"subject_types_supported"`
+		IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+		ScopesSupported                   []string `json:"scopes_supported"`
+		TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+		GrantTypesSupported               []string `json:"grant_types_supported"`
+		CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+		ClaimsSupported                   []string `json:"claims_supported"`
+	}
+	UserInfoResponse struct {
+		Subject           string `json:"sub"`
+		PreferredUsername string `json:"preferred_username,omitempty"`
+		UpdatedAt         int64  `json:"updated_at,omitempty"`
+		Email             string `json:"email,omitempty"`
+		EmailVerified     *bool  `json:"email_verified,omitempty"`
+	}
+)
+
+func issueIDToken(client *model.OAuthClient, code *model.OAuthCode) (string, error) {
+
+	now := time.Now()
+	token := &auth.IDToken{
+		AuthTime: code.AuthTime.Unix(),
+		Nonce:    code.Nonce,
+		StandardClaims: jwt.StandardClaims{
+			Issuer:    config.OIDCIssuer,
+			Subject:   code.UserID,
+			Audience:  client.ClientID,
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.Add(config.OIDCIDTokenLifetime).Unix(),
+		},
+	}
+
+	return token.Create(keyring.OIDC)
+}
+
+// bearerToken reads an access token from the Authorization header or, as
+// RFC 6750 allows, from the request body.
+func bearerToken(c echo.Context) string {
+
+	header := c.Request().Header.Get(echo.HeaderAuthorization)
+	if len(header) > len(oauth.TokenTypeBearer) && strings.EqualFold(header[:len(oauth.TokenTypeBearer)+1], oauth.TokenTypeBearer+" ") {
+		return header[len(oauth.TokenTypeBearer)+1:]
+	}
+
+	return c.FormValue("access_token")
+}
+
+// OpenIDConfiguration godoc
+// @Summary OpenID Connect discovery document
+// @Tags oidc
+// @Produce json
+// @Success 200 {object} controller.OpenIDConfigurationResponse
+// @Router /.well-known/openid-configuration [get]
+func OpenIDConfiguration(c echo.Context) (err error) {
+
+	issuer := strings.TrimSuffix(config.OIDCIssuer, "/")
+
+	return c.JSON(http.StatusOK, OpenIDConfigurationResponse{
+		Issuer:                            config.OIDCIssuer,
+		AuthorizationEndpoint:             config.OAuthAuthorizeLink,
+		TokenEndpoint:                     issuer + "/oauth/token",
+		UserInfoEndpoint:                  issuer + "/oauth/userinfo",
+		JWKSURI:                           issuer + "/oauth/jwks",
+		RevocationEndpoint:                issuer + "/oauth/revoke",
+		IntrospectionEndpoint:             issuer + "/oauth/introspect",
+		ResponseTypesSupported:            []string{oauth.ResponseTypeCode},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{keyring.OIDC.Active().Algorithm},
+		ScopesSupported:                   []string{oauth.ScopeOpenID, oauth.ScopeProfile, oauth.ScopeEmail},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		GrantTypesSupported:               []string{oauth.GrantAuthorizationCode, oauth.GrantRefreshToken, oauth.GrantClientCredentials},
+		CodeChallengeMethodsSupported:     []string{oauth.ChallengeMethodS256},
+		ClaimsSupported:                   []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "preferred_username", "updated_at", "email", "email_verified"},
+	})
+}
+
+// JWKS godoc
+// @Summary Public keys that verify ID tokens
+// @Tags oidc
+// @Produce json
+// @Success 200 {object} keyring.JWKS
+// @Router /oauth/jwks [get]
+func JWKS(c echo.Context) (err error) {
+	return c.JSON(http.StatusOK, keyring.OIDC.JWKS())
+}
+
+// UserInfo godoc
+// @Summary Claims about the user an access token was issued for
+// @Tags oidc
+// @Produce json
+// @Param Authorization header string true "Bearer access token"
+// @Success 200 {object} controller.UserInfoResponse
+// @Router /oauth/userinfo [get]
+func UserInfo(c echo.Context) (err error) {
+
+	token, err := repository.FindOAuthToken(bearerToken(c))
+	switch {
+	case err == errors.ErrOAuthGrantNotFound:
+		return oauthError(c, oauth.ErrInvalidToken)
+	case err != nil:
+		return oauthError(c, err)
+	case token.Type != model.OAuthAccessToken || token.UserID == "":
+		return oauthError(c, oauth.ErrInvalidToken)
+	case !oauth.Contains(token.Scopes, oauth.ScopeOpenID):
+		return oauthError(c, oauth.ErrInsufficientScope)
+	}
+
+	user, err := repository.GetAccountInfo(token.UserID)
+	if err != nil || !user.IsActive {
+		return oauthError(c, oauth.ErrInvalidToken)
+	}
+
+	response := UserInfoResponse{Subject: token.UserID}
+
+	if oauth.Contains(token.Scopes, oauth.ScopeProfile) {
+		response.PreferredUsername = user.Username
+		response.UpdatedAt = user.UpdatedAt.Unix()
+	}
+
+	if oauth.Contains(token.Scopes, oauth.ScopeEmail) {
+		response.Email = user.Email
+		response.EmailVerified = &user.IsEmailVerified
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
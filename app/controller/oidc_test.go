@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/auth"
+	"github.com/thedevsir/frame-backend/services/keyring"
+	"github.com/thedevsir/frame-backend/services/oauth"
+	"github.com/thedevsir/frame-backend/services/test"
+)
+
+func TestOpenIDConnect(t *testing.T) {
+
+	user, tokenParsed := oauthBeforeTest()
+	defer userAfterTest()
+	defer oauthAfterTest()
+
+	userID := user.Id.Hex()
+	redirectURI := "https://app.example/callback"
+	scopes := []string{oauth.ScopeOpenID, oauth.ScopeProfile, oauth.ScopeEmail}
+	client, secret, _ := repository.CreateOAuthClient("App", false, []string{redirectURI}, []string{oauth.GrantAuthorizationCode}, scopes)
+	repository.SaveOAuthConsent(userID, client.ClientID, scopes)
+
+	userInfo := func(accessToken string) (int, UserInfoResponse) {
+		c, rec := test.MakeRequest(echo.GET, "")
+		c.Request().Header.Set(echo.HeaderAuthorization, "Bearer "+accessToken)
+		assert.NoError(t, UserInfo(c))
+		body := UserInfoResponse{}
+		json.Unmarshal(rec.Body.Bytes(), &body)
+		return rec.Code, body
+	}
+
+	var accessToken string
+
+	t.Run("IDToken", func(t *testing.T) {
+
+		verifier := strings.Repeat("v", 43)
+		query := url.Values{
+			"response_type":         {"code"},
+			"client_id":             {client.ClientID},
+			"redirect_uri":          {redirectURI},
+			"scope":                 {"openid email"},
+			"nonce":                 {"n-0S6_WzA2Mj"},
+			"code_challenge":        {oauth.CodeChallenge(verifier)},
+			"code_challenge_method": {oauth.ChallengeMethodS256},
+		}
+
+		c, rec := test.MakeRequest(echo.GET, "")
+		c.Request().URL.RawQuery = query.Encode()
+		c.Set("user", tokenParsed)
+		if !assert.NoError(t, Authorize(c)) {
+			return
+		}
+		code := redirectParams(t, rec.Body.Bytes()).Get("code")
+
+		c, rec = test.MakeURLEncodedRequest(echo.POST, url.Values{
+			"grant_type":    {oauth.GrantAuthorizationCode},
+			"code":          {code},
+			"redirect_uri":  {redirectURI},
+			"code_verifier": {verifier},
+		})
+		c.Request().SetBasicAuth(client.ClientID, secret)
+		if !assert.NoError(t, Token(c)) || !assert.Equal(t, http.StatusOK, rec.Code) {
+			return
+		}
+
+		response := TokenResponse{}
+		json.Unmarshal(rec.Body.Bytes(), &response)
+		accessToken = response.AccessToken
+
+		claims := &auth.IDToken{}
+		_, err := keyring.OIDC.Parse(response.IDToken, claims)
+		if assert.Nil(t, err) {
+			assert.Equal(t, config.OIDCIssuer, claims.Issuer)
+			assert.Equal(t, userID, claims.Subject)
+			assert.Equal(t, client.ClientID, claims.Audience)
+			assert.Equal(t, "n-0S6_WzA2Mj", claims.Nonce)
+			assert.NotZero(t, claims.AuthTime)
+		}
+	})
+
+	t.Run("UserInfo", func(t *testing.T) {
+
+		status, body := userInfo(accessToken)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, userID, body.Subject)
+		assert.Equal(t, user.Email, body.Email)
+		if assert.NotNil(t, body.EmailVerified) {
+			assert.False(t, *body.EmailVerified)
+		}
+		// profile was not requested
+		assert.Empty(t, body.PreferredUsername)
+	})
+
+	t.Run("UserInfoInsufficientScope", func(t *testing.T) {
+
+		access, _, _ := repository.CreateOAuthTokens(client.ClientID, userID, []string{oauth.ScopeProfile}, false)
+		status, _ := userInfo(access)
+		assert.Equal(t, http.StatusForbidden, status)
+	})
+
+	t.Run("UserInfoInvalidToken", func(t *testing.T) {
+
+		status, _ := userInfo("invalid")
+		assert.Equal(t, http.StatusUnauthorized, status)
+	})
+
+	t.Run("OpenIDConfiguration", func(t *testing.T) {
+
+		c, rec := test.MakeRequest(echo.GET, "")
+		if assert.NoError(t, OpenIDConfiguration(c)) {
+			body := OpenIDConfigurationResponse{}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			assert.Equal(t, config.OIDCIssuer, body.Issuer)
+			assert.Contains(t, body.JWKSURI, "/oauth/jwks")
+			assert.Contains(t, body.CodeChallengeMethodsSupported, oauth.ChallengeMethodS256)
+		}
+	})
+
+	t.Run("JWKS", func(t *testing.T) {
+
+		c, rec := test.MakeRequest(echo.GET, "")
+		if assert.NoError(t, JWKS(c)) {
+			body := keyring.JWKS{}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			if assert.Len(t, body.Keys, 1) {
+				assert.Equal(t, keyring.OIDC.Active().ID, body.Keys[0].Kid)
+			}
+		}
+	})
+}
@@ -0,0 +1,320 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/auth"
+	"github.com/thedevsir/frame-backend/services/errors"
+	j "github.com/thedevsir/frame-backend/services/jwt"
+	"github.com/thedevsir/frame-backend/services/paginate"
+	"github.com/thedevsir/frame-backend/services/request"
+	r "github.com/thedevsir/frame-backend/services/response"
+	"github.com/thedevsir/frame-backend/services/webauthn"
+)
+
+const (
+	passkeyRegisterAction = "passkey-register"
+	passkeySigninAction   = "passkey-signin"
+)
+
+type (
+	RegisterPasskeySchema struct {
+		Challenge         string `json:"challenge" validate:"required"`
+		Name              string `json:"name" validate:"required,max=64"`
+		ClientDataJSON    string `json:"clientDataJSON" validate:"required"`
+		AttestationObject string `json:"attestationObject" validate:"required"`
+	}
+	SigninPasskeySchema struct {
+		Challenge         string `json:"challenge" validate:"required"`
+		CredentialID      string `json:"credentialId" validate:"required"`
+		ClientDataJSON    string `json:"clientDataJSON" validate:"required"`
+		AuthenticatorData string `json:"authenticatorData" validate:"required"`
+		Signature         string `json:"signature" validate:"required"`
+	}
+	PasskeyCeremony struct {
+		Challenge string      `json:"challenge"`
+		Options   interface{} `json:"options"`
+	}
+)
+
+func relyingParty() *webauthn.RelyingParty {
+
+	return &webauthn.RelyingParty{
+		ID:      config.WebAuthnRPID,
+		Name:    config.WebAuthnRPName,
+		Origins: strings.Split(config.WebAuthnOrigins, ","),
+		Timeout: config.WebAuthnTimeout,
+	}
+}
+
+// passkeyCeremony creates the challenge and the signed token that carries it
+// between the two steps of a ceremony.
+func passkeyCeremony(action, userID string) (challenge, token string, err error) {
+
+	challenge, err = webauthn.NewChallenge()
+	if err != nil {
+		return "", "", errors.ErrInternal
+	}
+
+	ceremony := &auth.PasskeyToken{
+		Action:    action,
+		ID:        userID,
+		Challenge: challenge,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(config.WebAuthnTimeout).Unix(),
+		},
+	}
+	token, err = ceremony.Create([]byte(config.SigningKey))
+	if err != nil {
+		return "", "", err
+	}
+
+	return challenge, token, nil
+}
+
+func decodePasskeyInputs(values ...string) ([][]byte, error) {
+
+	decoded := make([][]byte, len(values))
+	for i, value := range values {
+		data, err := webauthn.DecodeBase64(value)
+		if err != nil {
+			return nil, errors.ErrInvalidParams
+		}
+		decoded[i] = data
+	}
+
+	return decoded, nil
+}
+
+// BeginPasskeyRegistration godoc
+// @Summary Begin passkey registration
+// @Tags passkey
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Message
+// @Router /users/auth/passkeys/register [post]
+func BeginPasskeyRegistration(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	account, err := repository.GetAccountInfo(user.ID)
+	if err != nil {
+		return err
+	}
+
+	if !account.IsActive {
+		return errors.ErrAccessDenied
+	}
+
+	exclude, err := repository.GetPasskeyCredentialIDs(user.ID)
+	if err != nil {
+		return err
+	}
+
+	challenge, token, err := passkeyCeremony(passkeyRegisterAction, user.ID)
+	if err != nil {
+		return err
+	}
+
+	entity := webauthn.Entity{
+		ID:          webauthn.EncodeBase64([]byte(user.ID)),
+		Name:        account.Username,
+		DisplayName: account.Username,
+	}
+
+	data := PasskeyCeremony{
+		Challenge: token,
+		Options:   relyingParty().CreationOptions(challenge, entity, exclude),
+	}
+
+	return r.CustomErrorJson(http.StatusOK, data, c)
+}
+
+// RegisterPasskey godoc
+// @Summary Finish passkey registration
+// @Tags passkey
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param challenge body string true "Challenge"
+// @Param name body string true "Name"
+// @Param clientDataJSON body string true "Client data"
+// @Param attestationObject body string true "Attestation object"
+// @Success 201 {object} response.Message
+// @Router /users/auth/passkeys [post]
+func RegisterPasskey(c echo.Context) (err error) {
+
+	params := new(RegisterPasskeySchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	user := request.AuthenticatedUser(c)
+
+	ceremony, err := j.ParsePasskeyToken(params.Challenge, passkeyRegisterAction, []byte(config.SigningKey))
+	if err != nil {
+		return err
+	}
+
+	if ceremony.UserID != user.ID {
+		return errors.ErrAccessDenied
+	}
+
+	inputs, err := decodePasskeyInputs(params.ClientDataJSON, params.AttestationObject)
+	if err != nil {
+		return err
+	}
+
+	credential, err := relyingParty().VerifyRegistration(ceremony.Challenge, inputs[0], inputs[1])
+	if err != nil {
+		return errors.ErrPasskeyInvalid
+	}
+
+	passkey, err := repository.CreatePasskey(user.ID, params.Name, credential)
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusCreated, passkey, c)
+}
+
+// Passkeys godoc
+// @Summary Get user passkeys
+// @Tags passkey
+// @Produce json
+// @Param page query number false "Page"
+// @Param limit query number false "Limit"
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Message
+// @Router /users/auth/passkeys [get]
+func Passkeys(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	page, limit := paginate.HandleQueries(c)
+	passkeys, err := repository.GetUserPasskeys(user.ID, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusOK, passkeys, c)
+}
+
+// RemovePasskey godoc
+// @Summary Remove passkey
+// @Tags passkey
+// @Produce json
+// @Param id path string true "Passkey ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Message
+// @Router /users/auth/passkeys/{id} [delete]
+func RemovePasskey(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	if err = repository.RemovePasskey(user.ID, c.Param("id")); err != nil {
+		return err
+	}
+
+	return errors.ErrSuccess
+}
+
+// BeginPasskeySignin godoc
+// @Summary Begin passkey signin
+// @Tags user
+// @Produce json
+// @Success 200 {object} response.Message
+// @Router /users/signin/passkey/begin [post]
+func BeginPasskeySignin(c echo.Context) (err error) {
+
+	challenge, token, err := passkeyCeremony(passkeySigninAction, "")
+	if err != nil {
+		return err
+	}
+
+	// Passkeys are discoverable, the authenticator picks the account
+	data := PasskeyCeremony{
+		Challenge: token,
+		Options:   relyingParty().RequestOptions(challenge, nil),
+	}
+
+	return r.CustomErrorJson(http.StatusOK, data, c)
+}
+
+// SigninPasskey godoc
+// @Summary Complete signin with a passkey
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param challenge body string true "Challenge"
+// @Param credentialId body string true "Credential ID"
+// @Param clientDataJSON body string true "Client data"
+// @Param authenticatorData body string true "Authenticator data"
+// @Param signature body string true "Signature"
+// @Success 200 {object} response.Message
+// @Router /users/signin/passkey [post]
+func SigninPasskey(c echo.Context) (err error) {
+
+	ip := request.ClientIP(c)
+
+	params := new(SigninPasskeySchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	ceremony, err := j.ParsePasskeyToken(params.Challenge, passkeySigninAction, []byte(config.SigningKey))
+	if err != nil {
+		return err
+	}
+
+	// Attempts are recorded lowercased, as usernames are
+	identifier := strings.ToLower(params.CredentialID)
+	if err = checkAbuse(c, identifier); err != nil {
+		return err
+	}
+
+	inputs, err := decodePasskeyInputs(params.ClientDataJSON, params.AuthenticatorData, params.Signature)
+	if err != nil {
+		return err
+	}
+
+	passkey, err := repository.FindPasskey(params.CredentialID)
+	if err != nil {
+		repository.SubmitAttempt(ip, identifier)
+		return errors.ErrPasskeyInvalid
+	}
+
+	credential := &webauthn.Credential{
+		ID:        passkey.CredentialID,
+		PublicKey: passkey.PublicKey,
+		Algorithm: passkey.Algorithm,
+		SignCount: uint32(passkey.SignCount),
+	}
+
+	signCount, err := relyingParty().VerifyAssertion(ceremony.Challenge, credential, inputs[0], inputs[1], inputs[2])
+	if err != nil {
+		repository.SubmitAttempt(ip, identifier)
+		return errors.ErrPasskeyInvalid
+	}
+
+	if err = repository.UsePasskey(passkey.Id.Hex(), ceremony.Challenge, signCount); err != nil {
+		return err
+	}
+
+	user, err := repository.GetAccountInfo(passkey.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsActive {
+		return errors.ErrAccessDenied
+	}
+
+	return signinUser(c, passkey.UserID, false)
+}
@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/test"
+)
+
+func TestPasskey(t *testing.T) {
+
+	_, tokenParsed := userBeforeTest()
+	defer userAfterTest()
+
+	passkeyCollection := database.Connection.Model(model.PasskeyCollection)
+	passkeyCollection.RemoveAll(nil)
+	defer passkeyCollection.RemoveAll(nil)
+
+	origin := strings.Split(config.WebAuthnOrigins, ",")[0]
+	authenticator := test.NewAuthenticator(config.WebAuthnRPID, origin, false)
+
+	var passkeyID string
+
+	ceremony := func(handler echo.HandlerFunc, authenticated bool) (token, challenge string) {
+
+		c, rec := test.MakeRequest(echo.POST, "")
+		if authenticated {
+			c.Set("user", tokenParsed)
+		}
+
+		if assert.NoError(t, handler(c)) {
+			body := struct {
+				Message struct {
+					Challenge string
+					Options   struct {
+						Challenge string
+					}
+				}
+			}{}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			token, challenge = body.Message.Challenge, body.Message.Options.Challenge
+		}
+		return token, challenge
+	}
+
+	t.Run("RegisterPasskey", func(t *testing.T) {
+
+		token, challenge := ceremony(BeginPasskeyRegistration, true)
+
+		t.Run("WrongChallenge", func(t *testing.T) {
+
+			clientData, attestation := authenticator.Register("challenge")
+			JSONData := fmt.Sprintf(`{"challenge":"%s","name":"Laptop","clientDataJSON":"%s","attestationObject":"%s"}`, token, clientData, attestation)
+			c, _ := test.MakeRequest(echo.POST, JSONData)
+			c.Set("user", tokenParsed)
+			assert.Equal(t, errors.ErrPasskeyInvalid, RegisterPasskey(c))
+		})
+
+		t.Run("Success", func(t *testing.T) {
+
+			clientData, attestation := authenticator.Register(challenge)
+			JSONData := fmt.Sprintf(`{"challenge":"%s","name":"Laptop","clientDataJSON":"%s","attestationObject":"%s"}`, token, clientData, attestation)
+			c, rec := test.MakeRequest(echo.POST, JSONData)
+			c.Set("user", tokenParsed)
+
+			if assert.NoError(t, RegisterPasskey(c)) {
+				assert.Equal(t, http.StatusCreated, rec.Code)
+				body := struct {
+					Message model.Passkey
+				}{}
+				json.Unmarshal(rec.Body.Bytes(), &body)
+				passkeyID = body.Message.Id.Hex()
+			}
+		})
+
+		t.Run("AlreadyRegistered", func(t *testing.T) {
+
+			clientData, attestation := authenticator.Register(challenge)
+			JSONData := fmt.Sprintf(`{"challenge":"%s","name":"Laptop","clientDataJSON":"%s","attestationObject":"%s"}`, token, clientData, attestation)
+			c, _ := test.MakeRequest(echo.POST, JSONData)
+			c.Set("user", tokenParsed)
+			assert.Equal(t, errors.ErrPasskeyExists, RegisterPasskey(c))
+		})
+	})
+
+	t.Run("Passkeys", func(t *testing.T) {
+
+		c, rec := test.MakeRequest(echo.GET, "")
+		c.Set("user", tokenParsed)
+
+		if assert.NoError(t, Passkeys(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+		}
+	})
+
+	t.Run("SigninPasskey", func(t *testing.T) {
+
+		token, challenge := ceremony(BeginPasskeySignin, false)
+		ID, clientData, authData, signature := authenticator.Assert(challenge)
+		JSONData := fmt.Sprintf(`{"challenge":"%s","credentialId":"%s","clientDataJSON":"%s","authenticatorData":"%s","signature":"%s"}`, token, ID, clientData, authData, signature)
+
+		t.Run("Success", func(t *testing.T) {
+
+			c, rec := test.MakeRequest(echo.POST, JSONData)
+
+			if assert.NoError(t, SigninPasskey(c)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.NotEmpty(t, rec.Header().Get(echo.HeaderAuthorization))
+			}
+		})
+
+		t.Run("Replayed", func(t *testing.T) {
+
+			c, _ := test.MakeRequest(echo.POST, JSONData)
+			assert.Equal(t, errors.ErrPasskeyInvalid, SigninPasskey(c))
+		})
+
+		t.Run("UnknownCredential", func(t *testing.T) {
+
+			other := test.NewAuthenticator(config.WebAuthnRPID, origin, true)
+			token, challenge := ceremony(BeginPasskeySignin, false)
+			ID, clientData, authData, signature := other.Assert(challenge)
+			JSONData := fmt.Sprintf(`{"challenge":"%s","credentialId":"%s","clientDataJSON":"%s","authenticatorData":"%s","signature":"%s"}`, token, ID, clientData, authData, signature)
+			c, _ := test.MakeRequest(echo.POST, JSONData)
+			assert.Equal(t, errors.ErrPasskeyInvalid, SigninPasskey(c))
+		})
+	})
+
+	t.Run("RemovePasskey", func(t *testing.T) {
+
+		c, _ := test.MakeRequest(echo.DELETE, "")
+		c.Set("user", tokenParsed)
+		c.SetParamNames("id")
+		c.SetParamValues(passkeyID)
+		assert.Equal(t, errors.ErrSuccess, RemovePasskey(c))
+	})
+}
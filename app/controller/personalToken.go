@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/oauth"
+	"github.com/thedevsir/frame-backend/services/paginate"
+	"github.com/thedevsir/frame-backend/services/request"
+	r "github.com/thedevsir/frame-backend/services/response"
+)
+
+type (
+	CreatePersonalTokenSchema struct {
+		Name      string   `json:"name" validate:"required,max=100"`
+		Scopes    []string `json:"scopes" validate:"required,dive,required"`
+		ExpiresIn int      `json:"expiresIn" validate:"min=0,max=365"`
+	}
+	CreatedPersonalToken struct {
+		*model.PersonalToken
+		Token string `json:"token"`
+	}
+)
+
+// CreatePersonalToken godoc
+// @Summary Create a personal access token
+// @Description The token is sent as a bearer token in place of the session JWT and only grants its scopes. It is shown once.
+// @Tags personalToken
+// @Accept json
+// @Produce json
+// @Param name body string true "Name"
+// @Param scopes body array true "Scopes, account:read, account:write or account:export"
+// @Param expiresIn body number false "Lifetime in days, it never expires when left out"
+// @Security ApiKeyAuth
+// @Success 201 {object} response.Message
+// @Router /users/auth/tokens [post]
+func CreatePersonalToken(c echo.Context) (err error) {
+
+	params := new(CreatePersonalTokenSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	scopes := oauth.ParseScope(oauth.FormatScope(params.Scopes))
+	if !oauth.Covers(model.PersonalTokenScopes, scopes) {
+		return errors.ErrScopeInvalid
+	}
+
+	user := request.AuthenticatedUser(c)
+	lifetime := time.Duration(params.ExpiresIn) * 24 * time.Hour
+
+	token, value, err := repository.CreatePersonalToken(user.ID, params.Name, scopes, lifetime)
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusCreated, CreatedPersonalToken{token, value}, c)
+}
+
+// PersonalTokens godoc
+// @Summary Get personal access tokens
+// @Description Lists the tokens with when and where they were last used.
+// @Tags personalToken
+// @Produce json
+// @Param page query number false "Page"
+// @Param limit query number false "Limit"
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Message
+// @Router /users/auth/tokens [get]
+func PersonalTokens(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	page, limit := paginate.HandleQueries(c)
+	tokens, err := repository.GetUserPersonalTokens(user.ID, page, limit)
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusOK, tokens, c)
+}
+
+// RevokePersonalToken godoc
+// @Summary Revoke a personal access token
+// @Tags personalToken
+// @Produce json
+// @Param id path string true "Token ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Message
+// @Router /users/auth/tokens/{id} [delete]
+func RevokePersonalToken(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	if err = repository.RevokePersonalToken(user.ID, c.Param("id")); err != nil {
+		return err
+	}
+
+	return errors.ErrSuccess
+}
@@ -0,0 +1,166 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/middleware/auth"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/keyring"
+	"github.com/thedevsir/frame-backend/services/mail"
+	"github.com/thedevsir/frame-backend/services/response"
+	"github.com/thedevsir/frame-backend/services/test"
+)
+
+func TestPersonalToken(t *testing.T) {
+
+	_, tokenParsed := userBeforeTest()
+	defer userAfterTest()
+
+	tokenCollection := database.Connection.Model(model.PersonalTokenCollection)
+	tokenCollection.RemoveAll(nil)
+	defer tokenCollection.RemoveAll(nil)
+
+	create := func(body string) (CreatedPersonalToken, error) {
+		c, rec := test.MakeRequest(echo.POST, body)
+		c.Set("user", tokenParsed)
+		data := struct {
+			Message CreatedPersonalToken
+		}{}
+		err := CreatePersonalToken(c)
+		json.Unmarshal(rec.Body.Bytes(), &data)
+		return data.Message, err
+	}
+
+	var created CreatedPersonalToken
+
+	t.Run("CreatePersonalToken", func(t *testing.T) {
+
+		t.Run("ScopeInvalid", func(t *testing.T) {
+			_, err := create(`{"name":"ci","scopes":["account:read","admin"]}`)
+			assert.Equal(t, errors.ErrScopeInvalid, err)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			var err error
+			created, err = create(`{"name":"ci","scopes":["account:read","account:read"],"expiresIn":30}`)
+			if assert.NoError(t, err) {
+				assert.Regexp(t, "^"+model.PersonalTokenPrefix, created.Token)
+				assert.Equal(t, []string{model.ScopeAccountRead}, created.Scopes)
+				assert.NotNil(t, created.ExpireAt)
+			}
+		})
+	})
+
+	t.Run("Middleware", func(t *testing.T) {
+
+		e := echo.New()
+		e.HTTPErrorHandler = response.ErrorHandler
+		g := e.Group("/auth", auth.JWT(keyring.User), auth.Middleware)
+		auth.Scoped(g.GET("/mine", GetAccount), model.ScopeAccountRead)
+		auth.Scoped(g.DELETE("/avatar", DeleteAvatar), model.ScopeAccountWrite)
+		g.GET("/tokens", PersonalTokens)
+
+		call := func(method, path, token string) int {
+			req := httptest.NewRequest(method, path, nil)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			return rec.Code
+		}
+
+		assert.Equal(t, http.StatusOK, call(echo.GET, "/auth/mine", created.Token))
+		assert.Equal(t, http.StatusForbidden, call(echo.DELETE, "/auth/avatar", created.Token))
+		assert.Equal(t, http.StatusForbidden, call(echo.GET, "/auth/tokens", created.Token))
+		assert.Equal(t, http.StatusUnauthorized, call(echo.GET, "/auth/mine", model.PersonalTokenPrefix+"forged"))
+	})
+
+	t.Run("PersonalTokens", func(t *testing.T) {
+
+		c, rec := test.MakeRequest(echo.GET, "")
+		c.Set("user", tokenParsed)
+		if assert.NoError(t, PersonalTokens(c)) {
+			assert.Contains(t, rec.Body.String(), `"lastUsedIp":"`)
+			assert.NotContains(t, rec.Body.String(), created.Token)
+		}
+	})
+
+	t.Run("RevokePersonalToken", func(t *testing.T) {
+
+		revoke := func() error {
+			c, _ := test.MakeRequest(echo.DELETE, "")
+			c.Set("user", tokenParsed)
+			c.SetParamNames("id")
+			c.SetParamValues(created.Id.Hex())
+			return RevokePersonalToken(c)
+		}
+
+		assert.Equal(t, errors.ErrSuccess, revoke())
+		assert.Equal(t, errors.ErrPersonalTokenNotFound, revoke())
+	})
+}
+
+func TestRevokePersonalTokens(t *testing.T) {
+
+	user, tokenParsed := userBeforeTest()
+	defer userAfterTest()
+
+	tokenCollection := database.Connection.Model(model.PersonalTokenCollection)
+	tokenCollection.RemoveAll(nil)
+	defer tokenCollection.RemoveAll(nil)
+
+	_SendResetMail = func(username, email, token string) error { return nil }
+	userID := user.Id.Hex()
+
+	// each takeover recovery leaves no token of the user behind
+	revoked := func(t *testing.T, recover func() error) {
+		_, value, _ := repository.CreatePersonalToken(userID, "ci", []string{model.ScopeAccountWrite}, 0)
+		assert.Equal(t, errors.ErrSuccess, recover())
+		_, err := repository.UsePersonalToken(value, "127.0.0.1")
+		assert.Equal(t, errors.ErrPersonalTokenInvalid, err)
+	}
+
+	t.Run("Reset", func(t *testing.T) {
+		revoked(t, func() error {
+			token, _ := emailToken(model.EmailTokenReset, user)
+			c, _ := test.MakeRequest(echo.PUT, `{"password":"87654321","token":"`+token+`"}`)
+			return Reset(c)
+		})
+	})
+
+	t.Run("AdminChangePassword", func(t *testing.T) {
+		revoked(t, func() error {
+			c, _ := test.MakeRequest(echo.PUT, `{"password":"12345678"}`)
+			c.SetParamNames("id")
+			c.SetParamValues(userID)
+			return AdminChangePassword(c)
+		})
+	})
+
+	t.Run("DenySignin", func(t *testing.T) {
+		revoked(t, func() error {
+			SID, _, _, _ := repository.SessionCreate("127.0.0.1", userID, ":::USER-AGENT:::", false)
+			ID, _ := repository.CreateSessionEmailToken(model.EmailTokenSigninAlert, userID, SID, time.Hour)
+			token, _ := mail.MakeSigninAlertToken(ID, userID, user.Username, user.Email, []byte(config.SigningKey))
+			c, _ := test.MakeRequest(echo.POST, `{"token":"`+token+`"}`)
+			return DenySignin(c)
+		})
+	})
+
+	t.Run("SignoutEverywhere", func(t *testing.T) {
+		revoked(t, func() error {
+			c, _ := test.MakeRequest(echo.DELETE, "")
+			c.Set("user", tokenParsed)
+			return SignoutEverywhere(c)
+		})
+	})
+}
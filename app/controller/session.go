@@ -2,8 +2,10 @@ package controller
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/model"
 	"github.com/thedevsir/frame-backend/app/repository"
 	"github.com/thedevsir/frame-backend/services/errors"
 	"github.com/thedevsir/frame-backend/services/paginate"
@@ -13,7 +15,13 @@ import (
 
 type (
 	SignoutSchema struct {
-		ID string `json:"id" validate:"required"`
+		ID string `json:"id"`
+	}
+	RefreshSessionSchema struct {
+		RefreshToken string `json:"refreshToken" validate:"required"`
+	}
+	LabelSessionSchema struct {
+		Label string `json:"label" validate:"max=50"`
 	}
 )
 
@@ -37,11 +45,45 @@ func Sessions(c echo.Context) (err error) {
 		return err
 	}
 
+	if data, ok := sessions.Data.([]*model.Session); ok {
+		for _, session := range data {
+			session.Current = session.Id.Hex() == user.SID
+		}
+	}
+
 	return r.CustomErrorJson(http.StatusOK, sessions, c)
 }
 
+// LabelSession godoc
+// @Summary Label session
+// @Description Names a session of the user, like "work laptop". An empty label removes the name.
+// @Tags session
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Param label body string false "Label"
+// @Success 200 {object} response.Message
+// @Router /users/auth/sessions/{id} [put]
+func LabelSession(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	params := new(LabelSessionSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	if err = repository.LabelSession(user.ID, c.Param("id"), strings.TrimSpace(params.Label)); err != nil {
+		return err
+	}
+
+	return errors.ErrSuccess
+}
+
 // Signout godoc
 // @Summary Delete session
+// @Description Signs out a session of the user from the sessions list, or the one of the request when no id is given.
 // @Tags session
 // @Accept json
 // @Produce json
@@ -58,6 +100,10 @@ func Signout(c echo.Context) (err error) {
 		return err
 	}
 
+	if params.ID == "" {
+		params.ID = user.SID
+	}
+
 	session, err := repository.SessionFindByID(params.ID)
 	if err != nil {
 		return err
@@ -67,9 +113,88 @@ func Signout(c echo.Context) (err error) {
 		return errors.ErrAccessDenied
 	}
 
-	if err = repository.TerminateSession(user.SID); err != nil {
+	if err = repository.TerminateSession(params.ID); err != nil {
 		return err
 	}
 
 	return errors.ErrSuccess
 }
+
+// SignoutOthers godoc
+// @Summary Delete other sessions
+// @Description Signs the user out everywhere but the session of the request.
+// @Tags session
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Message
+// @Router /users/auth/signout/others [delete]
+func SignoutOthers(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	if err = repository.TerminateOtherSessions(user.ID, user.SID); err != nil {
+		return err
+	}
+
+	return errors.ErrSuccess
+}
+
+// SignoutEverywhere godoc
+// @Summary Delete all sessions
+// @Description Signs the user out everywhere, the session of the request included, and revokes their personal access tokens.
+// @Tags session
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Message
+// @Router /users/auth/signout/all [delete]
+func SignoutEverywhere(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	if err = repository.TerminateAllSessions(user.ID); err != nil {
+		return err
+	}
+
+	if err = repository.RevokeUserPersonalTokens(user.ID); err != nil {
+		return err
+	}
+
+	return errors.ErrSuccess
+}
+
+// RefreshSession godoc
+// @Summary Exchange a refresh token for new tokens
+// @Description Every refresh token is used once. Using one again revokes the session.
+// @Tags session
+// @Accept json
+// @Produce json
+// @Param refreshToken body string true "Refresh token"
+// @Success 200 {object} response.Message
+// @Header 200 {string} Refresh-Token "Refresh token"
+// @Router /users/signin/refresh [post]
+func RefreshSession(c echo.Context) (err error) {
+
+	params := new(RefreshSessionSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	session, key, refreshToken, err := repository.SessionRefresh(params.RefreshToken)
+	if err != nil {
+		return err
+	}
+
+	user, err := repository.GetAccountInfo(session.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsActive {
+		repository.TerminateSession(session.Id.Hex())
+		return errors.ErrAccessDenied
+	}
+
+	return sessionTokens(c, session.UserID, session.Id.Hex(), key, refreshToken)
+}
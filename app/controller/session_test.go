@@ -3,6 +3,8 @@ package controller
 import (
 	"fmt"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"gopkg.in/mgo.v2/bson"
@@ -18,6 +20,7 @@ import (
 	"github.com/thedevsir/frame-backend/services/auth"
 	"github.com/thedevsir/frame-backend/services/errors"
 	j "github.com/thedevsir/frame-backend/services/jwt"
+	"github.com/thedevsir/frame-backend/services/keyring"
 	"github.com/thedevsir/frame-backend/services/test"
 )
 
@@ -33,9 +36,10 @@ func sessionBeforeTest() (*jwt.Token, string) {
 	sessionCollection = database.Connection.Model(model.SessionCollection)
 	sessionCollection.RemoveAll(nil)
 
-	secret := []byte("secret")
+	signer, _ := keyring.GenerateKey(keyring.ES256)
+	keyring.User = keyring.NewRing(signer)
 	userID := bson.NewObjectId().Hex()
-	sid, key, _ := repository.SessionCreate("127.0.0.1", userID, ":::USER-AGENT:::")
+	sid, key, _, _ := repository.SessionCreate("127.0.0.1", userID, ":::USER-AGENT:::", false)
 
 	token := &auth.UserToken{
 		key,
@@ -44,8 +48,8 @@ func sessionBeforeTest() (*jwt.Token, string) {
 		jwt.StandardClaims{},
 	}
 
-	tc, _ := token.Create(secret)
-	tokenParsed, _ := j.ParseJWT(tc, secret)
+	tc, _ := token.Create(keyring.User)
+	tokenParsed, _ := j.ParseSignedJWT(tc, keyring.User)
 
 	return tokenParsed, sid
 }
@@ -64,9 +68,52 @@ func TestSessions(t *testing.T) {
 
 	if assert.NoError(t, Sessions(c)) {
 		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"current":true`)
 	}
 }
 
+func TestLabelSession(t *testing.T) {
+
+	tokenParsed, sid := sessionBeforeTest()
+	defer sessionAfterTest()
+
+	label := func(id, JSONData string) error {
+		c, _ := test.MakeRequest(echo.PUT, JSONData)
+		c.Set("user", tokenParsed)
+		c.SetParamNames("id")
+		c.SetParamValues(id)
+		return LabelSession(c)
+	}
+
+	t.Run("ValidateErr", func(t *testing.T) {
+		assert.Regexp(t, "code=400", label(sid, `{"label":"`+strings.Repeat("a", 51)+`"}`))
+	})
+
+	t.Run("SessionNotFound", func(t *testing.T) {
+		assert.Equal(t, errors.ErrSessionNotFound, label(bson.NewObjectId().Hex(), `{"label":"phone"}`))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+
+		assert.Equal(t, errors.ErrSuccess, label(sid, `{"label":" work laptop "}`))
+
+		session, _ := repository.SessionFindByID(sid)
+		assert.Equal(t, "work laptop", session.Label)
+	})
+
+	t.Run("AdminGetUserSessions", func(t *testing.T) {
+
+		session, _ := repository.SessionFindByID(sid)
+		c, rec := test.MakeRequest(echo.GET, "")
+		c.SetParamNames("id")
+		c.SetParamValues(session.UserID)
+
+		if assert.NoError(t, AdminGetUserSessions(c)) {
+			assert.Contains(t, rec.Body.String(), `"label":"work laptop"`)
+		}
+	})
+}
+
 func TestSignout(t *testing.T) {
 
 	tokenParsed, sid := sessionBeforeTest()
@@ -96,6 +143,33 @@ func TestSignout(t *testing.T) {
 		assert.Equal(t, errors.ErrSessionNotFound, Signout(c))
 	})
 
+	t.Run("AccessDenied", func(t *testing.T) {
+
+		other, _, _, _ := repository.SessionCreate("127.0.0.1", bson.NewObjectId().Hex(), ":::USER-AGENT:::", false)
+		JSONData := fmt.Sprintf(`{"id":"%s"}`, other)
+		c, _ := test.MakeRequest(echo.DELETE, JSONData)
+		c.Set("user", tokenParsed)
+
+		assert.Equal(t, errors.ErrAccessDenied, Signout(c))
+	})
+
+	t.Run("SignoutOtherSession", func(t *testing.T) {
+
+		session, _ := repository.SessionFindByID(sid)
+		other, _, _, _ := repository.SessionCreate("127.0.0.1", session.UserID, ":::USER-AGENT:::", false)
+		JSONData := fmt.Sprintf(`{"id":"%s"}`, other)
+		c, _ := test.MakeRequest(echo.DELETE, JSONData)
+		c.Set("user", tokenParsed)
+
+		assert.Equal(t, errors.ErrSuccess, Signout(c))
+
+		// the requested session is gone, the one signing it out stays
+		_, err := repository.SessionFindByID(other)
+		assert.Equal(t, errors.ErrSessionNotFound, err)
+		_, err = repository.SessionFindByID(sid)
+		assert.Nil(t, err)
+	})
+
 	t.Run("SignoutSuccessfully", func(t *testing.T) {
 
 		JSONData := fmt.Sprintf(`{"id":"%s"}`, sid)
@@ -104,4 +178,100 @@ func TestSignout(t *testing.T) {
 
 		assert.Equal(t, errors.ErrSuccess, Signout(c))
 	})
+
+	t.Run("SignoutCurrentSession", func(t *testing.T) {
+
+		userID := tokenParsed.Claims.(jwt.MapClaims)["userId"].(string)
+		current, key, _, _ := repository.SessionCreate("127.0.0.1", userID, ":::USER-AGENT:::", false)
+		token := &auth.UserToken{key, current, userID, jwt.StandardClaims{}}
+		tc, _ := token.Create(keyring.User)
+		currentParsed, _ := j.ParseSignedJWT(tc, keyring.User)
+
+		c, _ := test.MakeRequest(echo.DELETE, "")
+		c.Set("user", currentParsed)
+
+		// without an id the session of the request is signed out
+		assert.Equal(t, errors.ErrSuccess, Signout(c))
+		_, err := repository.SessionFindByID(current)
+		assert.Equal(t, errors.ErrSessionNotFound, err)
+	})
+}
+
+func TestSignoutOthers(t *testing.T) {
+
+	tokenParsed, sid := sessionBeforeTest()
+	defer sessionAfterTest()
+
+	session, _ := repository.SessionFindByID(sid)
+	other, _, _, _ := repository.SessionCreate("127.0.0.1", session.UserID, ":::USER-AGENT:::", false)
+
+	t.Run("SignoutOthers", func(t *testing.T) {
+
+		c, _ := test.MakeRequest(echo.DELETE, "")
+		c.Set("user", tokenParsed)
+		assert.Equal(t, errors.ErrSuccess, SignoutOthers(c))
+
+		_, err := repository.SessionFindByID(other)
+		assert.Equal(t, errors.ErrSessionNotFound, err)
+		_, err = repository.SessionFindByID(sid)
+		assert.Nil(t, err)
+	})
+
+	t.Run("SignoutEverywhere", func(t *testing.T) {
+
+		c, _ := test.MakeRequest(echo.DELETE, "")
+		c.Set("user", tokenParsed)
+		assert.Equal(t, errors.ErrSuccess, SignoutEverywhere(c))
+
+		_, err := repository.SessionFindByID(sid)
+		assert.Equal(t, errors.ErrSessionNotFound, err)
+	})
+}
+
+func TestRefreshSession(t *testing.T) {
+
+	user, _ := userBeforeTest()
+	defer userAfterTest()
+	sessionBeforeTest()
+	defer sessionAfterTest()
+
+	_, _, refreshToken, _ := repository.SessionCreate("127.0.0.1", user.Id.Hex(), ":::USER-AGENT:::", false)
+
+	refresh := func(token string) (*httptest.ResponseRecorder, error) {
+		JSONData := fmt.Sprintf(`{"refreshToken":"%s"}`, token)
+		c, rec := test.MakeRequest(echo.POST, JSONData)
+		return rec, RefreshSession(c)
+	}
+
+	var rotated string
+
+	t.Run("InvalidToken", func(t *testing.T) {
+
+		_, err := refresh("invalid")
+		assert.Equal(t, errors.ErrRefreshTokenInvalid, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+
+		rec, err := refresh(refreshToken)
+		if assert.NoError(t, err) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.NotEmpty(t, rec.Header().Get(echo.HeaderAuthorization))
+			rotated = rec.Header().Get(HeaderRefreshToken)
+			assert.NotEqual(t, refreshToken, rotated)
+		}
+	})
+
+	t.Run("Reused", func(t *testing.T) {
+
+		_, err := refresh(refreshToken)
+		assert.Equal(t, errors.ErrRefreshTokenReused, err)
+	})
+
+	t.Run("SessionRevoked", func(t *testing.T) {
+
+		// the reuse revoked the tokens issued after it too
+		_, err := refresh(rotated)
+		assert.Equal(t, errors.ErrRefreshTokenInvalid, err)
+	})
 }
@@ -0,0 +1,133 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/geoip"
+	j "github.com/thedevsir/frame-backend/services/jwt"
+	"github.com/thedevsir/frame-backend/services/mail"
+	"github.com/thedevsir/frame-backend/services/request"
+	"github.com/thedevsir/frame-backend/services/useragent"
+)
+
+var _SendSigninAlertMail = mail.SendSigninAlertMail
+
+type DenySigninSchema struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// alertNewDevice mails the user about a signin from an address and user
+// agent it never signed in from, with a link that undoes the signin.
+func alertNewDevice(userID, SID, IP, userAgent string) {
+
+	isNew, err := repository.RememberDevice(userID, IP, userAgent)
+	if err != nil || !isNew {
+		return
+	}
+
+	user, err := repository.GetUserByID(userID)
+	if err != nil {
+		return
+	}
+
+	ID, err := repository.CreateSessionEmailToken(model.EmailTokenSigninAlert, userID, SID, config.SessionRememberLifetime)
+	if err != nil {
+		return
+	}
+
+	token, err := mail.MakeSigninAlertToken(ID, userID, user.Username, user.Email, []byte(config.SigningKey))
+	if err == nil {
+		go _SendSigninAlertMail(user.Username, user.Email, token, describeDevice(userAgent), describeLocation(IP), time.Now())
+	}
+}
+
+// describeDevice reads like "Firefox 121 on Windows", unknown clients are
+// shown as they name themselves.
+func describeDevice(userAgent string) string {
+
+	if device := useragent.Parse(userAgent).String(); device != "" {
+		return device
+	}
+
+	return userAgent
+}
+
+// describeLocation names the city and country of the address when they are
+// known, the address is shown either way.
+func describeLocation(IP string) string {
+
+	if location := geoip.Lookup(IP).String(); location != "" {
+		return location + " (" + IP + ")"
+	}
+
+	return IP
+}
+
+// DenySignin godoc
+// @Summary Deny a signin
+// @Description Signs out the session a new device alert was about, revokes the personal access tokens of the user and emails a password reset link, with the link of the alert.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param token body string true "Token"
+// @Success 200 {object} response.Message
+// @Router /users/signin/deny [post]
+func DenySignin(c echo.Context) (err error) {
+
+	params := new(DenySigninSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	data, err := j.ParseEmailToken(params.Token, []byte(config.SigningKey))
+	if err != nil {
+		return err
+	}
+
+	if data.Action != model.EmailTokenSigninAlert {
+		return errors.ErrAccessDenied
+	}
+
+	alert, err := repository.ConsumeEmailToken(data.ID, data.Action, data.UserID)
+	if err != nil {
+		return err
+	}
+
+	// the session may be signed out or expired by now
+	session, err := repository.SessionFindByID(alert.SessionID)
+	switch {
+	case err == errors.ErrSessionNotFound:
+	case err != nil:
+		return err
+	default:
+		if err = repository.ForgetDevice(data.UserID, session.IP, session.UserAgent); err != nil {
+			return err
+		}
+		if err = repository.TerminateSession(alert.SessionID); err != nil && err != errors.ErrSessionNotFound {
+			return err
+		}
+	}
+
+	// a token minted from the session would outlive it
+	if err = repository.RevokeUserPersonalTokens(data.UserID); err != nil {
+		return err
+	}
+
+	user, err := repository.GetUserByID(data.UserID)
+	if err != nil {
+		return err
+	}
+
+	token, err := emailToken(model.EmailTokenReset, user)
+	if err != nil {
+		return err
+	}
+	go _SendResetMail(user.Username, user.Email, token)
+
+	return errors.ErrSuccess
+}
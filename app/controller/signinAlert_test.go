@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/ratelimit"
+	"github.com/thedevsir/frame-backend/services/test"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestSigninAlert(t *testing.T) {
+
+	user, _ := userBeforeTest()
+	defer userAfterTest()
+
+	for _, collection := range []string{model.KnownDeviceCollection, model.EmailTokenCollection, model.SessionCollection, model.AccountLockCollection} {
+		database.Connection.Model(collection).RemoveAll(nil)
+		defer database.Connection.Model(collection).RemoveAll(nil)
+	}
+
+	ratelimit.Store = ratelimit.NewMemory()
+
+	alerts := make(chan string, 1)
+	_SendSigninAlertMail = func(username, email, token, device, location string, signedInAt time.Time) error {
+		alerts <- token
+		return nil
+	}
+
+	resets := make(chan string, 1)
+	_SendResetMail = func(username, email, token string) error {
+		resets <- token
+		return nil
+	}
+
+	signin := func(userAgent string) {
+		c, _ := test.MakeRequest(echo.POST, `{"username":"`+user.Username+`","password":"12345678"}`)
+		c.Request().Header.Set("User-Agent", userAgent)
+		assert.NoError(t, Signin(c))
+	}
+
+	alerted := func() string {
+		select {
+		case token := <-alerts:
+			return token
+		case <-time.After(100 * time.Millisecond):
+			return ""
+		}
+	}
+
+	sessions := func(userAgent string) int {
+		count, _ := database.Connection.Model(model.SessionCollection).Find(bson.M{"userId": user.Id.Hex(), "userAgent": userAgent}).Count()
+		return count
+	}
+
+	var token string
+
+	t.Run("KnownDevice", func(t *testing.T) {
+
+		// the first device has nothing to compare with
+		signin("laptop")
+		signin("laptop")
+		assert.Empty(t, alerted())
+	})
+
+	t.Run("NewDevice", func(t *testing.T) {
+
+		signin("phone")
+		token = alerted()
+		assert.NotEmpty(t, token)
+
+		signin("phone")
+		assert.Empty(t, alerted())
+	})
+
+	t.Run("DenySignin", func(t *testing.T) {
+
+		deny := func(token string) error {
+			c, _ := test.MakeRequest(echo.POST, `{"token":"`+token+`"}`)
+			return DenySignin(c)
+		}
+
+		assert.Equal(t, errors.ErrSuccess, deny(token))
+		assert.NotEmpty(t, <-resets)
+		assert.Equal(t, 1, sessions("phone"))
+		assert.Equal(t, 2, sessions("laptop"))
+
+		assert.Equal(t, errors.ErrTokenIsNotValid, deny(token))
+
+		// the denied device alerts again
+		signin("phone")
+		assert.NotEmpty(t, alerted())
+	})
+}
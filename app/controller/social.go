@@ -0,0 +1,368 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/auth"
+	"github.com/thedevsir/frame-backend/services/encrypt"
+	"github.com/thedevsir/frame-backend/services/errors"
+	j "github.com/thedevsir/frame-backend/services/jwt"
+	"github.com/thedevsir/frame-backend/services/request"
+	r "github.com/thedevsir/frame-backend/services/response"
+	"github.com/thedevsir/frame-backend/services/social"
+	"github.com/thedevsir/frame-backend/services/usernames"
+)
+
+const (
+	socialSigninAction = "social-signin"
+	socialLinkAction   = "social-link"
+)
+
+var usernameCharacters = regexp.MustCompile(`[^a-z0-9]`)
+
+type (
+	SocialCallbackSchema struct {
+		Token string `json:"token" validate:"required"`
+		State string `json:"state" validate:"required"`
+		Code  string `json:"code" validate:"required"`
+	}
+	SocialRedirect struct {
+		URL   string `json:"url"`
+		Token string `json:"token"`
+	}
+)
+
+// beginSocial creates the provider URL along with the signed token the
+// frontend sends back with the callback. The PKCE verifier in the token is
+// sealed, only the state travels through the browser in the clear.
+func beginSocial(c echo.Context, action, userID string) error {
+
+	provider, err := social.Find(c.Param("provider"))
+	if err != nil {
+		return errors.ErrSocialProviderNotFound
+	}
+
+	state, err := encrypt.RandomToken(16)
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	verifier, err := encrypt.RandomToken(32)
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	sealed, err := encrypt.Seal(verifier, []byte(config.EncryptionKey))
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	redirect, err := provider.AuthCodeURL(fmt.Sprintf(config.SocialRedirectLink, provider.Name), state, verifier)
+	if err != nil {
+		return errors.ErrSocialSigninFailed
+	}
+
+	flow := &auth.SocialToken{
+		Action:   action,
+		ID:       userID,
+		Provider: provider.Name,
+		State:    state,
+		Verifier: sealed,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: time.Now().Add(config.SocialTimeout).Unix(),
+		},
+	}
+	token, err := flow.Create([]byte(config.SigningKey))
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusOK, SocialRedirect{URL: redirect, Token: token}, c)
+}
+
+// socialCallback checks the callback against its token and reads the
+// account from the provider.
+func socialCallback(c echo.Context, action string) (*social.Provider, *j.SocialData, *social.Identity, error) {
+
+	params := new(SocialCallbackSchema)
+	if err := request.GetInputs(c, params); err != nil {
+		return nil, nil, nil, err
+	}
+
+	flow, err := j.ParseSocialToken(params.Token, action, []byte(config.SigningKey))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if flow.Provider != c.Param("provider") || subtle.ConstantTimeCompare([]byte(flow.State), []byte(params.State)) != 1 {
+		return nil, nil, nil, errors.ErrSocialSigninFailed
+	}
+
+	provider, err := social.Find(flow.Provider)
+	if err != nil {
+		return nil, nil, nil, errors.ErrSocialProviderNotFound
+	}
+
+	verifier, err := encrypt.Open(flow.Verifier, []byte(config.EncryptionKey))
+	if err != nil {
+		return nil, nil, nil, errors.ErrSocialSigninFailed
+	}
+
+	accessToken, err := provider.Exchange(params.Code, fmt.Sprintf(config.SocialRedirectLink, provider.Name), verifier)
+	if err != nil {
+		return nil, nil, nil, errors.ErrSocialSigninFailed
+	}
+
+	identity, err := provider.Identity(accessToken)
+	if err != nil {
+		return nil, nil, nil, errors.ErrSocialSigninFailed
+	}
+
+	return provider, flow, identity, nil
+}
+
+// socialUsername derives a free username from the provider account.
+func socialUsername(identity *social.Identity) (string, error) {
+
+	base := identity.Username
+	if base == "" {
+		base = strings.Split(identity.Email, "@")[0]
+	}
+
+	base = usernameCharacters.ReplaceAllString(strings.ToLower(base), "")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	for len(base) < 3 {
+		base += "0"
+	}
+
+	username := base
+	for i := 0; i < 5; i++ {
+
+		existing, err := repository.CheckUsername(username)
+		if existing == nil && err != nil {
+			return "", err
+		}
+		if existing == nil && usernames.Check(username) == nil {
+			return username, nil
+		}
+
+		suffix, err := encrypt.RandomCode(6)
+		if err != nil {
+			return "", errors.ErrInternal
+		}
+		username = base + suffix
+	}
+
+	return "", errors.ErrUsernameExists
+}
+
+// socialSignup creates the account of a new social user, whose email the
+// provider verified. The password is random, the user can set one through
+// forgot password.
+func socialSignup(identity *social.Identity) (*model.User, error) {
+
+	username, err := socialUsername(identity)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := encrypt.RandomToken(32)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	user, err := repository.CreateUser(username, password, identity.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = repository.UserActivation(user.Id.Hex()); err != nil {
+		return nil, err
+	}
+	user.IsEmailVerified = true
+
+	return user, nil
+}
+
+// socialUser finds the user a provider account signs in. An unknown account
+// needs an email the provider verified, so nobody holds an address here
+// they do not own. It is linked to the user with the same email only when
+// this service verified it too, otherwise a new user is created.
+func socialUser(provider string, identity *social.Identity) (*model.User, error) {
+
+	linked, err := repository.FindSocialIdentity(provider, identity.Subject)
+	switch {
+	case err == nil:
+		if err = repository.UseSocialIdentity(linked.Id.Hex(), identity); err != nil {
+			return nil, err
+		}
+		return repository.GetAccountInfo(linked.UserID)
+	case err != errors.ErrSocialIdentityNotFound:
+		return nil, err
+	case identity.Email == "":
+		return nil, errors.ErrSocialEmailRequired
+	case !identity.EmailVerified:
+		return nil, errors.ErrSocialEmailUnverified
+	}
+
+	user, err := repository.CheckEmail(identity.Email)
+	switch {
+	case user != nil && !user.IsEmailVerified:
+		return nil, errors.ErrSocialEmailExists
+	case user != nil && !user.IsActive:
+		return nil, errors.ErrAccessDenied
+	case user == nil && err != nil:
+		return nil, err
+	case user == nil:
+		if user, err = socialSignup(identity); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err = repository.CreateSocialIdentity(user.Id.Hex(), provider, identity); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// BeginSocialSignin godoc
+// @Summary Begin signin with a social provider
+// @Description Responds with the provider URL to send the user to and the token to send back with the callback.
+// @Tags user
+// @Produce json
+// @Param provider path string true "Provider"
+// @Success 200 {object} response.Message
+// @Router /users/signin/social/{provider}/begin [post]
+func BeginSocialSignin(c echo.Context) (err error) {
+	return beginSocial(c, socialSigninAction, "")
+}
+
+// SigninSocial godoc
+// @Summary Complete signin with a social provider
+// @Description Signs up a new user when the provider account is not linked yet, which needs an email the provider verified.
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider"
+// @Param token body string true "Token"
+// @Param state body string true "State"
+// @Param code body string true "Code"
+// @Success 200 {object} response.Message
+// @Success 202 {object} response.Message
+// @Router /users/signin/social/{provider} [post]
+func SigninSocial(c echo.Context) (err error) {
+
+	provider, _, identity, err := socialCallback(c, socialSigninAction)
+	if err != nil {
+		return err
+	}
+
+	user, err := socialUser(provider.Name, identity)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsActive {
+		return errors.ErrAccessDenied
+	}
+
+	return completeSignin(c, user, user.Username, false)
+}
+
+// BeginSocialLink godoc
+// @Summary Begin linking a social provider
+// @Tags social
+// @Produce json
+// @Security ApiKeyAuth
+// @Param provider path string true "Provider"
+// @Success 200 {object} response.Message
+// @Router /users/auth/social/{provider}/begin [post]
+func BeginSocialLink(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+	return beginSocial(c, socialLinkAction, user.ID)
+}
+
+// LinkSocial godoc
+// @Summary Link a social provider
+// @Tags social
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param provider path string true "Provider"
+// @Param token body string true "Token"
+// @Param state body string true "State"
+// @Param code body string true "Code"
+// @Success 201 {object} response.Message
+// @Router /users/auth/social/{provider} [post]
+func LinkSocial(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	provider, flow, identity, err := socialCallback(c, socialLinkAction)
+	if err != nil {
+		return err
+	}
+
+	if flow.UserID != user.ID {
+		return errors.ErrAccessDenied
+	}
+
+	linked, err := repository.CreateSocialIdentity(user.ID, provider.Name, identity)
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusCreated, linked, c)
+}
+
+// SocialIdentities godoc
+// @Summary Get linked social accounts
+// @Tags social
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Message
+// @Router /users/auth/social [get]
+func SocialIdentities(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	identities, err := repository.GetUserSocialIdentities(user.ID)
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusOK, identities, c)
+}
+
+// UnlinkSocial godoc
+// @Summary Unlink a social provider
+// @Tags social
+// @Produce json
+// @Security ApiKeyAuth
+// @Param provider path string true "Provider"
+// @Success 200 {object} response.Message
+// @Router /users/auth/social/{provider} [delete]
+func UnlinkSocial(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	if err = repository.RemoveSocialIdentity(user.ID, c.Param("provider")); err != nil {
+		return err
+	}
+
+	return errors.ErrSuccess
+}
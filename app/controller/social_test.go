@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/oauth"
+	"github.com/thedevsir/frame-backend/services/social"
+	"github.com/thedevsir/frame-backend/services/test"
+)
+
+func TestSocial(t *testing.T) {
+
+	user, tokenParsed := userBeforeTest()
+	defer userAfterTest()
+
+	identityCollection := database.Connection.Model(model.SocialIdentityCollection)
+	identityCollection.RemoveAll(nil)
+	defer identityCollection.RemoveAll(nil)
+
+	fake := test.NewIdentityProvider("client", "secret")
+	defer fake.Close()
+
+	config.SocialRedirectLink = "https://app.example/social/%s/callback"
+	social.Providers = map[string]*social.Provider{
+		"fake": {
+			Name:         "fake",
+			Kind:         social.KindOIDC,
+			ClientID:     "client",
+			ClientSecret: "secret",
+			Issuer:       fake.URL,
+			Scopes:       []string{oauth.ScopeOpenID, oauth.ScopeEmail},
+		},
+	}
+
+	withProvider := func(c echo.Context, provider string) echo.Context {
+		c.SetParamNames("provider")
+		c.SetParamValues(provider)
+		return c
+	}
+
+	// begin runs the first step and lets the fake provider approve it
+	begin := func(handler echo.HandlerFunc, token *jwt.Token) map[string]string {
+		c, rec := test.MakeRequest(echo.POST, "")
+		c.Set("user", token)
+		if !assert.NoError(t, handler(withProvider(c, "fake"))) {
+			t.FailNow()
+		}
+
+		data := struct {
+			Message SocialRedirect
+		}{}
+		json.Unmarshal(rec.Body.Bytes(), &data)
+
+		params, err := fake.Authorize(data.Message.URL)
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+
+		return map[string]string{"token": data.Message.Token, "state": params.Get("state"), "code": params.Get("code")}
+	}
+
+	callback := func(body map[string]string, token *jwt.Token) (echo.Context, *httptest.ResponseRecorder) {
+		JSONData, _ := json.Marshal(body)
+		c, rec := test.MakeRequest(echo.POST, string(JSONData))
+		c.Set("user", token)
+		return withProvider(c, "fake"), rec
+	}
+
+	t.Run("SigninSocial", func(t *testing.T) {
+
+		t.Run("UnknownProvider", func(t *testing.T) {
+			c, _ := test.MakeRequest(echo.POST, "")
+			assert.Equal(t, errors.ErrSocialProviderNotFound, BeginSocialSignin(withProvider(c, "unknown")))
+		})
+
+		t.Run("StateMismatch", func(t *testing.T) {
+			fake.User = test.ProviderUser{Subject: "1", PreferredUsername: "jane", Email: "jane@example.com", EmailVerified: true}
+			body := begin(BeginSocialSignin, nil)
+			body["state"] = "forged"
+			c, _ := callback(body, nil)
+			assert.Equal(t, errors.ErrSocialSigninFailed, SigninSocial(c))
+		})
+
+		t.Run("Signup", func(t *testing.T) {
+			body := begin(BeginSocialSignin, nil)
+			c, rec := callback(body, nil)
+			if assert.NoError(t, SigninSocial(c)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.NotEmpty(t, rec.Header().Get(echo.HeaderAuthorization))
+			}
+		})
+
+		t.Run("Linked", func(t *testing.T) {
+			body := begin(BeginSocialSignin, nil)
+			c, rec := callback(body, nil)
+			if assert.NoError(t, SigninSocial(c)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			}
+			count, _ := userCollection.Find(nil).Count()
+			assert.Equal(t, 2, count)
+		})
+
+		t.Run("UnverifiedProviderEmail", func(t *testing.T) {
+			// the provider did not verify that the address belongs to them
+			fake.User = test.ProviderUser{Subject: "3", PreferredUsername: "mallory", Email: "victim@example.com", EmailVerified: false}
+			body := begin(BeginSocialSignin, nil)
+			c, _ := callback(body, nil)
+			assert.Equal(t, errors.ErrSocialEmailUnverified, SigninSocial(c))
+
+			existing, _ := repository.CheckEmail("victim@example.com")
+			assert.Nil(t, existing)
+		})
+
+		t.Run("UnverifiedEmailMatch", func(t *testing.T) {
+			// the local account has not verified this email yet
+			fake.User = test.ProviderUser{Subject: "2", Email: user.Email, EmailVerified: true}
+			body := begin(BeginSocialSignin, nil)
+			c, _ := callback(body, nil)
+			assert.Equal(t, errors.ErrSocialEmailExists, SigninSocial(c))
+		})
+	})
+
+	t.Run("LinkSocial", func(t *testing.T) {
+
+		t.Run("LinkedToAnotherUser", func(t *testing.T) {
+			fake.User = test.ProviderUser{Subject: "1"}
+			body := begin(BeginSocialLink, tokenParsed)
+			c, _ := callback(body, tokenParsed)
+			assert.Equal(t, errors.ErrSocialIdentityExists, LinkSocial(c))
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			fake.User = test.ProviderUser{Subject: "3"}
+			body := begin(BeginSocialLink, tokenParsed)
+			c, rec := callback(body, tokenParsed)
+			if assert.NoError(t, LinkSocial(c)) {
+				assert.Equal(t, http.StatusCreated, rec.Code)
+			}
+		})
+
+		t.Run("SigninToken", func(t *testing.T) {
+			body := begin(BeginSocialSignin, nil)
+			c, _ := callback(body, tokenParsed)
+			assert.Equal(t, errors.ErrTokenIsNotValid, LinkSocial(c))
+		})
+	})
+
+	t.Run("SocialIdentities", func(t *testing.T) {
+
+		c, rec := test.MakeRequest(echo.GET, "")
+		c.Set("user", tokenParsed)
+		if assert.NoError(t, SocialIdentities(c)) {
+			assert.Contains(t, rec.Body.String(), `"subject":"3"`)
+		}
+	})
+
+	t.Run("UnlinkSocial", func(t *testing.T) {
+
+		c, _ := test.MakeRequest(echo.DELETE, "")
+		c.Set("user", tokenParsed)
+		assert.Equal(t, errors.ErrSuccess, UnlinkSocial(withProvider(c, "fake")))
+
+		c, _ = test.MakeRequest(echo.DELETE, "")
+		c.Set("user", tokenParsed)
+		assert.Equal(t, errors.ErrSocialIdentityNotFound, UnlinkSocial(withProvider(c, "fake")))
+	})
+}
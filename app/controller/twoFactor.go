@@ -0,0 +1,307 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/encrypt"
+	"github.com/thedevsir/frame-backend/services/errors"
+	j "github.com/thedevsir/frame-backend/services/jwt"
+	"github.com/thedevsir/frame-backend/services/request"
+	r "github.com/thedevsir/frame-backend/services/response"
+	"github.com/thedevsir/frame-backend/services/totp"
+)
+
+type (
+	ConfirmTwoFactorSchema struct {
+		Code string `json:"code" validate:"required"`
+	}
+	DisableTwoFactorSchema struct {
+		Password string `json:"password" validate:"required"`
+		Code     string `json:"code" validate:"required"`
+	}
+	SigninTwoFactorSchema struct {
+		Challenge string `json:"challenge" validate:"required"`
+		Code      string `json:"code" validate:"required"`
+	}
+	RecoveryCodesSchema struct {
+		Password string `json:"password" validate:"required"`
+	}
+	RecoveryCodesInfo struct {
+		Remaining int                  `json:"remaining"`
+		Used      []model.RecoveryCode `json:"used"`
+	}
+)
+
+// EnrollTwoFactor godoc
+// @Summary Begin two-factor enrollment
+// @Tags twoFactor
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Message
+// @Router /users/auth/2fa [post]
+func EnrollTwoFactor(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	account, err := repository.GetAccountInfo(user.ID)
+	if err != nil {
+		return err
+	}
+
+	if account.TwoFactor {
+		return errors.ErrTwoFactorEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	sealed, err := encrypt.Seal(secret, []byte(config.EncryptionKey))
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	if err = repository.SetTwoFactorPending(user.ID, sealed); err != nil {
+		return err
+	}
+
+	data := map[string]string{
+		"secret": secret,
+		"uri":    totp.URI(config.TwoFactorIssuer, account.Username, secret),
+	}
+
+	return r.CustomErrorJson(http.StatusOK, data, c)
+}
+
+// ConfirmTwoFactor godoc
+// @Summary Confirm two-factor enrollment
+// @Tags twoFactor
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param code body string true "Code"
+// @Success 200 {object} response.Message
+// @Router /users/auth/2fa [put]
+func ConfirmTwoFactor(c echo.Context) (err error) {
+
+	params := new(ConfirmTwoFactorSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	user := request.AuthenticatedUser(c)
+
+	account, err := repository.GetAccountInfo(user.ID)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case account.TwoFactor:
+		return errors.ErrTwoFactorEnabled
+	case account.TwoFactorPending == "":
+		return errors.ErrTwoFactorNotBegun
+	}
+
+	secret, err := encrypt.Open(account.TwoFactorPending, []byte(config.EncryptionKey))
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	step, ok := totp.Validate(secret, params.Code, time.Now())
+	if !ok {
+		return errors.ErrInvalidCode
+	}
+
+	if err = repository.EnableTwoFactor(user.ID, account.TwoFactorPending, step); err != nil {
+		return err
+	}
+
+	codes, err := repository.GenerateRecoveryCodes(user.ID)
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusOK, map[string][]string{"recoveryCodes": codes}, c)
+}
+
+// DisableTwoFactor godoc
+// @Summary Disable two-factor authentication
+// @Tags twoFactor
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param password body string true "Password"
+// @Param code body string true "Code or recovery code"
+// @Success 200 {object} response.Message
+// @Router /users/auth/2fa [delete]
+func DisableTwoFactor(c echo.Context) (err error) {
+
+	params := new(DisableTwoFactorSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	user := request.AuthenticatedUser(c)
+
+	account, err := repository.CheckUserPassword(user.ID, params.Password)
+	if err != nil {
+		return err
+	}
+
+	if !account.TwoFactor {
+		return errors.ErrTwoFactorDisabled
+	}
+
+	if err = checkTwoFactorCode(account, params.Code, request.ClientIP(c)); err != nil {
+		return err
+	}
+
+	if err = repository.DisableTwoFactor(user.ID); err != nil {
+		return err
+	}
+
+	return errors.ErrSuccess
+}
+
+// SigninTwoFactor godoc
+// @Summary Complete signin with a two-factor code
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param challenge body string true "Challenge"
+// @Param code body string true "Code or recovery code"
+// @Success 200 {object} response.Message
+// @Router /users/signin/2fa [post]
+func SigninTwoFactor(c echo.Context) (err error) {
+
+	ip := request.ClientIP(c)
+
+	params := new(SigninTwoFactorSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	data, err := j.ParseMFAToken(params.Challenge, []byte(config.SigningKey))
+	if err != nil {
+		return err
+	}
+
+	if err = checkAbuse(c, data.Username); err != nil {
+		return err
+	}
+
+	key := repository.UserLockKey(data.UserID)
+	if err = repository.CheckAccountLock(key); err != nil {
+		return err
+	}
+
+	user, err := repository.GetAccountInfo(data.UserID)
+	if err != nil {
+		return err
+	}
+
+	if !user.IsActive || !user.TwoFactor {
+		return errors.ErrAccessDenied
+	}
+
+	if err = checkTwoFactorCode(user, params.Code, ip); err != nil {
+		repository.SubmitAttempt(ip, data.Username)
+		failedSignin(key, user)
+		return err
+	}
+
+	repository.ClearAccountLock(key)
+
+	return signinUser(c, data.UserID, data.Remember)
+}
+
+// RecoveryCodes godoc
+// @Summary Get recovery codes usage
+// @Tags twoFactor
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} response.Message
+// @Router /users/auth/2fa/recovery [get]
+func RecoveryCodes(c echo.Context) (err error) {
+
+	user := request.AuthenticatedUser(c)
+
+	account, err := repository.GetAccountInfo(user.ID)
+	if err != nil {
+		return err
+	}
+
+	if !account.TwoFactor {
+		return errors.ErrTwoFactorDisabled
+	}
+
+	info := RecoveryCodesInfo{Used: []model.RecoveryCode{}}
+	for _, code := range account.RecoveryCodes {
+		if code.UsedAt == nil {
+			info.Remaining++
+		} else {
+			info.Used = append(info.Used, code)
+		}
+	}
+
+	return r.CustomErrorJson(http.StatusOK, info, c)
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Replace recovery codes
+// @Tags twoFactor
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param password body string true "Password"
+// @Success 200 {object} response.Message
+// @Router /users/auth/2fa/recovery [post]
+func RegenerateRecoveryCodes(c echo.Context) (err error) {
+
+	params := new(RecoveryCodesSchema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	user := request.AuthenticatedUser(c)
+
+	if _, err = repository.CheckUserPassword(user.ID, params.Password); err != nil {
+		return err
+	}
+
+	codes, err := repository.GenerateRecoveryCodes(user.ID)
+	if err != nil {
+		return err
+	}
+
+	return r.CustomErrorJson(http.StatusOK, map[string][]string{"recoveryCodes": codes}, c)
+}
+
+// checkTwoFactorCode accepts either a TOTP code or one of the user's
+// unused recovery codes.
+func checkTwoFactorCode(user *model.User, code, IP string) error {
+
+	if repository.IsRecoveryCode(code) {
+		return repository.UseRecoveryCode(user, code, IP)
+	}
+
+	secret, err := encrypt.Open(user.TwoFactorSecret, []byte(config.EncryptionKey))
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return errors.ErrInvalidCode
+	}
+
+	return repository.UseTwoFactorStep(user.Id.Hex(), step)
+}
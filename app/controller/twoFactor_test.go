@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/test"
+	"github.com/thedevsir/frame-backend/services/totp"
+)
+
+func TestTwoFactor(t *testing.T) {
+
+	user, tokenParsed := userBeforeTest()
+	defer userAfterTest()
+
+	var secret, challenge string
+	var recoveryCodes []string
+
+	t.Run("EnrollTwoFactor", func(t *testing.T) {
+
+		c, rec := test.MakeRequest(echo.POST, "")
+		c.Set("user", tokenParsed)
+
+		if assert.NoError(t, EnrollTwoFactor(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			body := struct {
+				Message map[string]string
+			}{}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			secret = body.Message["secret"]
+			assert.NotEmpty(t, secret)
+		}
+	})
+
+	t.Run("ConfirmTwoFactor", func(t *testing.T) {
+
+		t.Run("InvalidCode", func(t *testing.T) {
+
+			c, _ := test.MakeRequest(echo.PUT, `{"code":"000000"}`)
+			c.Set("user", tokenParsed)
+			assert.Equal(t, errors.ErrInvalidCode, ConfirmTwoFactor(c))
+		})
+
+		t.Run("Success", func(t *testing.T) {
+
+			code, _ := totp.Code(secret, totp.Step(time.Now())-1)
+			c, rec := test.MakeRequest(echo.PUT, fmt.Sprintf(`{"code":"%s"}`, code))
+			c.Set("user", tokenParsed)
+
+			if assert.NoError(t, ConfirmTwoFactor(c)) {
+				body := struct {
+					Message map[string][]string
+				}{}
+				json.Unmarshal(rec.Body.Bytes(), &body)
+				recoveryCodes = body.Message["recoveryCodes"]
+				assert.Len(t, recoveryCodes, repository.RecoveryCodesCount)
+			}
+		})
+
+		t.Run("AlreadyEnabled", func(t *testing.T) {
+
+			c, _ := test.MakeRequest(echo.POST, "")
+			c.Set("user", tokenParsed)
+			assert.Equal(t, errors.ErrTwoFactorEnabled, EnrollTwoFactor(c))
+		})
+	})
+
+	t.Run("Signin", func(t *testing.T) {
+
+		JSONData := `{"username":"amir","password":"12345678"}`
+		c, rec := test.MakeRequest(echo.POST, JSONData)
+
+		if assert.NoError(t, Signin(c)) {
+			assert.Equal(t, http.StatusAccepted, rec.Code)
+			assert.Empty(t, rec.Header().Get(echo.HeaderAuthorization))
+			body := struct {
+				Message map[string]string
+			}{}
+			json.Unmarshal(rec.Body.Bytes(), &body)
+			challenge = body.Message["challenge"]
+		}
+	})
+
+	t.Run("SigninTwoFactor", func(t *testing.T) {
+
+		t.Run("ReplayedCode", func(t *testing.T) {
+
+			code, _ := totp.Code(secret, totp.Step(time.Now())-1)
+			JSONData := fmt.Sprintf(`{"challenge":"%s","code":"%s"}`, challenge, code)
+			c, _ := test.MakeRequest(echo.POST, JSONData)
+			assert.Equal(t, errors.ErrInvalidCode, SigninTwoFactor(c))
+		})
+
+		t.Run("Success", func(t *testing.T) {
+
+			code, _ := totp.Code(secret, totp.Step(time.Now()))
+			JSONData := fmt.Sprintf(`{"challenge":"%s","code":"%s"}`, challenge, code)
+			c, rec := test.MakeRequest(echo.POST, JSONData)
+
+			if assert.NoError(t, SigninTwoFactor(c)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.NotEmpty(t, rec.Header().Get(echo.HeaderAuthorization))
+			}
+		})
+	})
+
+	t.Run("RecoveryCodes", func(t *testing.T) {
+
+		t.Run("Signin", func(t *testing.T) {
+
+			JSONData := fmt.Sprintf(`{"challenge":"%s","code":"%s"}`, challenge, recoveryCodes[0])
+			c, rec := test.MakeRequest(echo.POST, JSONData)
+
+			if assert.NoError(t, SigninTwoFactor(c)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			}
+		})
+
+		t.Run("AlreadyUsed", func(t *testing.T) {
+
+			JSONData := fmt.Sprintf(`{"challenge":"%s","code":"%s"}`, challenge, recoveryCodes[0])
+			c, _ := test.MakeRequest(echo.POST, JSONData)
+			assert.Equal(t, errors.ErrInvalidCode, SigninTwoFactor(c))
+		})
+
+		t.Run("Usage", func(t *testing.T) {
+
+			c, rec := test.MakeRequest(echo.GET, "")
+			c.Set("user", tokenParsed)
+
+			if assert.NoError(t, RecoveryCodes(c)) {
+				body := struct {
+					Message RecoveryCodesInfo
+				}{}
+				json.Unmarshal(rec.Body.Bytes(), &body)
+				assert.Equal(t, repository.RecoveryCodesCount-1, body.Message.Remaining)
+				assert.Len(t, body.Message.Used, 1)
+			}
+		})
+
+		t.Run("Regenerate", func(t *testing.T) {
+
+			c, rec := test.MakeRequest(echo.POST, `{"password":"12345678"}`)
+			c.Set("user", tokenParsed)
+
+			if assert.NoError(t, RegenerateRecoveryCodes(c)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			}
+		})
+	})
+
+	t.Run("DisableTwoFactor", func(t *testing.T) {
+
+		t.Run("WrongPassword", func(t *testing.T) {
+
+			c, _ := test.MakeRequest(echo.DELETE, `{"password":"87654321","code":"000000"}`)
+			c.Set("user", tokenParsed)
+			assert.Equal(t, errors.ErrInvalidCredentials, DisableTwoFactor(c))
+		})
+
+		t.Run("Success", func(t *testing.T) {
+
+			code, _ := totp.Code(secret, totp.Step(time.Now())+1)
+			JSONData := fmt.Sprintf(`{"password":"12345678","code":"%s"}`, code)
+			c, _ := test.MakeRequest(echo.DELETE, JSONData)
+			c.Set("user", tokenParsed)
+			assert.Equal(t, errors.ErrSuccess, DisableTwoFactor(c))
+
+			account, _ := repository.GetAccountInfo(user.Id.Hex())
+			assert.False(t, account.TwoFactor)
+		})
+	})
+}
@@ -7,27 +7,35 @@ import (
 
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/model"
 	"github.com/thedevsir/frame-backend/app/repository"
 	"github.com/thedevsir/frame-backend/config"
 	"github.com/thedevsir/frame-backend/services/auth"
 	"github.com/thedevsir/frame-backend/services/errors"
 	j "github.com/thedevsir/frame-backend/services/jwt"
+	"github.com/thedevsir/frame-backend/services/keyring"
 	"github.com/thedevsir/frame-backend/services/mail"
+	"github.com/thedevsir/frame-backend/services/password"
 	"github.com/thedevsir/frame-backend/services/request"
 	r "github.com/thedevsir/frame-backend/services/response"
 	"github.com/thedevsir/frame-backend/services/storage"
+	"github.com/thedevsir/frame-backend/services/usernames"
 	"github.com/thedevsir/frame-backend/services/validation"
 )
 
+const HeaderRefreshToken = "Refresh-Token"
+
 var (
-	_SendVerficationMail = mail.SendVerficationMail
-	_SendResetMail       = mail.SendResetMail
+	_SendVerficationMail   = mail.SendVerficationMail
+	_SendResetMail         = mail.SendResetMail
+	_SendConfirmEmailMail  = mail.SendConfirmEmailMail
+	_SendEmailChangingMail = mail.SendEmailChangingMail
 )
 
 type (
 	SignupShcema struct {
 		Username string `json:"username" validate:"required,min=3,max=50,alphanum"`
-		Password string `json:"password" validate:"required,min=8,max=50"`
+		Password string `json:"password" validate:"required"`
 		Email    string `json:"email" validate:"required,email"`
 	}
 	ResendShcema struct {
@@ -39,16 +47,18 @@ type (
 	SigninShcema struct {
 		Username string `json:"username" validate:"required"`
 		Password string `json:"password" validate:"required"`
+		Remember bool   `json:"remember"`
 	}
 	ForgotShcema struct {
 		Email string `json:"email" validate:"required,email"`
 	}
 	ResetShcema struct {
 		Token    string `json:"token" validate:"required"`
-		Password string `json:"password" validate:"required,min=8,max=50"`
+		Password string `json:"password" validate:"required"`
 	}
 	ChangePasswordShcema struct {
-		Password string `json:"password" validate:"required,min=8,max=50"`
+		CurrentPassword string `json:"currentPassword" validate:"required"`
+		Password        string `json:"password" validate:"required"`
 	}
 	ChangeUsernameShcema struct {
 		Username string `json:"username" validate:"required,min=3,max=50,alphanum"`
@@ -56,6 +66,9 @@ type (
 	ChangeEmailShcema struct {
 		Email string `json:"email" validate:"required,email"`
 	}
+	EmailChangeShcema struct {
+		Token string `json:"token" validate:"required"`
+	}
 )
 
 // Signup godoc
@@ -75,6 +88,10 @@ func Signup(c echo.Context) (err error) {
 		return err
 	}
 
+	if err = usernames.Check(params.Username); err != nil {
+		return err
+	}
+
 	if _, err = repository.CheckUsername(params.Username); err != nil {
 		return err
 	}
@@ -83,12 +100,16 @@ func Signup(c echo.Context) (err error) {
 		return err
 	}
 
+	if err = password.Validate(password.Input{Password: params.Password, Username: params.Username, Email: params.Email}); err != nil {
+		return err
+	}
+
 	user, err := repository.CreateUser(params.Username, params.Password, params.Email)
 	if err != nil {
 		return err
 	}
 
-	token, err := mail.MakeEmailToken("verify", user.GetId().Hex(), params.Username, params.Email, []byte(config.SigningKey))
+	token, err := emailToken(model.EmailTokenVerify, user)
 	if err == nil {
 		go _SendVerficationMail(params.Username, params.Email, token)
 	}
@@ -96,6 +117,36 @@ func Signup(c echo.Context) (err error) {
 	return errors.ErrCreated
 }
 
+// emailToken stores a single-use token for an emailed link and signs the
+// link token that carries it.
+func emailToken(action string, user *model.User) (string, error) {
+	return emailTokenTo(action, user, user.Email)
+}
+
+// emailTokenTo signs a link token for an address the user does not have
+// yet, the link is bound to it.
+func emailTokenTo(action string, user *model.User, email string) (string, error) {
+
+	ID, err := repository.CreateEmailToken(action, user.Id.Hex(), mail.EmailTokenLifetime)
+	if err != nil {
+		return "", err
+	}
+
+	return mail.MakeEmailToken(action, ID, user.Id.Hex(), user.Username, email, []byte(config.SigningKey))
+}
+
+// passwordInput is what a new password of the user is checked against, the
+// history starts with the password it replaces.
+func passwordInput(newPassword string, user *model.User) password.Input {
+
+	return password.Input{
+		Password: newPassword,
+		Username: user.Username,
+		Email:    user.Email,
+		History:  append([]string{user.Password}, user.PasswordHistory...),
+	}
+}
+
 // Resend godoc
 // @Summary Resend email verfication
 // @Tags user
@@ -117,7 +168,7 @@ func Resend(c echo.Context) (err error) {
 			return errors.ErrAccountVerified
 		}
 
-		token, err := mail.MakeEmailToken("verify", user.GetId().Hex(), user.Username, params.Email, []byte(config.SigningKey))
+		token, err := emailToken(model.EmailTokenVerify, user)
 		if err == nil {
 			go _SendVerficationMail(user.Username, params.Email, token)
 		}
@@ -148,10 +199,14 @@ func Verification(c echo.Context) (err error) {
 		return err
 	}
 
-	if data.Action != "verify" {
+	if data.Action != model.EmailTokenVerify {
 		return errors.ErrAccessDenied
 	}
 
+	if _, err = repository.ConsumeEmailToken(data.ID, data.Action, data.UserID); err != nil {
+		return err
+	}
+
 	if err = repository.UserActivation(data.UserID); err != nil {
 		return err
 	}
@@ -166,48 +221,125 @@ func Verification(c echo.Context) (err error) {
 // @Produce json
 // @Param username body string true "Username"
 // @Param password body string true "Password"
-// @Success 201 {object} response.Message
+// @Param remember body bool false "Keep the session for longer"
+// @Success 200 {object} response.Message
+// @Header 200 {string} Refresh-Token "Refresh token"
+// @Success 202 {object} response.Message
 // @Router /users/signin [post]
 func Signin(c echo.Context) (err error) {
 
-	ip := c.RealIP()
-	userAgent := c.Request().Header.Get("User-Agent")
+	ip := request.ClientIP(c)
 
 	params := new(SigninShcema)
 	if err = request.GetInputs(c, params); err != nil {
 		return err
 	}
 
-	if err = repository.CheckAbuse(ip, params.Username); err != nil {
+	if err = checkAbuse(c, params.Username); err != nil {
 		return err
 	}
 
-	user, err := repository.FindUserByCredentials(params.Username, params.Password)
+	// names of no account are locked the same way
+	key, account, err := repository.SigninLockKey(params.Username)
 	if err != nil {
+		return err
+	}
+
+	if err = repository.CheckAccountLock(key); err != nil {
+		return err
+	}
+
+	user, err := repository.FindUserByCredentials(params.Username, params.Password)
+	if err == errors.ErrUserNotFound || err == errors.ErrInvalidCredentials {
 		repository.SubmitAttempt(ip, params.Username)
+		failedSignin(key, account)
+		return errors.ErrInvalidCredentials
+	} else if err != nil {
 		return err
 	}
 
-	userID := user.Id.Hex()
-	SID, uuid, err := repository.SessionCreate(ip, userID, userAgent)
+	return completeSignin(c, user, params.Username, params.Remember)
+}
+
+// completeSignin asks for the second factor when the user has two-factor
+// authentication enabled, otherwise the user is signed in. username is what
+// abusive attempts of the second step are recorded under, remember is
+// carried through the challenge.
+func completeSignin(c echo.Context, user *model.User, username string, remember bool) error {
+
+	if user.TwoFactor {
+		challenge := &auth.MFAToken{
+			ID:       user.Id.Hex(),
+			Username: username,
+			Remember: remember,
+			StandardClaims: jwt.StandardClaims{
+				ExpiresAt: time.Now().Add(config.TwoFactorChallengeLifetime).Unix(),
+			},
+		}
+		token, err := challenge.Create([]byte(config.SigningKey))
+		if err != nil {
+			return err
+		}
+		return r.CustomErrorJson(http.StatusAccepted, map[string]string{"challenge": token}, c)
+	}
+
+	// with two-factor authentication the lock is cleared by the second step
+	repository.ClearAccountLock(repository.UserLockKey(user.Id.Hex()))
+
+	return signinUser(c, user.Id.Hex(), remember)
+}
+
+// signinUser opens a session for an already authenticated user and
+// responds with its tokens, remembered sessions expire later.
+func signinUser(c echo.Context, userID string, remember bool) error {
+
+	ip := request.ClientIP(c)
+	userAgent := c.Request().Header.Get("User-Agent")
+
+	SID, uuid, refreshToken, err := repository.SessionCreate(ip, userID, userAgent, remember)
 	if err != nil {
 		return err
 	}
 
+	alertNewDevice(userID, SID, ip, userAgent)
+
+	return sessionTokens(c, userID, SID, uuid, refreshToken)
+}
+
+// sessionTokens responds with a short-lived access token in the
+// Authorization header and the refresh token of the session next to it.
+func sessionTokens(c echo.Context, userID, SID, key, refreshToken string) error {
+
 	token := &auth.UserToken{
-		uuid,
+		key,
 		SID,
 		userID,
 		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 24).Unix(),
+			ExpiresAt: time.Now().Add(config.AccessTokenLifetime).Unix(),
 		},
 	}
-	jwtToken, err := token.Create([]byte(config.SigningKey))
+	jwtToken, err := token.Create(keyring.User)
 	if err != nil {
 		return err
 	}
 
-	return r.CustomErrorWithHeader(http.StatusOK, "Success", map[string]string{echo.HeaderAuthorization: jwtToken}, c)
+	headers := map[string]string{
+		echo.HeaderAuthorization: jwtToken,
+		HeaderRefreshToken:       refreshToken,
+	}
+
+	return r.CustomErrorWithHeader(http.StatusOK, "Success", headers, c)
+}
+
+// TokenKeys godoc
+// @Summary Get the public keys of access tokens
+// @Description Services verify access tokens with these keys, the kid header of a token names its key.
+// @Tags user
+// @Produce json
+// @Success 200 {object} keyring.JWKS
+// @Router /users/jwks [get]
+func TokenKeys(c echo.Context) (err error) {
+	return c.JSON(http.StatusOK, keyring.User.JWKS())
 }
 
 // Forgot godoc
@@ -227,7 +359,7 @@ func Forgot(c echo.Context) (err error) {
 
 	if user, err := repository.CheckEmail(params.Email); err != nil {
 
-		token, err := mail.MakeEmailToken("reset", user.GetId().Hex(), user.Username, params.Email, []byte(config.SigningKey))
+		token, err := emailToken(model.EmailTokenReset, user)
 		if err == nil {
 			go _SendResetMail(user.Username, params.Email, token)
 		}
@@ -240,6 +372,7 @@ func Forgot(c echo.Context) (err error) {
 
 // Reset godoc
 // @Summary Reset password
+// @Description Signs the user out everywhere and revokes their personal access tokens.
 // @Tags user
 // @Accept json
 // @Produce json
@@ -259,24 +392,51 @@ func Reset(c echo.Context) (err error) {
 		return err
 	}
 
-	if data.Action != "reset" {
+	if data.Action != model.EmailTokenReset {
 		return errors.ErrAccessDenied
 	}
 
+	// a rejected password leaves the link usable for another try
+	account, err := repository.GetUserPasswords(data.UserID)
+	if err != nil {
+		return err
+	}
+
+	if err = password.Validate(passwordInput(params.Password, account)); err != nil {
+		return err
+	}
+
+	if _, err = repository.ConsumeEmailToken(data.ID, data.Action, data.UserID); err != nil {
+		return err
+	}
+
 	err = repository.ChangePassword(data.UserID, params.Password, false)
 	if err != nil {
 		return err
 	}
 
+	// whoever knew the old password is signed out too
+	if err = repository.TerminateAllSessions(data.UserID); err != nil {
+		return err
+	}
+
+	if err = repository.RevokeUserPersonalTokens(data.UserID); err != nil {
+		return err
+	}
+
+	repository.ClearAccountLock(repository.UserLockKey(data.UserID))
+
 	return errors.ErrSuccess
 }
 
 // ChangePassword godoc
 // @Summary Change password
+// @Description The other sessions of the user are signed out. Wrong current passwords count toward the account lock like failed signins.
 // @Tags user
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param currentPassword body string true "Current password"
 // @Param password body string true "Password"
 // @Success 200 {object} response.Message
 // @Router /users/auth/password [put]
@@ -287,17 +447,55 @@ func ChangePassword(c echo.Context) (err error) {
 		return err
 	}
 
+	ip := request.ClientIP(c)
 	user := request.AuthenticatedUser(c)
 
+	account, err := repository.GetAccountInfo(user.ID)
+	if err != nil {
+		return err
+	}
+
+	// wrong guesses count like failed signins, a stolen token must not
+	// brute-force the password
+	if err = checkAbuse(c, account.Username); err != nil {
+		return err
+	}
+
+	key := repository.UserLockKey(user.ID)
+	if err = repository.CheckAccountLock(key); err != nil {
+		return err
+	}
+
+	checked, err := repository.CheckUserPassword(user.ID, params.CurrentPassword)
+	if err == errors.ErrInvalidCredentials {
+		repository.SubmitAttempt(ip, account.Username)
+		failedSignin(key, account)
+		return err
+	} else if err != nil {
+		return err
+	}
+	account = checked
+
+	repository.ClearAccountLock(key)
+
+	if err = password.Validate(passwordInput(params.Password, account)); err != nil {
+		return err
+	}
+
 	if err = repository.ChangePassword(user.ID, params.Password, false); err != nil {
 		return err
 	}
 
+	if err = repository.TerminateOtherSessions(user.ID, user.SID); err != nil {
+		return err
+	}
+
 	return errors.ErrSuccess
 }
 
 // ChangeUsername godoc
 // @Summary Update username
+// @Description Reserved names are refused and the username can only change once per USERNAME_CHANGE_COOLDOWN, the previous one stays held for the user for USERNAME_RESERVATION.
 // @Tags user
 // @Accept json
 // @Produce json
@@ -400,6 +598,7 @@ func GetAccount(c echo.Context) (err error) {
 
 // ChangeEmail godoc
 // @Summary Change user account email
+// @Description The new address gets a confirmation link and the account switches to it once the link is used, the current address gets a link that cancels the change.
 // @Tags user
 // @Accept json
 // @Produce json
@@ -416,7 +615,91 @@ func ChangeEmail(c echo.Context) (err error) {
 
 	user := request.AuthenticatedUser(c)
 
-	if err = repository.ChangeEmail(user.ID, params.Email, false); err != nil {
+	account, err := repository.RequestEmailChange(user.ID, params.Email)
+	if err != nil {
+		return err
+	}
+
+	confirm, err := emailTokenTo(model.EmailTokenConfirmEmail, account, account.PendingEmail)
+	if err != nil {
+		return err
+	}
+
+	cancel, err := emailToken(model.EmailTokenCancelEmail, account)
+	if err != nil {
+		return err
+	}
+
+	go _SendConfirmEmailMail(account.Username, account.PendingEmail, confirm)
+	go _SendEmailChangingMail(account.Username, account.Email, account.PendingEmail, cancel)
+
+	return errors.ErrSuccess
+}
+
+// ConfirmEmailChange godoc
+// @Summary Confirm the new email address
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param token body string true "Token"
+// @Success 200 {object} response.Message
+// @Router /users/email/confirm [post]
+func ConfirmEmailChange(c echo.Context) (err error) {
+
+	params := new(EmailChangeShcema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	data, err := j.ParseEmailToken(params.Token, []byte(config.SigningKey))
+	if err != nil {
+		return err
+	}
+
+	if data.Action != model.EmailTokenConfirmEmail {
+		return errors.ErrAccessDenied
+	}
+
+	if _, err = repository.ConsumeEmailToken(data.ID, data.Action, data.UserID); err != nil {
+		return err
+	}
+
+	if err = repository.ConfirmEmailChange(data.UserID, data.Email); err != nil {
+		return err
+	}
+
+	return errors.ErrSuccess
+}
+
+// CancelEmailChange godoc
+// @Summary Cancel a pending email change
+// @Tags user
+// @Accept json
+// @Produce json
+// @Param token body string true "Token"
+// @Success 200 {object} response.Message
+// @Router /users/email/cancel [post]
+func CancelEmailChange(c echo.Context) (err error) {
+
+	params := new(EmailChangeShcema)
+	if err = request.GetInputs(c, params); err != nil {
+		return err
+	}
+
+	data, err := j.ParseEmailToken(params.Token, []byte(config.SigningKey))
+	if err != nil {
+		return err
+	}
+
+	if data.Action != model.EmailTokenCancelEmail {
+		return errors.ErrAccessDenied
+	}
+
+	if _, err = repository.ConsumeEmailToken(data.ID, data.Action, data.UserID); err != nil {
+		return err
+	}
+
+	if err = repository.CancelEmailChange(data.UserID); err != nil {
 		return err
 	}
 
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
@@ -18,9 +19,11 @@ import (
 	"github.com/thedevsir/frame-backend/services/auth"
 	"github.com/thedevsir/frame-backend/services/errors"
 	j "github.com/thedevsir/frame-backend/services/jwt"
-	"github.com/thedevsir/frame-backend/services/mail"
+	"github.com/thedevsir/frame-backend/services/keyring"
+	"github.com/thedevsir/frame-backend/services/password"
 	"github.com/thedevsir/frame-backend/services/storage"
 	"github.com/thedevsir/frame-backend/services/test"
+	"github.com/thedevsir/frame-backend/services/usernames"
 )
 
 var userCollection *mongodm.Model
@@ -33,6 +36,7 @@ func userBeforeTest() (*model.User, *jwt.Token) {
 	db.Shoot()
 
 	storage.Composer()
+	password.Default = password.NewPolicy(password.Length{Min: 8, Max: 50})
 
 	userCollection = database.Connection.Model(model.UserCollection)
 	userCollection.RemoveAll(nil)
@@ -50,14 +54,15 @@ func userBeforeTest() (*model.User, *jwt.Token) {
 	// CreateUser
 	user, _ := repository.CreateUser(username, password, email)
 
-	secret := []byte("secret")
+	signer, _ := keyring.GenerateKey(keyring.ES256)
+	keyring.User = keyring.NewRing(signer)
 	token := &auth.UserToken{
 		Session: "Session",
 		SID:     "SID",
 		ID:      user.Id.Hex(),
 	}
-	tc, _ := token.Create(secret)
-	tokenParsed, _ := j.ParseJWT(tc, secret)
+	tc, _ := token.Create(keyring.User)
+	tokenParsed, _ := j.ParseSignedJWT(tc, keyring.User)
 
 	return user, tokenParsed
 }
@@ -155,9 +160,8 @@ func TestVerification(t *testing.T) {
 	user, _ := userBeforeTest()
 	defer userAfterTest()
 
-	secret := []byte(config.SigningKey)
-	token0, _ := mail.MakeEmailToken("verify", user.Id.Hex(), user.Username, user.Email, secret)
-	token1, _ := mail.MakeEmailToken("reset", user.Id.Hex(), user.Username, user.Email, secret)
+	token0, _ := emailToken(model.EmailTokenVerify, user)
+	token1, _ := emailToken(model.EmailTokenReset, user)
 
 	t.Run("BindErr", func(t *testing.T) {
 
@@ -185,6 +189,13 @@ func TestVerification(t *testing.T) {
 		c, _ := test.MakeRequest(echo.POST, JSONData)
 		assert.Equal(t, errors.ErrSuccess, Verification(c))
 	})
+
+	t.Run("Replayed", func(t *testing.T) {
+
+		JSONData := fmt.Sprintf(`{"token":"%s"}`, token0)
+		c, _ := test.MakeRequest(echo.POST, JSONData)
+		assert.Equal(t, errors.ErrTokenIsNotValid, Verification(c))
+	})
 }
 
 func TestSignin(t *testing.T) {
@@ -209,7 +220,7 @@ func TestSignin(t *testing.T) {
 
 		JSONData := `{"username":"ir","password":"12345"}`
 		c, _ := test.MakeRequest(echo.POST, JSONData)
-		assert.Equal(t, errors.ErrUserNotFound, Signin(c))
+		assert.Equal(t, errors.ErrInvalidCredentials, Signin(c))
 	})
 
 	t.Run("LoginSuccessfully", func(t *testing.T) {
@@ -221,6 +232,20 @@ func TestSignin(t *testing.T) {
 			assert.Equal(t, http.StatusOK, rec.Code)
 		}
 	})
+
+	t.Run("Remember", func(t *testing.T) {
+
+		JSONData := `{"username":"amir","password":"12345678","remember":true}`
+		c, rec := test.MakeRequest(echo.POST, JSONData)
+
+		if assert.NoError(t, Signin(c)) {
+			SID, _, _ := auth.ParseRefreshToken(rec.Header().Get(HeaderRefreshToken))
+			session, err := repository.SessionFindByID(SID)
+			if assert.Nil(t, err) {
+				assert.True(t, session.Remember)
+			}
+		}
+	})
 }
 
 func TestForgot(t *testing.T) {
@@ -259,9 +284,9 @@ func TestReset(t *testing.T) {
 	user, _ := userBeforeTest()
 	defer userAfterTest()
 
-	secret := []byte(config.SigningKey)
-	token0, _ := mail.MakeEmailToken("reset", user.Id.Hex(), user.Username, user.Email, secret)
-	token1, _ := mail.MakeEmailToken("verify", user.Id.Hex(), user.Username, user.Email, secret)
+	older, _ := emailToken(model.EmailTokenReset, user)
+	token0, _ := emailToken(model.EmailTokenReset, user)
+	token1, _ := emailToken(model.EmailTokenVerify, user)
 
 	t.Run("BindErr", func(t *testing.T) {
 
@@ -289,30 +314,148 @@ func TestReset(t *testing.T) {
 		c, _ := test.MakeRequest(echo.PUT, JSONData)
 		assert.Equal(t, errors.ErrSuccess, Reset(c))
 	})
+
+	t.Run("Superseded", func(t *testing.T) {
+
+		JSONData := fmt.Sprintf(`{"password":"12345678","token":"%s"}`, older)
+		c, _ := test.MakeRequest(echo.PUT, JSONData)
+		assert.Equal(t, errors.ErrTokenIsNotValid, Reset(c))
+	})
+
+	t.Run("Replayed", func(t *testing.T) {
+
+		JSONData := fmt.Sprintf(`{"password":"12345678","token":"%s"}`, token0)
+		c, _ := test.MakeRequest(echo.PUT, JSONData)
+		assert.Equal(t, errors.ErrTokenIsNotValid, Reset(c))
+	})
 }
 
 func TestChangeUsername(t *testing.T) {
 
-	_, tokenParsed := userBeforeTest()
+	user, tokenParsed := userBeforeTest()
 	defer userAfterTest()
 
-	JSONData := `{"username":"Irani"}`
-	c, _ := test.MakeRequest(echo.PUT, JSONData)
-	c.Set("user", tokenParsed)
+	usernames.Reserved = map[string]bool{"admin": true}
+	defer func() { usernames.Reserved = map[string]bool{} }()
+	config.UsernameChangeCooldown = time.Hour
+	config.UsernameReservation = 24 * time.Hour
+
+	other, _ := repository.CreateUser("Other", "12345678", "other@forumX.com")
+
+	change := func(username string) error {
+		c, _ := test.MakeRequest(echo.PUT, `{"username":"`+username+`"}`)
+		c.Set("user", tokenParsed)
+		return ChangeUsername(c)
+	}
+
+	t.Run("Reserved", func(t *testing.T) {
+		assert.Equal(t, errors.ErrUsernameReserved, change("Admin"))
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		assert.Equal(t, errors.ErrUsernameExists, change("OTHER"))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		assert.Equal(t, errors.ErrSuccess, change("Irani"))
+
+		account, _ := repository.GetAccountInfo(user.Id.Hex())
+		assert.Equal(t, "irani", account.Username)
+		if assert.Len(t, account.UsernameHistory, 1) {
+			assert.Equal(t, "amir", account.UsernameHistory[0].Username)
+		}
+	})
+
+	t.Run("Cooldown", func(t *testing.T) {
+		assert.Equal(t, errors.ErrUsernameCooldown, change("Irani2"))
+	})
 
-	assert.Equal(t, errors.ErrSuccess, ChangeUsername(c))
+	t.Run("PreviousUsernameHeld", func(t *testing.T) {
+		// nobody else can take the old name while it is held for the user
+		assert.Equal(t, errors.ErrUsernameExists, repository.ChangeUsername(other.Id.Hex(), "amir", true))
+
+		// but the user can take it back, admins skip the cooldown
+		assert.Nil(t, repository.ChangeUsername(user.Id.Hex(), "amir", true))
+	})
 }
 
 func TestChangePassword(t *testing.T) {
 
-	_, tokenParsed := userBeforeTest()
+	user, tokenParsed := oauthBeforeTest()
 	defer userAfterTest()
+	defer oauthAfterTest()
 
-	JSONData := `{"password":"87654321"}`
-	c, _ := test.MakeRequest(echo.PUT, JSONData)
-	c.Set("user", tokenParsed)
+	current := tokenParsed.Claims.(jwt.MapClaims)["sid"].(string)
+	other, _, _, _ := repository.SessionCreate("127.0.0.1", user.Id.Hex(), ":::USER-AGENT:::", false)
+
+	t.Run("WrongCurrentPassword", func(t *testing.T) {
+
+		JSONData := `{"currentPassword":"wrong","password":"87654321"}`
+		c, _ := test.MakeRequest(echo.PUT, JSONData)
+		c.Set("user", tokenParsed)
+
+		assert.Equal(t, errors.ErrInvalidCredentials, ChangePassword(c))
+	})
+
+	t.Run("PolicyViolation", func(t *testing.T) {
+
+		JSONData := `{"currentPassword":"12345678","password":"1234567"}`
+		c, _ := test.MakeRequest(echo.PUT, JSONData)
+		c.Set("user", tokenParsed)
+
+		he, ok := ChangePassword(c).(*echo.HTTPError)
+		if assert.True(t, ok) {
+			assert.Equal(t, http.StatusBadRequest, he.Code)
+			assert.Equal(t, "length", he.Message.([]password.Violation)[0].Rule)
+		}
+	})
+
+	t.Run("ReusedPassword", func(t *testing.T) {
+
+		defer func(policy *password.Policy) { password.Default = policy }(password.Default)
+		password.Default = password.NewPolicy(password.History{})
+
+		JSONData := `{"currentPassword":"12345678","password":"12345678"}`
+		c, _ := test.MakeRequest(echo.PUT, JSONData)
+		c.Set("user", tokenParsed)
+
+		he, ok := ChangePassword(c).(*echo.HTTPError)
+		if assert.True(t, ok) {
+			assert.Equal(t, "history", he.Message.([]password.Violation)[0].Rule)
+		}
+	})
+
+	t.Run("Success", func(t *testing.T) {
+
+		JSONData := `{"currentPassword":"12345678","password":"87654321"}`
+		c, _ := test.MakeRequest(echo.PUT, JSONData)
+		c.Set("user", tokenParsed)
+
+		assert.Equal(t, errors.ErrSuccess, ChangePassword(c))
+
+		_, err := repository.SessionFindByID(current)
+		assert.Nil(t, err)
+		_, err = repository.SessionFindByID(other)
+		assert.Equal(t, errors.ErrSessionNotFound, err)
+	})
 
-	assert.Equal(t, errors.ErrSuccess, ChangePassword(c))
+	t.Run("Locked", func(t *testing.T) {
+
+		defer func(threshold int) { config.LockoutThreshold = threshold }(config.LockoutThreshold)
+		config.LockoutThreshold = 1
+		defer func(send func(string, string, string, time.Time) error) { _SendAccountLockedMail = send }(_SendAccountLockedMail)
+		_SendAccountLockedMail = func(username, email, token string, lockedUntil time.Time) error { return nil }
+		defer database.Connection.Model(model.AccountLockCollection).RemoveAll(nil)
+
+		change := func(current string) error {
+			c, _ := test.MakeRequest(echo.PUT, `{"currentPassword":"`+current+`","password":"12345678"}`)
+			c.Set("user", tokenParsed)
+			return ChangePassword(c)
+		}
+
+		assert.Equal(t, errors.ErrInvalidCredentials, change("wrong"))
+		assert.Equal(t, errors.ErrAccountLocked, change("87654321"))
+	})
 }
 
 func TestGetAccount(t *testing.T) {
@@ -330,14 +473,71 @@ func TestGetAccount(t *testing.T) {
 
 func TestChangeEmail(t *testing.T) {
 
-	_, tokenParsed := userBeforeTest()
+	user, tokenParsed := userBeforeTest()
 	defer userAfterTest()
 
-	JSONData := `{"email":"rock@amir.ir"}`
-	c, _ := test.MakeRequest(echo.PUT, JSONData)
-	c.Set("user", tokenParsed)
+	// the mails are sent in the background, the links come back on channels
+	confirms, cancels := make(chan string, 1), make(chan string, 1)
+	_SendConfirmEmailMail = func(username, email, token string) error {
+		confirms <- token
+		return nil
+	}
+	_SendEmailChangingMail = func(username, email, newEmail, token string) error {
+		cancels <- token
+		return nil
+	}
+
+	request := func(email string) (confirm, cancel string) {
+		c, _ := test.MakeRequest(echo.PUT, `{"email":"`+email+`"}`)
+		c.Set("user", tokenParsed)
+		if !assert.Equal(t, errors.ErrSuccess, ChangeEmail(c)) {
+			t.FailNow()
+		}
+		return <-confirms, <-cancels
+	}
+
+	submit := func(handler echo.HandlerFunc, token string) error {
+		c, _ := test.MakeRequest(echo.POST, `{"token":"`+token+`"}`)
+		return handler(c)
+	}
+
+	t.Run("EmailExists", func(t *testing.T) {
 
-	assert.Equal(t, errors.ErrSuccess, ChangeEmail(c))
+		c, _ := test.MakeRequest(echo.PUT, `{"email":"`+user.Email+`"}`)
+		c.Set("user", tokenParsed)
+		assert.Equal(t, errors.ErrEmailExists, ChangeEmail(c))
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+
+		confirm, cancel := request("rock@amir.ir")
+		assert.Equal(t, errors.ErrAccessDenied, submit(ConfirmEmailChange, cancel))
+		assert.Equal(t, errors.ErrSuccess, submit(CancelEmailChange, cancel))
+		assert.Equal(t, errors.ErrTokenIsNotValid, submit(ConfirmEmailChange, confirm))
+	})
+
+	t.Run("Superseded", func(t *testing.T) {
+
+		older, _ := request("rock@amir.ir")
+		confirm, _ := request("roll@amir.ir")
+		assert.Equal(t, errors.ErrTokenIsNotValid, submit(ConfirmEmailChange, older))
+
+		// the address was taken after the change was requested
+		repository.CreateUser("Taken", "12345678", "roll@amir.ir")
+		assert.Equal(t, errors.ErrEmailExists, submit(ConfirmEmailChange, confirm))
+	})
+
+	t.Run("Confirm", func(t *testing.T) {
+
+		confirm, cancel := request("rock@amir.ir")
+		assert.Equal(t, errors.ErrSuccess, submit(ConfirmEmailChange, confirm))
+		assert.Equal(t, errors.ErrTokenIsNotValid, submit(ConfirmEmailChange, confirm))
+		assert.Equal(t, errors.ErrTokenIsNotValid, submit(CancelEmailChange, cancel))
+
+		account, _ := repository.GetAccountInfo(user.Id.Hex())
+		assert.Equal(t, "rock@amir.ir", account.Email)
+		assert.True(t, account.IsEmailVerified)
+	})
 }
 
 func TestGetUser(t *testing.T) {
@@ -0,0 +1,149 @@
+package job
+
+import (
+	"bytes"
+	"log"
+
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/export"
+	"github.com/thedevsir/frame-backend/services/mail"
+	"github.com/thedevsir/frame-backend/services/storage"
+)
+
+var _SendDataExportMail = mail.SendDataExportMail
+
+// ExportData builds the archive of a pending export and stores it. An
+// export the user asked for is mailed to them, admins fetch theirs.
+func ExportData(dataExport *model.DataExport) {
+
+	status := model.DataExportReady
+	if err := buildDataExport(dataExport); err != nil {
+		log.Println(err)
+		status = model.DataExportFailed
+	}
+
+	finished, err := repository.FinishDataExport(dataExport.Id.Hex(), status)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if status != model.DataExportReady || finished.AdminID != "" {
+		return
+	}
+
+	user, err := repository.GetAccountInfo(finished.UserID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	link, err := storage.GetPresignedURL(finished.Object(), config.MinioExportBucket, config.DataExportLifetime)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if err = _SendDataExportMail(user.Username, user.Email, link, finished.ExpireAt); err != nil {
+		log.Println(err)
+	}
+}
+
+func buildDataExport(dataExport *model.DataExport) error {
+
+	// the password is left out
+	user, err := repository.GetAccountInfo(dataExport.UserID)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := repository.GetUserSessionHistory(dataExport.UserID)
+	if err != nil {
+		return err
+	}
+
+	attempts, err := repository.GetUserAuthAttempts(user)
+	if err != nil {
+		return err
+	}
+
+	devices, err := repository.GetUserKnownDevices(dataExport.UserID)
+	if err != nil {
+		return err
+	}
+
+	buf := &bytes.Buffer{}
+	archive := export.NewArchive(buf)
+
+	if err = archive.JSON("account", user); err != nil {
+		return err
+	}
+
+	if err = archive.JSON("sessions", sessions); err != nil {
+		return err
+	}
+
+	if err = archive.JSON("authAttempts", attempts); err != nil {
+		return err
+	}
+
+	if err = archive.JSON("knownDevices", devices); err != nil {
+		return err
+	}
+
+	avatar, contentType, err := storage.Get(dataExport.UserID, "avatar")
+	switch {
+	case err == errors.ErrObjectNotFound:
+	case err != nil:
+		return err
+	default:
+		err = archive.File("avatar", contentType, avatar)
+		avatar.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = archive.Close(); err != nil {
+		return err
+	}
+
+	return storage.Put(dataExport.Object(), config.MinioExportBucket, buf, int64(buf.Len()), "application/zip")
+}
+
+// RemoveDataExports removes the exports whose link expired with their
+// archive.
+func RemoveDataExports() {
+
+	exports, err := repository.ExpiredDataExports()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	if err = removeDataExports(exports); err != nil {
+		log.Println(err)
+	}
+}
+
+// removeDataExports removes every export it can, the error is the last one
+// that failed.
+func removeDataExports(exports []*model.DataExport) (failed error) {
+
+	for _, dataExport := range exports {
+
+		if err := storage.Delete(dataExport.Object(), config.MinioExportBucket); err != nil && err != errors.ErrObjectNotFound {
+			failed = err
+			continue
+		}
+
+		if err := repository.RemoveDataExport(dataExport.Id.Hex()); err != nil && err != errors.ErrDataExportNotFound {
+			failed = err
+		}
+	}
+
+	return failed
+}
@@ -0,0 +1,70 @@
+package job
+
+import (
+	"log"
+	"time"
+
+	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/storage"
+)
+
+// Composer starts the background jobs, they run on every instance and are
+// safe to run at the same time.
+func Composer() {
+
+	if config.AccountDeletionInterval > 0 {
+		go run(config.AccountDeletionInterval, DeleteAccounts)
+	}
+
+	if config.DataExportInterval > 0 {
+		go run(config.DataExportInterval, RemoveDataExports)
+	}
+}
+
+func run(interval time.Duration, job func()) {
+
+	for range time.Tick(interval) {
+		job()
+	}
+}
+
+// DeleteAccounts deletes the accounts whose grace period is over with
+// their avatar and data exports. The account goes last, so an account
+// whose data failed to go stays due and the next run retries it.
+func DeleteAccounts() {
+
+	users, err := repository.DueAccountDeletions()
+	if err != nil {
+		log.Println(err)
+		return
+	}
+
+	for _, user := range users {
+
+		if err = deleteAccountObjects(user.Id.Hex()); err != nil {
+			log.Println(err)
+			continue
+		}
+
+		// another instance may have deleted it already
+		if err = repository.DeleteAccount(user); err != nil && err != errors.ErrUserNotFound {
+			log.Println(err)
+		}
+	}
+}
+
+func deleteAccountObjects(userID string) error {
+
+	if err := storage.Delete(userID, "avatar"); err != nil && err != errors.ErrObjectNotFound {
+		return err
+	}
+
+	exports, err := repository.GetUserDataExports(userID)
+	if err != nil {
+		return err
+	}
+
+	return removeDataExports(exports)
+}
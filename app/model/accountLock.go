@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/zebresel-com/mongodm"
+)
+
+const AccountLockCollection = "AccountLock"
+
+// AccountLock counts the failed signins of an account. Names that belong to
+// no account are counted the same way, so a lock does not tell whether the
+// account exists.
+type AccountLock struct {
+	mongodm.DocumentBase `json:",inline" bson:",inline"`
+
+	Key           string     `json:"-" bson:"key"`
+	UserID        string     `json:"userId,omitempty" bson:"userId,omitempty"`
+	Failures      int        `json:"failures" bson:"failures"`
+	LastFailureAt time.Time  `json:"lastFailureAt" bson:"lastFailureAt"`
+	LockedUntil   *time.Time `json:"lockedUntil" bson:"lockedUntil"`
+}
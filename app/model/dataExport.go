@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/zebresel-com/mongodm"
+)
+
+const DataExportCollection = "DataExport"
+
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+)
+
+// DataExport tracks an archive of the data stored about a user, the archive
+// is kept in storage until ExpireAt.
+type DataExport struct {
+	mongodm.DocumentBase `json:",inline" bson:",inline"`
+
+	UserID   string    `json:"userId" bson:"userId"`
+	AdminID  string    `json:"adminId,omitempty" bson:"adminId,omitempty"`
+	Status   string    `json:"status" bson:"status"`
+	ExpireAt time.Time `json:"expireAt" bson:"expireAt"`
+	URL      string    `json:"url,omitempty" bson:"-"`
+}
+
+// Object is the name of the archive in storage.
+func (e *DataExport) Object() string {
+	return e.Id.Hex() + ".zip"
+}
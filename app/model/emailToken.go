@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/zebresel-com/mongodm"
+)
+
+const EmailTokenCollection = "EmailToken"
+
+const (
+	EmailTokenVerify = "verify"
+	EmailTokenReset  = "reset"
+	EmailTokenLogin  = "login"
+
+	// an email change is confirmed from the new address and can be
+	// canceled from the old one
+	EmailTokenConfirmEmail = "confirmEmail"
+	EmailTokenCancelEmail  = "cancelEmail"
+
+	EmailTokenRestore = "restore"
+	EmailTokenUnlock  = "unlock"
+
+	// a sign in from a new device is undone from the alert it sent
+	EmailTokenSigninAlert = "signinAlert"
+)
+
+type EmailToken struct {
+	mongodm.DocumentBase `json:",inline" bson:",inline"`
+
+	Token      string     `json:"-" bson:"token"`
+	Action     string     `json:"action" bson:"action"`
+	UserID     string     `json:"userId" bson:"userId"`
+	SessionID  string     `json:"sessionId,omitempty" bson:"sessionId,omitempty"`
+	ExpireAt   time.Time  `json:"expireAt" bson:"expireAt"`
+	ConsumedAt *time.Time `json:"consumedAt" bson:"consumedAt"`
+}
@@ -0,0 +1,21 @@
+package model
+
+import (
+	"time"
+
+	"github.com/zebresel-com/mongodm"
+)
+
+const KnownDeviceCollection = "KnownDevice"
+
+// KnownDevice is an address and user agent the user signed in from, the
+// user is alerted of signins from others. Unused ones are forgotten.
+type KnownDevice struct {
+	mongodm.DocumentBase `json:",inline" bson:",inline"`
+
+	UserID     string    `json:"userId" bson:"userId"`
+	Key        string    `json:"-" bson:"key"`
+	IP         string    `json:"ip" bson:"ip"`
+	UserAgent  string    `json:"userAgent" bson:"userAgent"`
+	LastUsedAt time.Time `json:"lastUsedAt" bson:"lastUsedAt"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"github.com/zebresel-com/mongodm"
+)
+
+const OAuthClientCollection = "OAuthClient"
+
+type OAuthClient struct {
+	mongodm.DocumentBase `json:",inline" bson:",inline"`
+
+	Name         string   `json:"name" bson:"name"`
+	ClientID     string   `json:"clientId" bson:"clientId"`
+	Secret       string   `json:"-" bson:"secret"`
+	Public       bool     `json:"public" bson:"public"`
+	RedirectURIs []string `json:"redirectUris" bson:"redirectUris"`
+	GrantTypes   []string `json:"grantTypes" bson:"grantTypes"`
+	Scopes       []string `json:"scopes" bson:"scopes"`
+	IsActive     bool     `json:"isActive" bson:"isActive"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/zebresel-com/mongodm"
+)
+
+const OAuthCodeCollection = "OAuthCode"
+
+type OAuthCode struct {
+	mongodm.DocumentBase `json:",inline" bson:",inline"`
+
+	Code                string    `json:"-" bson:"code"`
+	ClientID            string    `json:"clientId" bson:"clientId"`
+	UserID              string    `json:"userId" bson:"userId"`
+	RedirectURI         string    `json:"redirectUri" bson:"redirectUri"`
+	Scopes              []string  `json:"scopes" bson:"scopes"`
+	CodeChallenge       string    `json:"-" bson:"codeChallenge"`
+	CodeChallengeMethod string    `json:"-" bson:"codeChallengeMethod"`
+	Nonce               string    `json:"-" bson:"nonce"`
+	AuthTime            time.Time `json:"authTime" bson:"authTime"`
+	ExpireAt            time.Time `json:"expireAt" bson:"expireAt"`
+}
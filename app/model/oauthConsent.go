@@ -0,0 +1,15 @@
+package model
+
+import (
+	"github.com/zebresel-com/mongodm"
+)
+
+const OAuthConsentCollection = "OAuthConsent"
+
+type OAuthConsent struct {
+	mongodm.DocumentBase `json:",inline" bson:",inline"`
+
+	UserID   string   `json:"userId" bson:"userId"`
+	ClientID string   `json:"clientId" bson:"clientId"`
+	Scopes   []string `json:"scopes" bson:"scopes"`
+}
@@ -0,0 +1,25 @@
+package model
+
+import (
+	"time"
+
+	"github.com/zebresel-com/mongodm"
+)
+
+const OAuthTokenCollection = "OAuthToken"
+
+const (
+	OAuthAccessToken  = "access"
+	OAuthRefreshToken = "refresh"
+)
+
+type OAuthToken struct {
+	mongodm.DocumentBase `json:",inline" bson:",inline"`
+
+	Token    string    `json:"-" bson:"token"`
+	Type     string    `json:"type" bson:"type"`
+	ClientID string    `json:"clientId" bson:"clientId"`
+	UserID   string    `json:"userId,omitempty" bson:"userId"`
+	Scopes   []string  `json:"scopes" bson:"scopes"`
+	ExpireAt time.Time `json:"expireAt" bson:"expireAt"`
+}
@@ -0,0 +1,22 @@
+package model
+
+import (
+	"time"
+
+	"github.com/zebresel-com/mongodm"
+)
+
+const PasskeyCollection = "Passkey"
+
+type Passkey struct {
+	mongodm.DocumentBase `json:",inline" bson:",inline"`
+
+	UserID        string     `json:"userId" bson:"userId"`
+	Name          string     `json:"name" bson:"name"`
+	CredentialID  string     `json:"credentialId" bson:"credentialId"`
+	PublicKey     []byte     `json:"-" bson:"publicKey"`
+	Algorithm     int        `json:"algorithm" bson:"algorithm"`
+	SignCount     int64      `json:"-" bson:"signCount"`
+	LastChallenge string     `json:"-" bson:"lastChallenge"`
+	LastUsedAt    *time.Time `json:"lastUsedAt" bson:"lastUsedAt"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"github.com/zebresel-com/mongodm"
+)
+
+const PersonalTokenCollection = "PersonalToken"
+
+// PersonalTokenPrefix tells personal access tokens apart from session
+// JWTs in the Authorization header.
+const PersonalTokenPrefix = "pat_"
+
+const (
+	ScopeAccountRead   = "account:read"
+	ScopeAccountWrite  = "account:write"
+	ScopeAccountExport = "account:export"
+)
+
+// PersonalTokenScopes can be granted to a personal access token, a session
+// holds all of them.
+var PersonalTokenScopes = []string{
+	ScopeAccountRead,
+	ScopeAccountWrite,
+	ScopeAccountExport,
+}
+
+type PersonalToken struct {
+	mongodm.DocumentBase `json:",inline" bson:",inline"`
+
+	UserID     string     `json:"userId" bson:"userId"`
+	Name       string     `json:"name" bson:"name"`
+	Token      string     `json:"-" bson:"token"`
+	Scopes     []string   `json:"scopes" bson:"scopes"`
+	ExpireAt   *time.Time `json:"expireAt" bson:"expireAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt" bson:"lastUsedAt"`
+	LastUsedIP string     `json:"lastUsedIp" bson:"lastUsedIp"`
+}
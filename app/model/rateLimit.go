@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"github.com/zebresel-com/mongodm"
+)
+
+const RateLimitCollection = "RateLimit"
+
+// RateLimit counts the hits on a key in the window starting at Start, it
+// expires once the next window is over too.
+type RateLimit struct {
+	mongodm.DocumentBase `json:",inline" bson:",inline"`
+
+	Key      string    `json:"key" bson:"key"`
+	Start    time.Time `json:"start" bson:"start"`
+	Count    int       `json:"count" bson:"count"`
+	ExpireAt time.Time `json:"expireAt" bson:"expireAt"`
+}
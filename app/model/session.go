@@ -8,13 +8,38 @@ import (
 
 const SessionCollection = "Session"
 
+// SessionDevice is what the user agent of a session tells about it.
+type SessionDevice struct {
+	Browser string `json:"browser" bson:"browser"`
+	OS      string `json:"os" bson:"os"`
+	Type    string `json:"type" bson:"type"`
+}
+
+// SessionLocation is where the address of a session approximately is.
+type SessionLocation struct {
+	Country     string `json:"country" bson:"country"`
+	CountryCode string `json:"countryCode" bson:"countryCode"`
+	City        string `json:"city" bson:"city"`
+}
+
 type Session struct {
 	mongodm.DocumentBase `json:",inline" bson:",inline"`
 
-	IP           string    `json:"ip" bson:"ip"`
-	Key          string    `json:"key" bson:"key"`
-	UserID       string    `json:"userId" bson:"userId"`
-	UserAgent    string    `json:"userAgent" bson:"userAgent"`
-	LastActivity time.Time `json:"lastActivity" bson:"lastActivity"`
-	ExpireAt     time.Time `json:"expireAt" bson:"expireAt"`
+	IP           string          `json:"ip" bson:"ip"`
+	Key          string          `json:"key" bson:"key"`
+	UserID       string          `json:"userId" bson:"userId"`
+	UserAgent    string          `json:"userAgent" bson:"userAgent"`
+	Device       SessionDevice   `json:"device" bson:"device"`
+	Location     SessionLocation `json:"location" bson:"location"`
+	Label        string          `json:"label" bson:"label"`
+	Current      bool            `json:"current" bson:"-"`
+	Remember     bool            `json:"remember" bson:"remember"`
+	LastActivity time.Time       `json:"lastActivity" bson:"lastActivity"`
+
+	// ExpireAt slides with activity up to AbsoluteExpireAt
+	ExpireAt         time.Time `json:"expireAt" bson:"expireAt"`
+	AbsoluteExpireAt time.Time `json:"absoluteExpireAt" bson:"absoluteExpireAt"`
+
+	RefreshSeed       string `json:"-" bson:"refreshSeed"`
+	RefreshGeneration int64  `json:"-" bson:"refreshGeneration"`
 }
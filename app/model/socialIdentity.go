@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"github.com/zebresel-com/mongodm"
+)
+
+const SocialIdentityCollection = "SocialIdentity"
+
+type SocialIdentity struct {
+	mongodm.DocumentBase `json:",inline" bson:",inline"`
+
+	UserID     string     `json:"userId" bson:"userId"`
+	Provider   string     `json:"provider" bson:"provider"`
+	Subject    string     `json:"subject" bson:"subject"`
+	Email      string     `json:"email" bson:"email"`
+	Username   string     `json:"username" bson:"username"`
+	LastUsedAt *time.Time `json:"lastUsedAt" bson:"lastUsedAt"`
+}
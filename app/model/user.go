@@ -1,6 +1,8 @@
 package model
 
 import (
+	"time"
+
 	"github.com/zebresel-com/mongodm"
 )
 
@@ -14,4 +16,33 @@ type User struct {
 	Email           string `json:"email" bson:"email"`
 	IsEmailVerified bool   `json:"isEmailVerified" bson:"isEmailVerified"`
 	IsActive        bool   `json:"isActive" bson:"isActive"`
+	PendingEmail    string `json:"pendingEmail,omitempty" bson:"pendingEmail,omitempty"`
+
+	// DeleteAt is set while a deleted account can still be restored
+	DeleteAt *time.Time `json:"deleteAt,omitempty" bson:"deleteAt,omitempty"`
+
+	PasswordHistory []string `json:"-" bson:"passwordHistory"`
+
+	UsernameChangedAt *time.Time         `json:"usernameChangedAt" bson:"usernameChangedAt"`
+	UsernameHistory   []PreviousUsername `json:"usernameHistory" bson:"usernameHistory"`
+
+	TwoFactor         bool   `json:"twoFactor" bson:"twoFactor"`
+	TwoFactorSecret   string `json:"-" bson:"twoFactorSecret"`
+	TwoFactorPending  string `json:"-" bson:"twoFactorPending"`
+	TwoFactorLastStep int64  `json:"-" bson:"twoFactorLastStep"`
+
+	RecoveryCodes []RecoveryCode `json:"-" bson:"recoveryCodes"`
+}
+
+// PreviousUsername stays reserved for the user for a while after the
+// change, so nobody can take it over to impersonate them.
+type PreviousUsername struct {
+	Username  string    `json:"username" bson:"username"`
+	ChangedAt time.Time `json:"changedAt" bson:"changedAt"`
+}
+
+type RecoveryCode struct {
+	Hash   string     `json:"-" bson:"hash"`
+	UsedAt *time.Time `json:"usedAt" bson:"usedAt"`
+	UsedIP string     `json:"usedIp" bson:"usedIp"`
 }
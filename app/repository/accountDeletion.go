@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// userCollections hold documents of a user under its userId, they go
+// with the account.
+var userCollections = []string{
+	model.SessionCollection,
+	model.EmailTokenCollection,
+	model.PasskeyCollection,
+	model.SocialIdentityCollection,
+	model.OAuthConsentCollection,
+	model.OAuthCodeCollection,
+	model.OAuthTokenCollection,
+	model.PersonalTokenCollection,
+	model.KnownDeviceCollection,
+}
+
+// ScheduleAccountDeletion deactivates the account and signs it out, it is
+// deleted at deleteAt unless it is restored before.
+func ScheduleAccountDeletion(userID string, deleteAt time.Time) error {
+
+	userModel := database.Connection.Model(model.UserCollection)
+	update := bson.M{
+		"$set": bson.M{
+			"isActive": false,
+			"deleteAt": deleteAt,
+		},
+	}
+
+	err := userModel.Update(bson.M{"_id": bson.ObjectIdHex(userID), "isActive": true}, update)
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrUserNotFound
+	case err != nil:
+		return errors.ErrInternal
+	}
+
+	if err = TerminateAllSessions(userID); err != nil {
+		return err
+	}
+
+	return InvalidateEmailTokens(userID, emailTokenActions...)
+}
+
+// RestoreAccount calls a scheduled deletion off while it is not due yet.
+func RestoreAccount(userID string) error {
+
+	userModel := database.Connection.Model(model.UserCollection)
+	update := bson.M{
+		"$set": bson.M{
+			"isActive": true,
+		},
+		"$unset": bson.M{
+			"deleteAt": "",
+		},
+	}
+
+	err := userModel.Update(bson.M{"_id": bson.ObjectIdHex(userID), "deleteAt": bson.M{"$gt": time.Now()}}, update)
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrTokenIsNotValid
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
+
+// DueAccountDeletions lists the accounts whose grace period is over.
+func DueAccountDeletions() ([]*model.User, error) {
+
+	userModel := database.Connection.Model(model.UserCollection)
+	users := []*model.User{}
+	err := userModel.Find(bson.M{"deleteAt": bson.M{"$lte": time.Now()}}).
+		Select(bson.M{"username": 1, "email": 1, "deleteAt": 1}).
+		Exec(&users)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return users, nil
+}
+
+// DeleteAccount removes a due account for good with everything stored for
+// it. A due account can not be restored anymore, so its data goes first and
+// the account last: a step failing leaves it due for the next run.
+func DeleteAccount(user *model.User) error {
+
+	userModel := database.Connection.Model(model.UserCollection)
+	due := bson.M{"_id": user.Id, "deleteAt": bson.M{"$lte": time.Now()}}
+
+	count, err := userModel.Find(due).Count()
+	switch {
+	case err != nil:
+		return errors.ErrInternal
+	case count == 0:
+		return errors.ErrUserNotFound
+	}
+
+	userID := user.Id.Hex()
+	for _, collection := range userCollections {
+		if _, err = database.Connection.Model(collection).RemoveAll(bson.M{"userId": userID}); err != nil {
+			return errors.ErrInternal
+		}
+	}
+
+	if err = ClearAccountLock(UserLockKey(userID)); err != nil {
+		return err
+	}
+
+	attemptModel := database.Connection.Model(model.AuthAttemptCollection)
+	if _, err = attemptModel.RemoveAll(bson.M{"username": bson.M{"$in": attemptUsernames(user)}}); err != nil {
+		return errors.ErrInternal
+	}
+
+	// another instance may have deleted it meanwhile
+	err = userModel.Remove(due)
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrUserNotFound
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
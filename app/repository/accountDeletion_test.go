@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestAccountDeletion(t *testing.T) {
+
+	userBeforeTest()
+	defer userAfterTest()
+
+	tokenCollection := database.Connection.Model(model.EmailTokenCollection)
+	attemptCollection := database.Connection.Model(model.AuthAttemptCollection)
+	for _, collection := range []string{model.EmailTokenCollection, model.AuthAttemptCollection} {
+		database.Connection.Model(collection).RemoveAll(nil)
+		defer database.Connection.Model(collection).RemoveAll(nil)
+	}
+
+	user, err := CreateUser("Deleted", "12345678", "deleted@forumX.com")
+	if !assert.Nil(t, err) {
+		return
+	}
+	userID := user.Id.Hex()
+
+	t.Run("ScheduleAccountDeletion", func(t *testing.T) {
+
+		t.Run("UserNotFound", func(t *testing.T) {
+			err := ScheduleAccountDeletion(bson.NewObjectId().Hex(), time.Now())
+			assert.Equal(t, errors.ErrUserNotFound, err)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			assert.Nil(t, ScheduleAccountDeletion(userID, time.Now().Add(time.Hour)))
+
+			_, err := CheckUserPassword(userID, "12345678")
+			assert.Equal(t, errors.ErrUserNotFound, err)
+		})
+	})
+
+	t.Run("DueAccountDeletions", func(t *testing.T) {
+
+		users, err := DueAccountDeletions()
+		if assert.Nil(t, err) {
+			assert.Empty(t, users)
+		}
+	})
+
+	t.Run("RestoreAccount", func(t *testing.T) {
+
+		assert.Nil(t, RestoreAccount(userID))
+		assert.Equal(t, errors.ErrTokenIsNotValid, RestoreAccount(userID))
+
+		_, err := CheckUserPassword(userID, "12345678")
+		assert.Nil(t, err)
+	})
+
+	t.Run("DeleteAccount", func(t *testing.T) {
+
+		t.Run("NotDue", func(t *testing.T) {
+			assert.Equal(t, errors.ErrUserNotFound, DeleteAccount(user))
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			assert.Nil(t, ScheduleAccountDeletion(userID, time.Now().Add(-time.Second)))
+			CreateEmailToken(model.EmailTokenRestore, userID, time.Hour)
+			SubmitAttempt("127.0.0.1", user.Email)
+			assert.Equal(t, errors.ErrTokenIsNotValid, RestoreAccount(userID))
+
+			users, err := DueAccountDeletions()
+			if assert.Nil(t, err) && assert.Len(t, users, 1) {
+				assert.Nil(t, DeleteAccount(users[0]))
+			}
+
+			count, _ := userCollection.Find(bson.M{"_id": user.Id}).Count()
+			assert.Equal(t, 0, count)
+			count, _ = tokenCollection.Find(bson.M{"userId": userID}).Count()
+			assert.Equal(t, 0, count)
+			count, _ = attemptCollection.Find(bson.M{"username": user.Email}).Count()
+			assert.Equal(t, 0, count)
+		})
+	})
+}
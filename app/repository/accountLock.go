@@ -0,0 +1,166 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/usernames"
+	"github.com/zebresel-com/mongodm"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// UserLockKey is the lock of an account, whichever name it signs in with.
+func UserLockKey(userID string) string {
+	return "user:" + userID
+}
+
+// SigninLockKey finds the lock of the name a signin was attempted with, and
+// the account it belongs to if there is one.
+func SigninLockKey(username string) (string, *model.User, error) {
+
+	userModel := database.Connection.Model(model.UserCollection)
+	user := &model.User{}
+
+	name := usernames.Canonical(username)
+	findStruct := bson.M{"username": name}
+	if strings.Index(username, "@") > -1 {
+		name = strings.ToLower(username)
+		findStruct = bson.M{"email": name}
+	}
+
+	err := userModel.FindOne(findStruct).Select(bson.M{"username": 1, "email": 1, "isActive": 1}).Exec(user)
+	_, ok := err.(*mongodm.NotFoundError)
+	switch {
+	case ok:
+		return "name:" + name, nil, nil
+	case err != nil:
+		return "", nil, errors.ErrInternal
+	default:
+		return UserLockKey(user.Id.Hex()), user, nil
+	}
+}
+
+func CheckAccountLock(key string) error {
+
+	lockModel := database.Connection.Model(model.AccountLockCollection)
+	count, err := lockModel.Find(bson.M{"key": key, "lockedUntil": bson.M{"$gt": time.Now()}}).Count()
+	switch {
+	case err != nil:
+		return errors.ErrInternal
+	case count != 0:
+		return errors.ErrAccountLocked
+	default:
+		return nil
+	}
+}
+
+// lockoutDelay doubles with every failure past the threshold.
+func lockoutDelay(failures int) time.Duration {
+
+	delay := config.LockoutDelay
+	for i := config.LockoutThreshold; i < failures && delay < config.LockoutMaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > config.LockoutMaxDelay {
+		delay = config.LockoutMaxDelay
+	}
+
+	return delay
+}
+
+// RecordFailedSignin counts a failure against the lock and locks it once
+// the threshold is reached. locked is set only by the failure that started
+// a run of locks, so the owner is told once.
+func RecordFailedSignin(key, userID string) (lock *model.AccountLock, locked bool, err error) {
+
+	lockModel := database.Connection.Model(model.AccountLockCollection)
+	now := time.Now()
+
+	// failures older than the window are forgotten
+	_, err = lockModel.Collection.UpdateAll(
+		bson.M{"key": key, "lastFailureAt": bson.M{"$lte": now.Add(-config.LockoutWindow)}},
+		bson.M{"$set": bson.M{"failures": 0}},
+	)
+	if err != nil {
+		return nil, false, errors.ErrInternal
+	}
+
+	set := bson.M{"lastFailureAt": now, "updatedAt": now}
+	if userID != "" {
+		set["userId"] = userID
+	}
+	change := mgo.Change{
+		Update: bson.M{
+			"$inc":         bson.M{"failures": 1},
+			"$set":         set,
+			"$setOnInsert": bson.M{"createdAt": now},
+		},
+		Upsert:    true,
+		ReturnNew: true,
+	}
+
+	lock = &model.AccountLock{}
+	if _, err = lockModel.Collection.Find(bson.M{"key": key}).Apply(change, lock); err != nil {
+		return nil, false, errors.ErrInternal
+	}
+
+	if lock.Failures < config.LockoutThreshold {
+		return lock, false, nil
+	}
+
+	lockedUntil := now.Add(lockoutDelay(lock.Failures))
+	if err = lockModel.Update(bson.M{"_id": lock.Id}, bson.M{"$set": bson.M{"lockedUntil": lockedUntil}}); err != nil {
+		return nil, false, errors.ErrInternal
+	}
+	lock.LockedUntil = &lockedUntil
+
+	return lock, lock.Failures == config.LockoutThreshold, nil
+}
+
+// ClearAccountLock forgets the failures once the account is signed in.
+func ClearAccountLock(key string) error {
+
+	lockModel := database.Connection.Model(model.AccountLockCollection)
+	if _, err := lockModel.RemoveAll(bson.M{"key": key}); err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
+
+func GetAccountLock(userID string) (*model.AccountLock, error) {
+
+	lockModel := database.Connection.Model(model.AccountLockCollection)
+	lock := &model.AccountLock{}
+
+	err := lockModel.FindOne(bson.M{"key": UserLockKey(userID)}).Exec(lock)
+	_, ok := err.(*mongodm.NotFoundError)
+	switch {
+	case ok:
+		return nil, errors.ErrAccountNotLocked
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return lock, nil
+	}
+}
+
+func UnlockAccount(userID string) error {
+
+	lockModel := database.Connection.Model(model.AccountLockCollection)
+	err := lockModel.Remove(bson.M{"key": UserLockKey(userID)})
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrAccountNotLocked
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestAccountLock(t *testing.T) {
+
+	userBeforeTest()
+	defer userAfterTest()
+
+	lockCollection := database.Connection.Model(model.AccountLockCollection)
+	lockCollection.RemoveAll(nil)
+	defer lockCollection.RemoveAll(nil)
+
+	config.LockoutThreshold = 3
+	config.LockoutDelay = time.Minute
+	config.LockoutMaxDelay = 10 * time.Minute
+	config.LockoutWindow = time.Hour
+
+	userID := bson.NewObjectId().Hex()
+	key := UserLockKey(userID)
+
+	t.Run("lockoutDelay", func(t *testing.T) {
+
+		assert.Equal(t, time.Minute, lockoutDelay(3))
+		assert.Equal(t, 2*time.Minute, lockoutDelay(4))
+		assert.Equal(t, 8*time.Minute, lockoutDelay(6))
+		assert.Equal(t, 10*time.Minute, lockoutDelay(7))
+		assert.Equal(t, 10*time.Minute, lockoutDelay(100))
+	})
+
+	t.Run("RecordFailedSignin", func(t *testing.T) {
+
+		for i := 1; i < config.LockoutThreshold; i++ {
+			_, locked, err := RecordFailedSignin(key, userID)
+			assert.Nil(t, err)
+			assert.False(t, locked)
+		}
+		assert.Nil(t, CheckAccountLock(key))
+
+		lock, locked, err := RecordFailedSignin(key, userID)
+		if assert.Nil(t, err) {
+			assert.True(t, locked)
+			assert.Equal(t, 3, lock.Failures)
+		}
+		assert.Equal(t, errors.ErrAccountLocked, CheckAccountLock(key))
+
+		// only the failure that started the lock reports it
+		_, locked, _ = RecordFailedSignin(key, userID)
+		assert.False(t, locked)
+	})
+
+	t.Run("Window", func(t *testing.T) {
+
+		lockCollection.Update(bson.M{"key": key}, bson.M{"$set": bson.M{"lastFailureAt": time.Now().Add(-2 * time.Hour)}})
+		lock, _, err := RecordFailedSignin(key, userID)
+		if assert.Nil(t, err) {
+			assert.Equal(t, 1, lock.Failures)
+		}
+	})
+
+	t.Run("UnlockAccount", func(t *testing.T) {
+
+		lock, err := GetAccountLock(userID)
+		if assert.Nil(t, err) {
+			assert.Equal(t, userID, lock.UserID)
+		}
+
+		assert.Nil(t, UnlockAccount(userID))
+		assert.Equal(t, errors.ErrAccountNotLocked, UnlockAccount(userID))
+		assert.Nil(t, CheckAccountLock(key))
+	})
+}
@@ -124,9 +124,28 @@ func FindAdminByCredentials(username, password string) (*model.Admin, error) {
 			return nil, errors.ErrInvalidCredentials
 		}
 	}
+
+	if encrypt.NeedsRehash(admin.Password) {
+		rehashAdminPassword(admin, password)
+	}
+
 	return admin, nil
 }
 
+func rehashAdminPassword(admin *model.Admin, password string) {
+
+	hash, err := encrypt.Hash(password)
+	if err != nil {
+		return
+	}
+
+	adminModel := database.Connection.Model(model.AdminCollection)
+	err = adminModel.Update(bson.M{"_id": admin.Id, "password": admin.Password}, bson.M{"$set": bson.M{"password": hash}})
+	if err == nil {
+		admin.Password = hash
+	}
+}
+
 func SetAdminSession(adminID string) (key string, err error) {
 
 	uuid := uuid.Must(uuid.NewV4(), nil).String() // nil
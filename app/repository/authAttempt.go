@@ -8,52 +8,50 @@ import (
 	"github.com/thedevsir/frame-backend/config"
 	"github.com/thedevsir/frame-backend/config/database"
 	"github.com/thedevsir/frame-backend/services/errors"
-	"gopkg.in/mgo.v2/bson"
+	"github.com/thedevsir/frame-backend/services/ratelimit"
 )
 
-func CheckAbuse(ip, username string) error {
+// abuseLimits are the failed attempts an address may make within the abuse
+// window, for any name and for one.
+func abuseLimits(ip, username string) map[string]ratelimit.Policy {
 
-	anHourLater := time.Now().Add(-1 * time.Hour)
-	authAttemptModel := database.Connection.Model(model.AuthAttemptCollection)
-
-	numIP, err := authAttemptModel.Find(
-		bson.M{
-			"ip": ip,
-			"createdAt": bson.M{
-				"$gt": anHourLater,
-			},
-		},
-	).Count()
-
-	if err != nil {
-		return errors.ErrInternal
+	username = strings.ToLower(username)
+	return map[string]ratelimit.Policy{
+		"abuse:ip:" + ip: {Limit: config.AbuseIP, Window: config.AbuseWindow},
+		"abuse:ip-username:" + ip + ":" + username: {Limit: config.AbuseIPUsername, Window: config.AbuseWindow},
 	}
+}
 
-	numIPUsername, err := authAttemptModel.Find(
-		bson.M{
-			"ip":       ip,
-			"username": username,
-			"createdAt": bson.M{
-				"$gt": anHourLater,
-			},
-		},
-	).Count()
+// CheckAbuse rejects an address that made too many failed attempts, wait
+// is how long until it may try again.
+func CheckAbuse(ip, username string) (wait time.Duration, err error) {
 
-	if err != nil {
-		return errors.ErrInternal
+	for key, policy := range abuseLimits(ip, username) {
+		limited, err := ratelimit.Store.Peek(key, policy)
+		if err != nil {
+			return 0, errors.ErrInternal
+		}
+		if limited > wait {
+			wait = limited
+		}
 	}
 
-	ipLimitReached := numIP >= config.AbuseIP
-	ipUsernameLimitReached := numIPUsername >= config.AbuseIPUsername
-	if ipLimitReached || ipUsernameLimitReached {
-		return errors.ErrAttemptsReached
+	if wait > 0 {
+		return wait, errors.ErrAttemptsReached
 	}
-
-	return nil
+	return 0, nil
 }
 
+// SubmitAttempt counts a failed attempt against the address and keeps it
+// for the user's history until it expires.
 func SubmitAttempt(IP, username string) error {
 
+	for key, policy := range abuseLimits(IP, username) {
+		if _, err := ratelimit.Store.Take(key, policy); err != nil {
+			return errors.ErrInternal
+		}
+	}
+
 	authAttemptModel := database.Connection.Model(model.AuthAttemptCollection)
 	attempt := &model.AuthAttempt{}
 	authAttemptModel.New(attempt)
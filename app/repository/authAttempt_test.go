@@ -9,6 +9,7 @@ import (
 	"github.com/thedevsir/frame-backend/config"
 	"github.com/thedevsir/frame-backend/config/database"
 	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/ratelimit"
 	"github.com/thedevsir/frame-backend/services/test"
 	"github.com/thedevsir/frame-backend/services/utils"
 )
@@ -24,6 +25,7 @@ func authAttemptBeforeTest() {
 
 	authAttemptCollection = database.Connection.Model(model.AuthAttemptCollection)
 	authAttemptCollection.RemoveAll(nil)
+	ratelimit.Store = ratelimit.NewMemory()
 }
 
 func authAttemptAfterTest() {
@@ -47,7 +49,9 @@ func TestAuthAttempt(t *testing.T) {
 		config.AbuseIP = 10
 		config.AbuseIPUsername = 10
 
-		assert.Nil(t, CheckAbuse(ip, username))
+		wait, err := CheckAbuse(ip, username)
+		assert.Nil(t, err)
+		assert.Zero(t, wait)
 	})
 
 	t.Run("MaximumAttemptsReached", func(t *testing.T) {
@@ -55,8 +59,8 @@ func TestAuthAttempt(t *testing.T) {
 		config.AbuseIP = 3
 		config.AbuseIPUsername = 3
 
-		err := CheckAbuse(ip, username)
-		assert.Error(t, err)
+		wait, err := CheckAbuse(ip, username)
 		assert.Equal(t, errors.ErrAttemptsReached, err)
+		assert.True(t, wait > 0)
 	})
 }
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/zebresel-com/mongodm"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// CreateDataExport starts an export of the data of a user, requested by the
+// user or by an admin. A requester waits for its pending export to finish.
+func CreateDataExport(userID, adminID string) (*model.DataExport, error) {
+
+	exportModel := database.Connection.Model(model.DataExportCollection)
+
+	filter := bson.M{
+		"userId":   userID,
+		"status":   model.DataExportPending,
+		"expireAt": bson.M{"$gt": time.Now()},
+		"adminId":  bson.M{"$exists": false},
+	}
+	if adminID != "" {
+		filter["adminId"] = adminID
+	}
+
+	count, err := exportModel.Find(filter).Count()
+	switch {
+	case err != nil:
+		return nil, errors.ErrInternal
+	case count != 0:
+		return nil, errors.ErrDataExportPending
+	}
+
+	export := &model.DataExport{}
+	exportModel.New(export)
+
+	export.UserID = userID
+	export.AdminID = adminID
+	export.Status = model.DataExportPending
+	export.ExpireAt = time.Now().Add(config.DataExportLifetime)
+
+	if err = export.Save(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return export, nil
+}
+
+// FinishDataExport records the outcome of an export, a ready archive is
+// kept for the export lifetime from now on.
+func FinishDataExport(ID, status string) (*model.DataExport, error) {
+
+	exportModel := database.Connection.Model(model.DataExportCollection)
+	update := bson.M{
+		"$set": bson.M{
+			"status":   status,
+			"expireAt": time.Now().Add(config.DataExportLifetime),
+		},
+	}
+
+	export := &model.DataExport{}
+	_, err := exportModel.Collection.Find(bson.M{"_id": bson.ObjectIdHex(ID), "status": model.DataExportPending}).
+		Apply(mgo.Change{Update: update, ReturnNew: true}, export)
+	switch {
+	case err == mgo.ErrNotFound:
+		return nil, errors.ErrDataExportNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return export, nil
+	}
+}
+
+func GetDataExport(ID string) (*model.DataExport, error) {
+
+	exportModel := database.Connection.Model(model.DataExportCollection)
+	export := &model.DataExport{}
+
+	err := exportModel.FindOne(bson.M{"_id": bson.ObjectIdHex(ID), "expireAt": bson.M{"$gt": time.Now()}}).Exec(export)
+	_, ok := err.(*mongodm.NotFoundError)
+	switch {
+	case ok:
+		return nil, errors.ErrDataExportNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return export, nil
+	}
+}
+
+// ExpiredDataExports lists the exports whose archive is no longer served.
+func ExpiredDataExports() ([]*model.DataExport, error) {
+	return findDataExports(bson.M{"expireAt": bson.M{"$lte": time.Now()}})
+}
+
+func GetUserDataExports(userID string) ([]*model.DataExport, error) {
+	return findDataExports(bson.M{"userId": userID})
+}
+
+func findDataExports(filter bson.M) ([]*model.DataExport, error) {
+
+	exportModel := database.Connection.Model(model.DataExportCollection)
+	exports := []*model.DataExport{}
+
+	if err := exportModel.Find(filter).Exec(&exports); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return exports, nil
+}
+
+func RemoveDataExport(ID string) error {
+
+	exportModel := database.Connection.Model(model.DataExportCollection)
+	err := exportModel.Remove(bson.M{"_id": bson.ObjectIdHex(ID)})
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrDataExportNotFound
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
+
+// GetUserSessionHistory lists every session of a user, without its key.
+func GetUserSessionHistory(userID string) ([]*model.Session, error) {
+
+	sessionModel := database.Connection.Model(model.SessionCollection)
+	sessions := []*model.Session{}
+
+	err := sessionModel.Find(bson.M{"userId": userID}).
+		Select(bson.M{"key": 0, "refreshSeed": 0}).
+		Sort("createdAt").
+		Exec(&sessions)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return sessions, nil
+}
+
+// attemptUsernames are the names the auth attempts of a user are recorded
+// under, signins take either the username or the email.
+func attemptUsernames(user *model.User) []string {
+	return []string{strings.ToLower(user.Username), strings.ToLower(user.Email)}
+}
+
+func GetUserAuthAttempts(user *model.User) ([]*model.AuthAttempt, error) {
+
+	attemptModel := database.Connection.Model(model.AuthAttemptCollection)
+	attempts := []*model.AuthAttempt{}
+
+	err := attemptModel.Find(bson.M{"username": bson.M{"$in": attemptUsernames(user)}}).
+		Sort("createdAt").
+		Exec(&attempts)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return attempts, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestDataExport(t *testing.T) {
+
+	userBeforeTest()
+	defer userAfterTest()
+
+	exportCollection := database.Connection.Model(model.DataExportCollection)
+	exportCollection.RemoveAll(nil)
+	defer exportCollection.RemoveAll(nil)
+
+	userID, adminID := bson.NewObjectId().Hex(), bson.NewObjectId().Hex()
+
+	dataExport, err := CreateDataExport(userID, "")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	t.Run("CreateDataExport", func(t *testing.T) {
+
+		t.Run("Pending", func(t *testing.T) {
+			_, err := CreateDataExport(userID, "")
+			assert.Equal(t, errors.ErrDataExportPending, err)
+		})
+
+		t.Run("AnotherRequester", func(t *testing.T) {
+			_, err := CreateDataExport(userID, adminID)
+			assert.Nil(t, err)
+		})
+	})
+
+	t.Run("FinishDataExport", func(t *testing.T) {
+
+		finished, err := FinishDataExport(dataExport.Id.Hex(), model.DataExportReady)
+		if assert.Nil(t, err) {
+			assert.Equal(t, model.DataExportReady, finished.Status)
+		}
+
+		_, err = FinishDataExport(dataExport.Id.Hex(), model.DataExportFailed)
+		assert.Equal(t, errors.ErrDataExportNotFound, err)
+
+		_, err = CreateDataExport(userID, "")
+		assert.Nil(t, err)
+	})
+
+	t.Run("GetUserDataExports", func(t *testing.T) {
+
+		exports, err := GetUserDataExports(userID)
+		if assert.Nil(t, err) {
+			assert.Len(t, exports, 3)
+		}
+
+		exports, err = ExpiredDataExports()
+		if assert.Nil(t, err) {
+			assert.Empty(t, exports)
+		}
+	})
+
+	t.Run("RemoveDataExport", func(t *testing.T) {
+
+		assert.Nil(t, RemoveDataExport(dataExport.Id.Hex()))
+		assert.Equal(t, errors.ErrDataExportNotFound, RemoveDataExport(dataExport.Id.Hex()))
+
+		_, err := GetDataExport(dataExport.Id.Hex())
+		assert.Equal(t, errors.ErrDataExportNotFound, err)
+	})
+}
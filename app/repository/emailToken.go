@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/encrypt"
+	"github.com/thedevsir/frame-backend/services/errors"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const emailTokenSize = 32
+
+// CreateEmailToken records a token that can be consumed once, only its
+// digest is stored. The returned value travels inside the emailed link and
+// the links the user got before for the same action stop working.
+func CreateEmailToken(action, userID string, lifetime time.Duration) (string, error) {
+
+	if err := InvalidateEmailTokens(userID, action); err != nil {
+		return "", err
+	}
+
+	return createEmailToken(action, userID, "", lifetime)
+}
+
+// CreateSessionEmailToken records a token about one session of the user,
+// the links sent about other sessions keep working.
+func CreateSessionEmailToken(action, userID, sessionID string, lifetime time.Duration) (string, error) {
+	return createEmailToken(action, userID, sessionID, lifetime)
+}
+
+func createEmailToken(action, userID, sessionID string, lifetime time.Duration) (string, error) {
+
+	tokenModel := database.Connection.Model(model.EmailTokenCollection)
+	token := &model.EmailToken{}
+	tokenModel.New(token)
+
+	value, err := encrypt.RandomToken(emailTokenSize)
+	if err != nil {
+		return "", errors.ErrInternal
+	}
+
+	token.Token = encrypt.Digest(value)
+	token.Action = action
+	token.UserID = userID
+	token.SessionID = sessionID
+	token.ExpireAt = time.Now().Add(lifetime)
+
+	err = token.Save()
+	if err != nil {
+		return "", errors.ErrInternal
+	}
+
+	return value, nil
+}
+
+// ConsumeEmailToken marks the token consumed while reading it, so a link
+// works once even when it is opened twice at the same time.
+func ConsumeEmailToken(value, action, userID string) (*model.EmailToken, error) {
+
+	tokenModel := database.Connection.Model(model.EmailTokenCollection)
+	token := &model.EmailToken{}
+	filter := bson.M{
+		"token":      encrypt.Digest(value),
+		"action":     action,
+		"userId":     userID,
+		"consumedAt": nil,
+		"expireAt":   bson.M{"$gt": time.Now()},
+	}
+	change := mgo.Change{
+		Update:    bson.M{"$set": bson.M{"consumedAt": time.Now()}},
+		ReturnNew: true,
+	}
+
+	_, err := tokenModel.Collection.Find(filter).Apply(change, token)
+	switch {
+	case err == mgo.ErrNotFound:
+		return nil, errors.ErrTokenIsNotValid
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return token, nil
+	}
+}
+
+// InvalidateEmailTokens removes the outstanding tokens of the actions,
+// consumed ones are kept until they expire.
+func InvalidateEmailTokens(userID string, actions ...string) error {
+
+	tokenModel := database.Connection.Model(model.EmailTokenCollection)
+	filter := bson.M{
+		"userId":     userID,
+		"action":     bson.M{"$in": actions},
+		"consumedAt": nil,
+	}
+
+	if _, err := tokenModel.RemoveAll(filter); err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestEmailToken(t *testing.T) {
+
+	userBeforeTest()
+	defer userAfterTest()
+
+	tokenCollection := database.Connection.Model(model.EmailTokenCollection)
+	tokenCollection.RemoveAll(nil)
+	defer tokenCollection.RemoveAll(nil)
+
+	userID := bson.NewObjectId().Hex()
+
+	value, err := CreateEmailToken(model.EmailTokenLogin, userID, time.Minute)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	t.Run("ConsumeEmailToken", func(t *testing.T) {
+
+		t.Run("WrongAction", func(t *testing.T) {
+			_, err := ConsumeEmailToken(value, model.EmailTokenReset, userID)
+			assert.Equal(t, errors.ErrTokenIsNotValid, err)
+		})
+
+		t.Run("WrongUser", func(t *testing.T) {
+			_, err := ConsumeEmailToken(value, model.EmailTokenLogin, bson.NewObjectId().Hex())
+			assert.Equal(t, errors.ErrTokenIsNotValid, err)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			token, err := ConsumeEmailToken(value, model.EmailTokenLogin, userID)
+			if assert.Nil(t, err) {
+				assert.Equal(t, userID, token.UserID)
+				assert.NotNil(t, token.ConsumedAt)
+			}
+		})
+
+		t.Run("Consumed", func(t *testing.T) {
+			_, err := ConsumeEmailToken(value, model.EmailTokenLogin, userID)
+			assert.Equal(t, errors.ErrTokenIsNotValid, err)
+		})
+
+		t.Run("Expired", func(t *testing.T) {
+			expired, _ := CreateEmailToken(model.EmailTokenLogin, userID, -time.Minute)
+			_, err := ConsumeEmailToken(expired, model.EmailTokenLogin, userID)
+			assert.Equal(t, errors.ErrTokenIsNotValid, err)
+		})
+	})
+
+	t.Run("CreateEmailToken", func(t *testing.T) {
+
+		older, _ := CreateEmailToken(model.EmailTokenReset, userID, time.Minute)
+		verify, _ := CreateEmailToken(model.EmailTokenVerify, userID, time.Minute)
+		newer, _ := CreateEmailToken(model.EmailTokenReset, userID, time.Minute)
+
+		_, err := ConsumeEmailToken(older, model.EmailTokenReset, userID)
+		assert.Equal(t, errors.ErrTokenIsNotValid, err)
+
+		_, err = ConsumeEmailToken(newer, model.EmailTokenReset, userID)
+		assert.Nil(t, err)
+
+		// other actions are left alone
+		_, err = ConsumeEmailToken(verify, model.EmailTokenVerify, userID)
+		assert.Nil(t, err)
+	})
+
+	t.Run("InvalidateEmailTokens", func(t *testing.T) {
+
+		reset, _ := CreateEmailToken(model.EmailTokenReset, userID, time.Minute)
+		assert.Nil(t, InvalidateEmailTokens(userID, model.EmailTokenReset, model.EmailTokenLogin))
+
+		_, err := ConsumeEmailToken(reset, model.EmailTokenReset, userID)
+		assert.Equal(t, errors.ErrTokenIsNotValid, err)
+	})
+}
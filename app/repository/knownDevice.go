@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/encrypt"
+	"github.com/thedevsir/frame-backend/services/errors"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func deviceKey(IP, userAgent string) string {
+	return encrypt.Digest(IP + "\n" + userAgent)
+}
+
+// RememberDevice records a signin from the address and user agent. It is
+// new when the user signed in before, but never from them.
+func RememberDevice(userID, IP, userAgent string) (bool, error) {
+
+	deviceModel := database.Connection.Model(model.KnownDeviceCollection)
+	key := deviceKey(IP, userAgent)
+	now := time.Now()
+
+	change := mgo.Change{
+		Update: bson.M{
+			"$set": bson.M{"lastUsedAt": now, "updatedAt": now},
+			"$setOnInsert": bson.M{
+				"ip":        IP,
+				"userAgent": userAgent,
+				"createdAt": now,
+			},
+		},
+		Upsert: true,
+	}
+
+	info, err := deviceModel.Collection.Find(bson.M{"userId": userID, "key": key}).Apply(change, nil)
+	if err != nil {
+		return false, errors.ErrInternal
+	}
+
+	if info.UpsertedId == nil {
+		return false, nil
+	}
+
+	// the first signin has nothing to compare with
+	others, err := deviceModel.Find(bson.M{"userId": userID, "key": bson.M{"$ne": key}}).Count()
+	if err != nil {
+		return false, errors.ErrInternal
+	}
+
+	return others > 0, nil
+}
+
+// ForgetDevice lets the next signin from the address and user agent alert
+// the user again.
+func ForgetDevice(userID, IP, userAgent string) error {
+
+	deviceModel := database.Connection.Model(model.KnownDeviceCollection)
+	if _, err := deviceModel.RemoveAll(bson.M{"userId": userID, "key": deviceKey(IP, userAgent)}); err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
+
+func GetUserKnownDevices(userID string) ([]*model.KnownDevice, error) {
+
+	deviceModel := database.Connection.Model(model.KnownDeviceCollection)
+	devices := []*model.KnownDevice{}
+
+	err := deviceModel.Find(bson.M{"userId": userID}).Sort("createdAt").Exec(&devices)
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return devices, nil
+}
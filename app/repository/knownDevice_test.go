@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestKnownDevice(t *testing.T) {
+
+	userBeforeTest()
+	defer userAfterTest()
+
+	deviceCollection := database.Connection.Model(model.KnownDeviceCollection)
+	deviceCollection.RemoveAll(nil)
+	defer deviceCollection.RemoveAll(nil)
+
+	userID := bson.NewObjectId().Hex()
+
+	t.Run("RememberDevice", func(t *testing.T) {
+
+		isNew, err := RememberDevice(userID, "127.0.0.1", "laptop")
+		assert.Nil(t, err)
+		assert.False(t, isNew)
+
+		isNew, _ = RememberDevice(userID, "127.0.0.1", "laptop")
+		assert.False(t, isNew)
+
+		isNew, _ = RememberDevice(userID, "127.0.0.1", "phone")
+		assert.True(t, isNew)
+
+		isNew, _ = RememberDevice(userID, "10.0.0.1", "phone")
+		assert.True(t, isNew)
+
+		// devices are known per user
+		isNew, _ = RememberDevice(bson.NewObjectId().Hex(), "127.0.0.1", "laptop")
+		assert.False(t, isNew)
+	})
+
+	t.Run("GetUserKnownDevices", func(t *testing.T) {
+
+		devices, err := GetUserKnownDevices(userID)
+		if assert.Nil(t, err) && assert.Len(t, devices, 3) {
+			assert.Equal(t, "laptop", devices[0].UserAgent)
+			assert.Equal(t, userID, devices[0].UserID)
+		}
+	})
+
+	t.Run("ForgetDevice", func(t *testing.T) {
+
+		assert.Nil(t, ForgetDevice(userID, "127.0.0.1", "phone"))
+
+		isNew, _ := RememberDevice(userID, "127.0.0.1", "phone")
+		assert.True(t, isNew)
+	})
+}
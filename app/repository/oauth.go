@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/encrypt"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/zebresel-com/mongodm"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const oauthTokenSize = 32
+
+func GetOAuthConsent(userID, clientID string) (*model.OAuthConsent, error) {
+
+	consentModel := database.Connection.Model(model.OAuthConsentCollection)
+	consent := &model.OAuthConsent{}
+
+	err := consentModel.FindOne(bson.M{"userId": userID, "clientId": clientID}).Exec(consent)
+	_, ok := err.(*mongodm.NotFoundError)
+	switch {
+	case ok:
+		return nil, errors.ErrOAuthConsentNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return consent, nil
+	}
+}
+
+func GetUserOAuthConsents(userID string) ([]*model.OAuthConsent, error) {
+
+	consentModel := database.Connection.Model(model.OAuthConsentCollection)
+	consents := []*model.OAuthConsent{}
+
+	err := consentModel.Find(bson.M{"userId": userID}).Sort("createdAt").Exec(&consents)
+	_, ok := err.(*mongodm.NotFoundError)
+	if err != nil && !ok {
+		return nil, errors.ErrInternal
+	}
+
+	return consents, nil
+}
+
+// SaveOAuthConsent adds scopes to the consent a user gave to a client.
+func SaveOAuthConsent(userID, clientID string, scopes []string) error {
+
+	consentModel := database.Connection.Model(model.OAuthConsentCollection)
+	now := time.Now()
+	update := bson.M{
+		"$addToSet": bson.M{
+			"scopes": bson.M{"$each": scopes},
+		},
+		"$set": bson.M{
+			"updatedAt": now,
+		},
+		"$setOnInsert": bson.M{
+			"createdAt": now,
+			"deleted":   false,
+		},
+	}
+
+	_, err := consentModel.Upsert(bson.M{"userId": userID, "clientId": clientID}, update)
+	if err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
+
+// RevokeOAuthConsent removes the consent and every token the client holds
+// for the user.
+func RevokeOAuthConsent(userID, clientID string) error {
+
+	consentModel := database.Connection.Model(model.OAuthConsentCollection)
+	filter := bson.M{"userId": userID, "clientId": clientID}
+
+	err := consentModel.Remove(filter)
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrOAuthConsentNotFound
+	case err != nil:
+		return errors.ErrInternal
+	}
+
+	for _, collection := range []string{model.OAuthCodeCollection, model.OAuthTokenCollection} {
+		if _, err = database.Connection.Model(collection).RemoveAll(filter); err != nil {
+			return errors.ErrInternal
+		}
+	}
+
+	return nil
+}
+
+// CreateOAuthCode keeps the nonce and the time the user signed in for the
+// ID token issued with the code.
+func CreateOAuthCode(clientID, userID, redirectURI string, scopes []string, challenge, method, nonce string, authTime time.Time) (string, error) {
+
+	codeModel := database.Connection.Model(model.OAuthCodeCollection)
+	code := &model.OAuthCode{}
+	codeModel.New(code)
+
+	value, err := encrypt.RandomToken(oauthTokenSize)
+	if err != nil {
+		return "", errors.ErrInternal
+	}
+
+	code.Code = encrypt.Digest(value)
+	code.ClientID = clientID
+	code.UserID = userID
+	code.RedirectURI = redirectURI
+	code.Scopes = scopes
+	code.CodeChallenge = challenge
+	code.CodeChallengeMethod = method
+	code.Nonce = nonce
+	code.AuthTime = authTime
+	code.ExpireAt = time.Now().Add(config.OAuthCodeLifetime)
+
+	err = code.Save()
+	if err != nil {
+		return "", errors.ErrInternal
+	}
+
+	return value, nil
+}
+
+// UseOAuthCode removes the code while reading it, so a code can be
+// exchanged only once even by concurrent requests.
+func UseOAuthCode(value, clientID string) (*model.OAuthCode, error) {
+
+	codeModel := database.Connection.Model(model.OAuthCodeCollection)
+	code := &model.OAuthCode{}
+	filter := bson.M{
+		"code":     encrypt.Digest(value),
+		"clientId": clientID,
+		"expireAt": bson.M{"$gt": time.Now()},
+	}
+
+	_, err := codeModel.Collection.Find(filter).Apply(mgo.Change{Remove: true}, code)
+	switch {
+	case err == mgo.ErrNotFound:
+		return nil, errors.ErrOAuthGrantNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return code, nil
+	}
+}
+
+func createOAuthToken(kind, clientID, userID string, scopes []string, lifetime time.Duration) (string, error) {
+
+	tokenModel := database.Connection.Model(model.OAuthTokenCollection)
+	token := &model.OAuthToken{}
+	tokenModel.New(token)
+
+	value, err := encrypt.RandomToken(oauthTokenSize)
+	if err != nil {
+		return "", errors.ErrInternal
+	}
+
+	token.Token = encrypt.Digest(value)
+	token.Type = kind
+	token.ClientID = clientID
+	token.UserID = userID
+	token.Scopes = scopes
+	token.ExpireAt = time.Now().Add(lifetime)
+
+	err = token.Save()
+	if err != nil {
+		return "", errors.ErrInternal
+	}
+
+	return value, nil
+}
+
+// CreateOAuthTokens issues an access token and, when refresh is set, a
+// refresh token for the same grant.
+func CreateOAuthTokens(clientID, userID string, scopes []string, refresh bool) (access, refreshToken string, err error) {
+
+	access, err = createOAuthToken(model.OAuthAccessToken, clientID, userID, scopes, config.OAuthAccessTokenLifetime)
+	if err != nil || !refresh {
+		return access, "", err
+	}
+
+	refreshToken, err = createOAuthToken(model.OAuthRefreshToken, clientID, userID, scopes, config.OAuthRefreshTokenLifetime)
+	if err != nil {
+		return "", "", err
+	}
+
+	return access, refreshToken, nil
+}
+
+// UseOAuthRefreshToken consumes a refresh token, the caller issues a new one
+// so every refresh token is used once.
+func UseOAuthRefreshToken(value, clientID string) (*model.OAuthToken, error) {
+
+	tokenModel := database.Connection.Model(model.OAuthTokenCollection)
+	token := &model.OAuthToken{}
+	filter := bson.M{
+		"token":    encrypt.Digest(value),
+		"type":     model.OAuthRefreshToken,
+		"clientId": clientID,
+		"expireAt": bson.M{"$gt": time.Now()},
+	}
+
+	_, err := tokenModel.Collection.Find(filter).Apply(mgo.Change{Remove: true}, token)
+	switch {
+	case err == mgo.ErrNotFound:
+		return nil, errors.ErrOAuthGrantNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return token, nil
+	}
+}
+
+func FindOAuthToken(value string) (*model.OAuthToken, error) {
+
+	tokenModel := database.Connection.Model(model.OAuthTokenCollection)
+	token := &model.OAuthToken{}
+
+	err := tokenModel.FindOne(bson.M{"token": encrypt.Digest(value), "expireAt": bson.M{"$gt": time.Now()}}).Exec(token)
+	_, ok := err.(*mongodm.NotFoundError)
+	switch {
+	case ok:
+		return nil, errors.ErrOAuthGrantNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return token, nil
+	}
+}
+
+func RevokeOAuthToken(value, clientID string) error {
+
+	tokenModel := database.Connection.Model(model.OAuthTokenCollection)
+	err := tokenModel.Remove(bson.M{"token": encrypt.Digest(value), "clientId": clientID})
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrOAuthGrantNotFound
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"crypto/subtle"
+
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/encrypt"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/paginate"
+	"github.com/zebresel-com/mongodm"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	oauthClientIDLength = 24
+	oauthSecretSize     = 32
+)
+
+// CreateOAuthClient registers a client, the secret is only returned here and
+// is empty for public clients.
+func CreateOAuthClient(name string, public bool, redirectURIs, grantTypes, scopes []string) (*model.OAuthClient, string, error) {
+
+	clientModel := database.Connection.Model(model.OAuthClientCollection)
+	client := &model.OAuthClient{}
+	clientModel.New(client)
+
+	clientID, err := encrypt.RandomCode(oauthClientIDLength)
+	if err != nil {
+		return nil, "", errors.ErrInternal
+	}
+
+	secret := ""
+	if !public {
+		secret, err = encrypt.RandomToken(oauthSecretSize)
+		if err != nil {
+			return nil, "", errors.ErrInternal
+		}
+		client.Secret = encrypt.Digest(secret)
+	}
+
+	client.Name = name
+	client.ClientID = clientID
+	client.Public = public
+	client.RedirectURIs = redirectURIs
+	client.GrantTypes = grantTypes
+	client.Scopes = scopes
+	client.IsActive = true
+
+	err = client.Save()
+	if err != nil {
+		return nil, "", errors.ErrInternal
+	}
+
+	return client, secret, nil
+}
+
+func GetOAuthClient(clientID string) (*model.OAuthClient, error) {
+
+	clientModel := database.Connection.Model(model.OAuthClientCollection)
+	client := &model.OAuthClient{}
+
+	err := clientModel.FindOne(bson.M{"clientId": clientID, "isActive": true}).Exec(client)
+	_, ok := err.(*mongodm.NotFoundError)
+	switch {
+	case ok:
+		return nil, errors.ErrOAuthClientNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return client, nil
+	}
+}
+
+func GetOAuthClientByID(ID string) (*model.OAuthClient, error) {
+
+	clientModel := database.Connection.Model(model.OAuthClientCollection)
+	client := &model.OAuthClient{}
+
+	err := clientModel.FindId(bson.ObjectIdHex(ID)).Exec(client)
+	_, ok := err.(*mongodm.NotFoundError)
+	switch {
+	case ok:
+		return nil, errors.ErrOAuthClientNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return client, nil
+	}
+}
+
+// AuthenticateOAuthClient checks the secret of a confidential client, public
+// clients have no secret and can not authenticate.
+func AuthenticateOAuthClient(clientID, secret string) (*model.OAuthClient, error) {
+
+	client, err := GetOAuthClient(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	if client.Public || subtle.ConstantTimeCompare([]byte(encrypt.Digest(secret)), []byte(client.Secret)) != 1 {
+		return nil, errors.ErrInvalidCredentials
+	}
+
+	return client, nil
+}
+
+func GetOAuthClients(page, limit int) (*paginate.Paginate, error) {
+
+	clientModel := database.Connection.Model(model.OAuthClientCollection)
+	clients := []*model.OAuthClient{}
+	result := clientModel.Find(nil).
+		Sort("createdAt").
+		Skip((page - 1) * limit).
+		Limit(limit)
+
+	count, err := result.Count()
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	err = result.Exec(&clients)
+	_, ok := err.(*mongodm.NotFoundError)
+	switch {
+	case ok || count == 0:
+		return nil, errors.ErrOAuthClientNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	}
+
+	pagination := paginate.Generate(clients, count, page, limit)
+	return pagination, nil
+}
+
+func ChangeOAuthClientStatus(ID string, status bool) error {
+
+	clientModel := database.Connection.Model(model.OAuthClientCollection)
+	update := bson.M{
+		"$set": bson.M{
+			"isActive": status,
+		},
+	}
+
+	err := clientModel.UpdateId(bson.ObjectIdHex(ID), update)
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrOAuthClientNotFound
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
+
+// RemoveOAuthClient deletes a client together with every grant it holds.
+func RemoveOAuthClient(ID string) error {
+
+	client, err := GetOAuthClientByID(ID)
+	if err != nil {
+		return err
+	}
+
+	clientModel := database.Connection.Model(model.OAuthClientCollection)
+	if err = clientModel.RemoveId(client.Id); err != nil {
+		return errors.ErrInternal
+	}
+
+	filter := bson.M{"clientId": client.ClientID}
+	for _, collection := range []string{model.OAuthConsentCollection, model.OAuthCodeCollection, model.OAuthTokenCollection} {
+		if _, err = database.Connection.Model(collection).RemoveAll(filter); err != nil {
+			return errors.ErrInternal
+		}
+	}
+
+	return nil
+}
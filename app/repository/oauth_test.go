@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestOAuth(t *testing.T) {
+
+	userBeforeTest()
+	defer userAfterTest()
+
+	collections := []string{model.OAuthClientCollection, model.OAuthConsentCollection, model.OAuthCodeCollection, model.OAuthTokenCollection}
+	defer func() {
+		for _, collection := range collections {
+			database.Connection.Model(collection).RemoveAll(nil)
+		}
+	}()
+
+	userID := bson.NewObjectId().Hex()
+	client, secret, err := CreateOAuthClient("App", false, []string{"https://app.example/callback"}, []string{"authorization_code"}, []string{"profile", "email"})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	t.Run("AuthenticateOAuthClient", func(t *testing.T) {
+
+		t.Run("Success", func(t *testing.T) {
+			_, err := AuthenticateOAuthClient(client.ClientID, secret)
+			assert.Nil(t, err)
+		})
+
+		t.Run("WrongSecret", func(t *testing.T) {
+			_, err := AuthenticateOAuthClient(client.ClientID, "wrong")
+			assert.Equal(t, errors.ErrInvalidCredentials, err)
+		})
+
+		t.Run("Inactive", func(t *testing.T) {
+			assert.Nil(t, ChangeOAuthClientStatus(client.Id.Hex(), false))
+			_, err := AuthenticateOAuthClient(client.ClientID, secret)
+			assert.Equal(t, errors.ErrOAuthClientNotFound, err)
+			assert.Nil(t, ChangeOAuthClientStatus(client.Id.Hex(), true))
+		})
+	})
+
+	t.Run("OAuthConsent", func(t *testing.T) {
+
+		t.Run("NotFound", func(t *testing.T) {
+			_, err := GetOAuthConsent(userID, client.ClientID)
+			assert.Equal(t, errors.ErrOAuthConsentNotFound, err)
+		})
+
+		t.Run("Save", func(t *testing.T) {
+			assert.Nil(t, SaveOAuthConsent(userID, client.ClientID, []string{"profile"}))
+			assert.Nil(t, SaveOAuthConsent(userID, client.ClientID, []string{"profile", "email"}))
+
+			consent, err := GetOAuthConsent(userID, client.ClientID)
+			if assert.Nil(t, err) {
+				assert.ElementsMatch(t, []string{"profile", "email"}, consent.Scopes)
+			}
+		})
+	})
+
+	t.Run("UseOAuthCode", func(t *testing.T) {
+
+		code, err := CreateOAuthCode(client.ClientID, userID, "https://app.example/callback", []string{"profile"}, "challenge", "S256", "nonce", time.Now())
+		assert.Nil(t, err)
+
+		t.Run("WrongClient", func(t *testing.T) {
+			_, err := UseOAuthCode(code, "other")
+			assert.Equal(t, errors.ErrOAuthGrantNotFound, err)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			used, err := UseOAuthCode(code, client.ClientID)
+			if assert.Nil(t, err) {
+				assert.Equal(t, userID, used.UserID)
+				assert.Equal(t, "challenge", used.CodeChallenge)
+				assert.Equal(t, "nonce", used.Nonce)
+			}
+		})
+
+		t.Run("AlreadyUsed", func(t *testing.T) {
+			_, err := UseOAuthCode(code, client.ClientID)
+			assert.Equal(t, errors.ErrOAuthGrantNotFound, err)
+		})
+	})
+
+	t.Run("OAuthTokens", func(t *testing.T) {
+
+		access, refresh, err := CreateOAuthTokens(client.ClientID, userID, []string{"profile"}, true)
+		assert.Nil(t, err)
+
+		t.Run("FindOAuthToken", func(t *testing.T) {
+			token, err := FindOAuthToken(access)
+			if assert.Nil(t, err) {
+				assert.Equal(t, model.OAuthAccessToken, token.Type)
+			}
+		})
+
+		t.Run("UseOAuthRefreshToken", func(t *testing.T) {
+			_, err := UseOAuthRefreshToken(access, client.ClientID)
+			assert.Equal(t, errors.ErrOAuthGrantNotFound, err)
+
+			_, err = UseOAuthRefreshToken(refresh, client.ClientID)
+			assert.Nil(t, err)
+
+			_, err = UseOAuthRefreshToken(refresh, client.ClientID)
+			assert.Equal(t, errors.ErrOAuthGrantNotFound, err)
+		})
+	})
+
+	t.Run("RevokeOAuthConsent", func(t *testing.T) {
+
+		access, _, _ := CreateOAuthTokens(client.ClientID, userID, []string{"profile"}, false)
+		assert.Nil(t, RevokeOAuthConsent(userID, client.ClientID))
+
+		_, err := FindOAuthToken(access)
+		assert.Equal(t, errors.ErrOAuthGrantNotFound, err)
+		assert.Equal(t, errors.ErrOAuthConsentNotFound, RevokeOAuthConsent(userID, client.ClientID))
+	})
+
+	t.Run("RemoveOAuthClient", func(t *testing.T) {
+		assert.Nil(t, RemoveOAuthClient(client.Id.Hex()))
+		assert.Equal(t, errors.ErrOAuthClientNotFound, RemoveOAuthClient(client.Id.Hex()))
+	})
+}
@@ -0,0 +1,145 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/paginate"
+	"github.com/thedevsir/frame-backend/services/webauthn"
+	"github.com/zebresel-com/mongodm"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func CreatePasskey(userID, name string, credential *webauthn.Credential) (*model.Passkey, error) {
+
+	passkeyModel := database.Connection.Model(model.PasskeyCollection)
+
+	count, err := passkeyModel.Find(bson.M{"credentialId": credential.ID}).Count()
+	switch {
+	case err != nil:
+		return nil, errors.ErrInternal
+	case count != 0:
+		return nil, errors.ErrPasskeyExists
+	}
+
+	passkey := &model.Passkey{}
+	passkeyModel.New(passkey)
+
+	passkey.UserID = userID
+	passkey.Name = name
+	passkey.CredentialID = credential.ID
+	passkey.PublicKey = credential.PublicKey
+	passkey.Algorithm = credential.Algorithm
+	passkey.SignCount = int64(credential.SignCount)
+
+	err = passkey.Save()
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return passkey, nil
+}
+
+func FindPasskey(credentialID string) (*model.Passkey, error) {
+
+	passkeyModel := database.Connection.Model(model.PasskeyCollection)
+	passkey := &model.Passkey{}
+
+	err := passkeyModel.FindOne(bson.M{"credentialId": credentialID}).Exec(passkey)
+	_, ok := err.(*mongodm.NotFoundError)
+	switch {
+	case ok:
+		return nil, errors.ErrPasskeyNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return passkey, nil
+	}
+}
+
+func GetPasskeyCredentialIDs(userID string) ([]string, error) {
+
+	passkeyModel := database.Connection.Model(model.PasskeyCollection)
+	passkeys := []*model.Passkey{}
+
+	err := passkeyModel.Find(bson.M{"userId": userID}).Select(bson.M{"credentialId": 1}).Exec(&passkeys)
+	_, ok := err.(*mongodm.NotFoundError)
+	if err != nil && !ok {
+		return nil, errors.ErrInternal
+	}
+
+	IDs := make([]string, 0, len(passkeys))
+	for _, passkey := range passkeys {
+		IDs = append(IDs, passkey.CredentialID)
+	}
+
+	return IDs, nil
+}
+
+func GetUserPasskeys(userID string, page, limit int) (*paginate.Paginate, error) {
+
+	passkeyModel := database.Connection.Model(model.PasskeyCollection)
+	passkeys := []*model.Passkey{}
+	result := passkeyModel.Find(bson.M{"userId": userID}).
+		Sort("createdAt").
+		Skip((page - 1) * limit).
+		Limit(limit)
+
+	count, err := result.Count()
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	err = result.Exec(&passkeys)
+	_, ok := err.(*mongodm.NotFoundError)
+	switch {
+	case ok || count == 0:
+		return nil, errors.ErrPasskeyNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	}
+
+	pagination := paginate.Generate(passkeys, count, page, limit)
+	return pagination, nil
+}
+
+// UsePasskey stores the new signature counter, a challenge is accepted only
+// once per passkey so a captured assertion can not be replayed.
+func UsePasskey(ID, challenge string, signCount uint32) error {
+
+	passkeyModel := database.Connection.Model(model.PasskeyCollection)
+	update := bson.M{
+		"$set": bson.M{
+			"signCount":     int64(signCount),
+			"lastChallenge": challenge,
+			"lastUsedAt":    time.Now(),
+		},
+	}
+
+	err := passkeyModel.Update(bson.M{"_id": bson.ObjectIdHex(ID), "lastChallenge": bson.M{"$ne": challenge}}, update)
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrPasskeyInvalid
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
+
+func RemovePasskey(userID, ID string) error {
+
+	passkeyModel := database.Connection.Model(model.PasskeyCollection)
+	err := passkeyModel.Remove(bson.M{"_id": bson.ObjectIdHex(ID), "userId": userID})
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrPasskeyNotFound
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/webauthn"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestPasskey(t *testing.T) {
+
+	userBeforeTest()
+	defer userAfterTest()
+
+	passkeyCollection := database.Connection.Model(model.PasskeyCollection)
+	passkeyCollection.RemoveAll(nil)
+	defer passkeyCollection.RemoveAll(nil)
+
+	user, _ := CreateUser("Irani", "12345678", "freshmanlimited@gmail.com")
+	userID := user.Id.Hex()
+
+	credential := &webauthn.Credential{
+		ID:        "Y3JlZGVudGlhbA",
+		PublicKey: []byte{0xa0},
+		Algorithm: webauthn.AlgES256,
+	}
+
+	var passkey *model.Passkey
+
+	t.Run("CreatePasskey", func(t *testing.T) {
+
+		t.Run("Success", func(t *testing.T) {
+			var err error
+			passkey, err = CreatePasskey(userID, "Laptop", credential)
+			assert.Nil(t, err)
+		})
+
+		t.Run("AlreadyRegistered", func(t *testing.T) {
+			_, err := CreatePasskey(userID, "Laptop", credential)
+			assert.Equal(t, errors.ErrPasskeyExists, err)
+		})
+	})
+
+	t.Run("FindPasskey", func(t *testing.T) {
+
+		t.Run("NotFound", func(t *testing.T) {
+			_, err := FindPasskey("unknown")
+			assert.Equal(t, errors.ErrPasskeyNotFound, err)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			found, err := FindPasskey(credential.ID)
+			if assert.Nil(t, err) {
+				assert.Equal(t, userID, found.UserID)
+				assert.Equal(t, credential.PublicKey, found.PublicKey)
+			}
+		})
+	})
+
+	t.Run("GetPasskeyCredentialIDs", func(t *testing.T) {
+		IDs, err := GetPasskeyCredentialIDs(userID)
+		assert.Nil(t, err)
+		assert.Equal(t, []string{credential.ID}, IDs)
+	})
+
+	t.Run("GetUserPasskeys", func(t *testing.T) {
+
+		t.Run("Success", func(t *testing.T) {
+			_, err := GetUserPasskeys(userID, 1, 10)
+			assert.Nil(t, err)
+		})
+
+		t.Run("NotFound", func(t *testing.T) {
+			_, err := GetUserPasskeys(bson.NewObjectId().Hex(), 1, 10)
+			assert.Equal(t, errors.ErrPasskeyNotFound, err)
+		})
+	})
+
+	t.Run("UsePasskey", func(t *testing.T) {
+
+		t.Run("Success", func(t *testing.T) {
+			assert.Nil(t, UsePasskey(passkey.Id.Hex(), "challenge", 1))
+		})
+
+		t.Run("Replayed", func(t *testing.T) {
+			assert.Equal(t, errors.ErrPasskeyInvalid, UsePasskey(passkey.Id.Hex(), "challenge", 2))
+		})
+	})
+
+	t.Run("RemovePasskey", func(t *testing.T) {
+
+		t.Run("OtherUser", func(t *testing.T) {
+			assert.Equal(t, errors.ErrPasskeyNotFound, RemovePasskey(bson.NewObjectId().Hex(), passkey.Id.Hex()))
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			assert.Nil(t, RemovePasskey(userID, passkey.Id.Hex()))
+		})
+	})
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/encrypt"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/paginate"
+	"github.com/zebresel-com/mongodm"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const personalTokenSize = 32
+
+// CreatePersonalToken returns the token value, only its digest is stored so
+// it is shown once. A zero lifetime never expires.
+func CreatePersonalToken(userID, name string, scopes []string, lifetime time.Duration) (*model.PersonalToken, string, error) {
+
+	tokenModel := database.Connection.Model(model.PersonalTokenCollection)
+	token := &model.PersonalToken{}
+	tokenModel.New(token)
+
+	value, err := encrypt.RandomToken(personalTokenSize)
+	if err != nil {
+		return nil, "", errors.ErrInternal
+	}
+	value = model.PersonalTokenPrefix + value
+
+	token.UserID = userID
+	token.Name = name
+	token.Token = encrypt.Digest(value)
+	token.Scopes = scopes
+	if lifetime > 0 {
+		expireAt := time.Now().Add(lifetime)
+		token.ExpireAt = &expireAt
+	}
+
+	if err = token.Save(); err != nil {
+		return nil, "", errors.ErrInternal
+	}
+
+	return token, value, nil
+}
+
+// UsePersonalToken finds a token that has not expired and records where it
+// was used from.
+func UsePersonalToken(value, IP string) (*model.PersonalToken, error) {
+
+	tokenModel := database.Connection.Model(model.PersonalTokenCollection)
+	token := &model.PersonalToken{}
+	filter := bson.M{
+		"token": encrypt.Digest(value),
+		"$or": []bson.M{
+			{"expireAt": nil},
+			{"expireAt": bson.M{"$gt": time.Now()}},
+		},
+	}
+	change := mgo.Change{
+		Update:    bson.M{"$set": bson.M{"lastUsedAt": time.Now(), "lastUsedIp": IP}},
+		ReturnNew: true,
+	}
+
+	_, err := tokenModel.Collection.Find(filter).Apply(change, token)
+	switch {
+	case err == mgo.ErrNotFound:
+		return nil, errors.ErrPersonalTokenInvalid
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return token, nil
+	}
+}
+
+func GetUserPersonalTokens(userID string, page, limit int) (*paginate.Paginate, error) {
+
+	tokenModel := database.Connection.Model(model.PersonalTokenCollection)
+	tokens := []*model.PersonalToken{}
+	result := tokenModel.Find(bson.M{"userId": userID}).
+		Sort("createdAt").
+		Skip((page - 1) * limit).
+		Limit(limit)
+
+	count, err := result.Count()
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	err = result.Exec(&tokens)
+	_, ok := err.(*mongodm.NotFoundError)
+	switch {
+	case ok || count == 0:
+		return nil, errors.ErrPersonalTokenNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	}
+
+	pagination := paginate.Generate(tokens, count, page, limit)
+	return pagination, nil
+}
+
+// RevokeUserPersonalTokens revokes every token of the user, the takeovers
+// that sign out every session must not leave a token behind.
+func RevokeUserPersonalTokens(userID string) error {
+
+	tokenModel := database.Connection.Model(model.PersonalTokenCollection)
+	if _, err := tokenModel.RemoveAll(bson.M{"userId": userID}); err != nil {
+		return errors.ErrInternal
+	}
+
+	return nil
+}
+
+func RevokePersonalToken(userID, ID string) error {
+
+	tokenModel := database.Connection.Model(model.PersonalTokenCollection)
+	err := tokenModel.Remove(bson.M{"_id": bson.ObjectIdHex(ID), "userId": userID})
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrPersonalTokenNotFound
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestPersonalToken(t *testing.T) {
+
+	userBeforeTest()
+	defer userAfterTest()
+
+	tokenCollection := database.Connection.Model(model.PersonalTokenCollection)
+	tokenCollection.RemoveAll(nil)
+	defer tokenCollection.RemoveAll(nil)
+
+	userID := bson.NewObjectId().Hex()
+	scopes := []string{model.ScopeAccountRead}
+
+	token, value, err := CreatePersonalToken(userID, "ci", scopes, 0)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	t.Run("UsePersonalToken", func(t *testing.T) {
+
+		t.Run("Success", func(t *testing.T) {
+			used, err := UsePersonalToken(value, "127.0.0.1")
+			if assert.Nil(t, err) {
+				assert.Equal(t, token.Id, used.Id)
+				assert.Nil(t, used.ExpireAt)
+				assert.NotNil(t, used.LastUsedAt)
+				assert.Equal(t, "127.0.0.1", used.LastUsedIP)
+			}
+		})
+
+		t.Run("Expired", func(t *testing.T) {
+			_, expired, _ := CreatePersonalToken(userID, "old", scopes, -time.Minute)
+			_, err := UsePersonalToken(expired, "127.0.0.1")
+			assert.Equal(t, errors.ErrPersonalTokenInvalid, err)
+		})
+	})
+
+	t.Run("GetUserPersonalTokens", func(t *testing.T) {
+
+		tokens, err := GetUserPersonalTokens(userID, 1, 10)
+		if assert.Nil(t, err) {
+			assert.Equal(t, 2, tokens.Items.Total)
+		}
+	})
+
+	t.Run("RevokePersonalToken", func(t *testing.T) {
+
+		assert.Equal(t, errors.ErrPersonalTokenNotFound, RevokePersonalToken(bson.NewObjectId().Hex(), token.Id.Hex()))
+		assert.Nil(t, RevokePersonalToken(userID, token.Id.Hex()))
+
+		_, err := UsePersonalToken(value, "127.0.0.1")
+		assert.Equal(t, errors.ErrPersonalTokenInvalid, err)
+	})
+
+	t.Run("RevokeUserPersonalTokens", func(t *testing.T) {
+
+		_, other, _ := CreatePersonalToken(userID, "other", scopes, 0)
+		assert.Nil(t, RevokeUserPersonalTokens(userID))
+
+		_, err := UsePersonalToken(other, "127.0.0.1")
+		assert.Equal(t, errors.ErrPersonalTokenInvalid, err)
+	})
+}
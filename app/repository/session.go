@@ -6,40 +6,189 @@ import (
 	"github.com/satori/go.uuid"
 	"github.com/zebresel-com/mongodm"
 	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config"
 	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/auth"
 	"github.com/thedevsir/frame-backend/services/encrypt"
 	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/geoip"
 	"github.com/thedevsir/frame-backend/services/paginate"
+	"github.com/thedevsir/frame-backend/services/useragent"
 	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
-func SessionCreate(IP, userID, userAgent string) (sid, key string, err error) {
+func sessionKey() (key, hash string, err error) {
+
+	// uuid use panic
+	key = uuid.Must(uuid.NewV4(), nil).String() // nil
+	hash, err = encrypt.Hash(key)
+	if err != nil {
+		return "", "", errors.ErrInternal
+	}
+
+	return key, hash, nil
+}
+
+// describeSession reads the device from the user agent of the session and
+// locates its address.
+func describeSession(session *model.Session) {
+
+	agent := useragent.Parse(session.UserAgent)
+	session.Device = model.SessionDevice{
+		Browser: agent.Browser,
+		OS:      agent.OS,
+		Type:    agent.Device,
+	}
+
+	location := geoip.Lookup(session.IP)
+	session.Location = model.SessionLocation{
+		Country:     location.Country,
+		CountryCode: location.CountryCode,
+		City:        location.City,
+	}
+}
+
+// sessionPolicy is how long a session lives at most and how long it
+// survives without activity, remembered sessions get the longer pair.
+func sessionPolicy(remember bool) (lifetime, idle time.Duration) {
+
+	if remember {
+		return config.SessionRememberLifetime, config.SessionRememberIdleTimeout
+	}
+	return config.SessionLifetime, config.SessionIdleTimeout
+}
+
+// sessionExpiry is when a session active at now expires, the idle timeout
+// slides it forward but never past its absolute expiry.
+func sessionExpiry(session *model.Session, now time.Time) time.Time {
+
+	_, idle := sessionPolicy(session.Remember)
+
+	// Sessions opened before idle timeouts expire where they used to
+	limit := session.AbsoluteExpireAt
+	if limit.IsZero() {
+		limit = session.ExpireAt
+	}
+
+	expireAt := now.Add(idle)
+	if expireAt.After(limit) {
+		return limit
+	}
+	return expireAt
+}
+
+// SessionCreate opens a session and issues its first refresh token. The
+// session expires once idle for too long or at its absolute expiry, access
+// tokens only carry its key.
+func SessionCreate(IP, userID, userAgent string, remember bool) (sid, key, refreshToken string, err error) {
 
 	sessionModel := database.Connection.Model(model.SessionCollection)
 	session := &model.Session{}
 	sessionModel.New(session)
 
-	// uuid use panic
-	uuid := uuid.Must(uuid.NewV4(), nil).String() // nil
-	hash, err := encrypt.Hash(uuid)
+	key, hash, err := sessionKey()
 	if err != nil {
-		return "", "", errors.ErrInternal
+		return "", "", "", err
+	}
+
+	seed, err := encrypt.RandomToken(32)
+	if err != nil {
+		return "", "", "", errors.ErrInternal
+	}
+
+	sealed, err := encrypt.Seal(seed, []byte(config.EncryptionKey))
+	if err != nil {
+		return "", "", "", errors.ErrInternal
 	}
 
 	session.IP = IP
 	session.Key = hash
 	session.UserID = userID
 	session.UserAgent = userAgent
-	session.LastActivity = time.Now()
-	session.ExpireAt = time.Now().Add(time.Hour * 24)
+	describeSession(session)
+
+	now := time.Now()
+	lifetime, _ := sessionPolicy(remember)
+	session.Remember = remember
+	session.LastActivity = now
+	session.AbsoluteExpireAt = now.Add(lifetime)
+	session.ExpireAt = sessionExpiry(session, now)
+	session.RefreshSeed = sealed
 
 	err = session.Save()
 	if err != nil {
-		return "", "", errors.ErrInternal
+		return "", "", "", errors.ErrInternal
+	}
+
+	sid = session.Id.Hex()
+	return sid, key, auth.NewRefreshToken(seed, sid, 0), nil
+}
+
+// SessionRefresh rotates the refresh token and the key of a session. A
+// genuine token of an older generation means it leaked, so the whole session
+// is revoked.
+func SessionRefresh(refreshToken string) (session *model.Session, key, rotated string, err error) {
+
+	SID, generation, err := auth.ParseRefreshToken(refreshToken)
+	if err != nil || !bson.IsObjectIdHex(SID) {
+		return nil, "", "", errors.ErrRefreshTokenInvalid
+	}
+
+	session, err = SessionFindByID(SID)
+	switch {
+	case err == errors.ErrSessionNotFound:
+		return nil, "", "", errors.ErrRefreshTokenInvalid
+	case err != nil:
+		return nil, "", "", err
+	}
+
+	seed, err := encrypt.Open(session.RefreshSeed, []byte(config.EncryptionKey))
+	if err != nil || !auth.CheckRefreshToken(refreshToken, seed) || time.Now().After(session.ExpireAt) {
+		return nil, "", "", errors.ErrRefreshTokenInvalid
+	}
+
+	if generation != session.RefreshGeneration {
+		return nil, "", "", revokeReusedSession(SID)
+	}
+
+	key, hash, err := sessionKey()
+	if err != nil {
+		return nil, "", "", err
 	}
 
-	return session.Id.Hex(), uuid, nil
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"key":          hash,
+			"lastActivity": now,
+			"expireAt":     sessionExpiry(session, now),
+		},
+		"$inc": bson.M{
+			"refreshGeneration": 1,
+		},
+	}
+
+	// Of two requests racing with the same token only one rotates it
+	sessionModel := database.Connection.Model(model.SessionCollection)
+	err = sessionModel.Update(bson.M{"_id": session.Id, "refreshGeneration": generation}, update)
+	switch {
+	case err == mgo.ErrNotFound:
+		return nil, "", "", revokeReusedSession(SID)
+	case err != nil:
+		return nil, "", "", errors.ErrInternal
+	}
+
+	return session, key, auth.NewRefreshToken(seed, SID, generation+1), nil
+}
+
+func revokeReusedSession(SID string) error {
+
+	if err := TerminateSession(SID); err != nil && err != errors.ErrSessionNotFound {
+		return err
+	}
+
+	return errors.ErrRefreshTokenReused
 }
 
 func SessionFindByCredentials(Session, SID string) error {
@@ -54,6 +203,11 @@ func SessionFindByCredentials(Session, SID string) error {
 		return errors.ErrInvalidCredentials
 	}
 
+	// The TTL monitor only sweeps once a minute
+	if time.Now().After(session.ExpireAt) {
+		return errors.ErrSessionExpired
+	}
+
 	return nil
 }
 
@@ -74,16 +228,28 @@ func SessionFindByID(SID string) (*model.Session, error) {
 	}
 }
 
+// SessionUpdateLastActivity records activity on a session and slides its
+// expiry by the idle timeout.
 func SessionUpdateLastActivity(SID string) error {
 
+	session, err := SessionFindByID(SID)
+	switch {
+	case err == errors.ErrSessionNotFound:
+		return errors.ErrInvalidCredentials
+	case err != nil:
+		return err
+	}
+
+	now := time.Now()
 	sessionModel := database.Connection.Model(model.SessionCollection)
 	update := bson.M{
 		"$set": bson.M{
-			"lastActivity": time.Now(),
+			"lastActivity": now,
+			"expireAt":     sessionExpiry(session, now),
 		},
 	}
 
-	err := sessionModel.UpdateId(bson.ObjectIdHex(SID), update)
+	err = sessionModel.UpdateId(session.Id, update)
 	switch {
 	case err == mgo.ErrNotFound:
 		return errors.ErrInvalidCredentials
@@ -118,10 +284,35 @@ func GetUserSessions(userID string, page, limit int) (*paginate.Paginate, error)
 		return nil, errors.ErrInternal
 	}
 
+	// sessions opened before devices were read are described now
+	for _, session := range sessions {
+		if session.Device == (model.SessionDevice{}) {
+			describeSession(session)
+		}
+	}
+
 	pagination := paginate.Generate(sessions, count, page, limit)
 	return pagination, nil
 }
 
+// LabelSession names a session of the user, an empty label removes the name.
+func LabelSession(userID, SID, label string) error {
+
+	sessionModel := database.Connection.Model(model.SessionCollection)
+	err := sessionModel.Update(
+		bson.M{"_id": bson.ObjectIdHex(SID), "userId": userID},
+		bson.M{"$set": bson.M{"label": label}},
+	)
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrSessionNotFound
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
+
 func TerminateSession(ID string) error {
 
 	sessionModel := database.Connection.Model(model.SessionCollection)
@@ -147,3 +338,16 @@ func TerminateAllSessions(userID string) error {
 		return nil
 	}
 }
+
+// TerminateOtherSessions signs the user out everywhere but the session SID.
+func TerminateOtherSessions(userID, SID string) error {
+
+	sessionModel := database.Connection.Model(model.SessionCollection)
+	_, err := sessionModel.RemoveAll(bson.M{"userId": userID, "_id": bson.M{"$ne": bson.ObjectIdHex(SID)}})
+	switch {
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
@@ -2,10 +2,12 @@ package repository
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/zebresel-com/mongodm"
 	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config"
 	"github.com/thedevsir/frame-backend/config/database"
 	"github.com/thedevsir/frame-backend/services/errors"
 	"github.com/thedevsir/frame-backend/services/test"
@@ -40,7 +42,7 @@ func TestSession(t *testing.T) {
 
 	t.Run("CreateSession", func(t *testing.T) {
 		assert.NotPanics(t, func() {
-			SID, sess, err = SessionCreate(ip, userID, userAgent)
+			SID, sess, _, err = SessionCreate(ip, userID, userAgent, false)
 			assert.Nil(t, err)
 		})
 	})
@@ -81,6 +83,45 @@ func TestSession(t *testing.T) {
 		})
 	})
 
+	t.Run("Expiry", func(t *testing.T) {
+
+		t.Run("Slides", func(t *testing.T) {
+			sessionCollection.UpdateId(bson.ObjectIdHex(SID), bson.M{"$set": bson.M{"expireAt": time.Now().Add(time.Minute)}})
+			assert.Nil(t, SessionUpdateLastActivity(SID))
+
+			session, _ := SessionFindByID(SID)
+			assert.WithinDuration(t, time.Now().Add(config.SessionIdleTimeout), session.ExpireAt, time.Minute)
+			assert.WithinDuration(t, time.Now().Add(config.SessionLifetime), session.AbsoluteExpireAt, time.Minute)
+		})
+
+		t.Run("Absolute", func(t *testing.T) {
+			now := time.Now()
+			session := &model.Session{AbsoluteExpireAt: now.Add(time.Minute)}
+			assert.Equal(t, now.Add(time.Minute), sessionExpiry(session, now))
+
+			session = &model.Session{ExpireAt: now.Add(time.Minute)}
+			assert.Equal(t, now.Add(time.Minute), sessionExpiry(session, now))
+		})
+
+		t.Run("Remember", func(t *testing.T) {
+			other, _, _, _ := SessionCreate(ip, userID, userAgent, true)
+			defer TerminateSession(other)
+
+			session, _ := SessionFindByID(other)
+			assert.True(t, session.Remember)
+			assert.WithinDuration(t, time.Now().Add(config.SessionRememberIdleTimeout), session.ExpireAt, time.Minute)
+			assert.WithinDuration(t, time.Now().Add(config.SessionRememberLifetime), session.AbsoluteExpireAt, time.Minute)
+		})
+
+		t.Run("Idle", func(t *testing.T) {
+			other, key, _, _ := SessionCreate(ip, userID, userAgent, false)
+			defer TerminateSession(other)
+
+			sessionCollection.UpdateId(bson.ObjectIdHex(other), bson.M{"$set": bson.M{"expireAt": time.Now().Add(-time.Minute)}})
+			assert.Equal(t, errors.ErrSessionExpired, SessionFindByCredentials(key, other))
+		})
+	})
+
 	t.Run("GetUserSessions", func(t *testing.T) {
 
 		t.Run("Success", func(t *testing.T) {
@@ -94,6 +135,43 @@ func TestSession(t *testing.T) {
 		})
 	})
 
+	t.Run("Device", func(t *testing.T) {
+
+		chrome := "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36"
+		other, _, _, _ := SessionCreate(ip, userID, chrome, false)
+		defer TerminateSession(other)
+
+		session, err := SessionFindByID(other)
+		if assert.Nil(t, err) {
+			assert.Equal(t, model.SessionDevice{Browser: "Chrome 120", OS: "Android", Type: "mobile"}, session.Device)
+		}
+	})
+
+	t.Run("LabelSession", func(t *testing.T) {
+
+		t.Run("Success", func(t *testing.T) {
+			assert.Nil(t, LabelSession(userID, SID, "work laptop"))
+
+			session, _ := SessionFindByID(SID)
+			assert.Equal(t, "work laptop", session.Label)
+		})
+
+		t.Run("OtherUser", func(t *testing.T) {
+			assert.Equal(t, errors.ErrSessionNotFound, LabelSession(bson.NewObjectId().Hex(), SID, "mine"))
+		})
+	})
+
+	t.Run("TerminateOtherSessions", func(t *testing.T) {
+
+		other, _, _, _ := SessionCreate(ip, userID, userAgent, false)
+		assert.Nil(t, TerminateOtherSessions(userID, SID))
+
+		_, err := SessionFindByID(SID)
+		assert.Nil(t, err)
+		_, err = SessionFindByID(other)
+		assert.Equal(t, errors.ErrSessionNotFound, err)
+	})
+
 	t.Run("TerminateSession", func(t *testing.T) {
 
 		t.Run("Success", func(t *testing.T) {
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/social"
+	"github.com/zebresel-com/mongodm"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// CreateSocialIdentity links a provider account to a user, an account links
+// to one user and a user links one account per provider.
+func CreateSocialIdentity(userID, provider string, identity *social.Identity) (*model.SocialIdentity, error) {
+
+	identityModel := database.Connection.Model(model.SocialIdentityCollection)
+
+	count, err := identityModel.Find(bson.M{"provider": provider, "subject": identity.Subject}).Count()
+	switch {
+	case err != nil:
+		return nil, errors.ErrInternal
+	case count != 0:
+		return nil, errors.ErrSocialIdentityExists
+	}
+
+	count, err = identityModel.Find(bson.M{"userId": userID, "provider": provider}).Count()
+	switch {
+	case err != nil:
+		return nil, errors.ErrInternal
+	case count != 0:
+		return nil, errors.ErrSocialProviderLinked
+	}
+
+	now := time.Now()
+	linked := &model.SocialIdentity{}
+	identityModel.New(linked)
+
+	linked.UserID = userID
+	linked.Provider = provider
+	linked.Subject = identity.Subject
+	linked.Email = identity.Email
+	linked.Username = identity.Username
+	linked.LastUsedAt = &now
+
+	err = linked.Save()
+	if err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return linked, nil
+}
+
+func FindSocialIdentity(provider, subject string) (*model.SocialIdentity, error) {
+
+	identityModel := database.Connection.Model(model.SocialIdentityCollection)
+	linked := &model.SocialIdentity{}
+
+	err := identityModel.FindOne(bson.M{"provider": provider, "subject": subject}).Exec(linked)
+	_, ok := err.(*mongodm.NotFoundError)
+	switch {
+	case ok:
+		return nil, errors.ErrSocialIdentityNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return linked, nil
+	}
+}
+
+// UseSocialIdentity keeps the profile of the provider account up to date.
+func UseSocialIdentity(ID string, identity *social.Identity) error {
+
+	identityModel := database.Connection.Model(model.SocialIdentityCollection)
+	update := bson.M{
+		"$set": bson.M{
+			"email":      identity.Email,
+			"username":   identity.Username,
+			"lastUsedAt": time.Now(),
+		},
+	}
+
+	err := identityModel.Update(bson.M{"_id": bson.ObjectIdHex(ID)}, update)
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrSocialIdentityNotFound
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
+
+func GetUserSocialIdentities(userID string) ([]*model.SocialIdentity, error) {
+
+	identityModel := database.Connection.Model(model.SocialIdentityCollection)
+	identities := []*model.SocialIdentity{}
+
+	err := identityModel.Find(bson.M{"userId": userID}).Sort("createdAt").Exec(&identities)
+	_, ok := err.(*mongodm.NotFoundError)
+	if err != nil && !ok {
+		return nil, errors.ErrInternal
+	}
+
+	return identities, nil
+}
+
+func RemoveSocialIdentity(userID, provider string) error {
+
+	identityModel := database.Connection.Model(model.SocialIdentityCollection)
+	err := identityModel.Remove(bson.M{"userId": userID, "provider": provider})
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrSocialIdentityNotFound
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/social"
+)
+
+func TestSocialIdentity(t *testing.T) {
+
+	userBeforeTest()
+	defer userAfterTest()
+
+	identityCollection := database.Connection.Model(model.SocialIdentityCollection)
+	identityCollection.RemoveAll(nil)
+	defer identityCollection.RemoveAll(nil)
+
+	user, _ := CreateUser("Irani", "12345678", "freshmanlimited@gmail.com")
+	userID := user.Id.Hex()
+
+	identity := &social.Identity{Subject: "583231", Username: "octocat", Email: "freshmanlimited@gmail.com", EmailVerified: true}
+
+	var linked *model.SocialIdentity
+
+	t.Run("CreateSocialIdentity", func(t *testing.T) {
+
+		t.Run("Success", func(t *testing.T) {
+			var err error
+			linked, err = CreateSocialIdentity(userID, "github", identity)
+			assert.Nil(t, err)
+		})
+
+		t.Run("LinkedToUser", func(t *testing.T) {
+			_, err := CreateSocialIdentity("5b4a3c2d1e0f000000000000", "github", identity)
+			assert.Equal(t, errors.ErrSocialIdentityExists, err)
+		})
+
+		t.Run("ProviderLinked", func(t *testing.T) {
+			_, err := CreateSocialIdentity(userID, "github", &social.Identity{Subject: "1"})
+			assert.Equal(t, errors.ErrSocialProviderLinked, err)
+		})
+	})
+
+	t.Run("FindSocialIdentity", func(t *testing.T) {
+
+		t.Run("NotFound", func(t *testing.T) {
+			_, err := FindSocialIdentity("google", identity.Subject)
+			assert.Equal(t, errors.ErrSocialIdentityNotFound, err)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			found, err := FindSocialIdentity("github", identity.Subject)
+			if assert.Nil(t, err) {
+				assert.Equal(t, userID, found.UserID)
+			}
+		})
+	})
+
+	t.Run("UseSocialIdentity", func(t *testing.T) {
+
+		assert.Nil(t, UseSocialIdentity(linked.Id.Hex(), &social.Identity{Subject: identity.Subject, Username: "monalisa"}))
+
+		found, _ := FindSocialIdentity("github", identity.Subject)
+		assert.Equal(t, "monalisa", found.Username)
+	})
+
+	t.Run("GetUserSocialIdentities", func(t *testing.T) {
+
+		identities, err := GetUserSocialIdentities(userID)
+		if assert.Nil(t, err) {
+			assert.Len(t, identities, 1)
+		}
+	})
+
+	t.Run("RemoveSocialIdentity", func(t *testing.T) {
+
+		assert.Nil(t, RemoveSocialIdentity(userID, "github"))
+		assert.Equal(t, errors.ErrSocialIdentityNotFound, RemoveSocialIdentity(userID, "github"))
+	})
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"strings"
+	"time"
+
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/encrypt"
+	"github.com/thedevsir/frame-backend/services/errors"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func SetTwoFactorPending(userID, secret string) error {
+
+	userModel := database.Connection.Model(model.UserCollection)
+	update := bson.M{
+		"$set": bson.M{
+			"twoFactorPending": secret,
+		},
+	}
+
+	err := userModel.Update(bson.M{"_id": bson.ObjectIdHex(userID), "isActive": true, "twoFactor": bson.M{"$ne": true}}, update)
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrTwoFactorEnabled
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
+
+func EnableTwoFactor(userID, secret string, step int64) error {
+
+	userModel := database.Connection.Model(model.UserCollection)
+	update := bson.M{
+		"$set": bson.M{
+			"twoFactor":         true,
+			"twoFactorSecret":   secret,
+			"twoFactorLastStep": step,
+		},
+		"$unset": bson.M{
+			"twoFactorPending": "",
+		},
+	}
+
+	err := userModel.Update(bson.M{"_id": bson.ObjectIdHex(userID), "isActive": true, "twoFactorPending": secret}, update)
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrTwoFactorNotBegun
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
+
+func DisableTwoFactor(userID string) error {
+
+	userModel := database.Connection.Model(model.UserCollection)
+	update := bson.M{
+		"$set": bson.M{
+			"twoFactor": false,
+		},
+		"$unset": bson.M{
+			"twoFactorSecret":   "",
+			"twoFactorPending":  "",
+			"twoFactorLastStep": "",
+			"recoveryCodes":     "",
+		},
+	}
+
+	err := userModel.Update(bson.M{"_id": bson.ObjectIdHex(userID), "twoFactor": true}, update)
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrTwoFactorDisabled
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
+
+// UseTwoFactorStep stores the time step of an accepted code, a code from
+// the same or an earlier step can not be used again.
+func UseTwoFactorStep(userID string, step int64) error {
+
+	userModel := database.Connection.Model(model.UserCollection)
+	update := bson.M{
+		"$set": bson.M{
+			"twoFactorLastStep": step,
+		},
+	}
+
+	err := userModel.Update(bson.M{"_id": bson.ObjectIdHex(userID), "twoFactor": true, "twoFactorLastStep": bson.M{"$lt": step}}, update)
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrInvalidCode
+	case err != nil:
+		return errors.ErrInternal
+	default:
+		return nil
+	}
+}
+
+const (
+	RecoveryCodesCount  = 10
+	recoveryCodesLength = 10
+)
+
+func normalizeRecoveryCode(code string) string {
+
+	code = strings.ToLower(code)
+	code = strings.Replace(code, "-", "", -1)
+	return strings.Replace(code, " ", "", -1)
+}
+
+func IsRecoveryCode(code string) bool {
+	return len(normalizeRecoveryCode(code)) == recoveryCodesLength
+}
+
+// GenerateRecoveryCodes replaces every recovery code of the user and
+// returns the new ones, only their hashes are kept.
+func GenerateRecoveryCodes(userID string) ([]string, error) {
+
+	codes := make([]string, RecoveryCodesCount)
+	recoveryCodes := make([]model.RecoveryCode, RecoveryCodesCount)
+
+	for i := range codes {
+		code, err := encrypt.RandomCode(recoveryCodesLength)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		hash, err := encrypt.Hash(code)
+		if err != nil {
+			return nil, errors.ErrInternal
+		}
+
+		codes[i] = code[:recoveryCodesLength/2] + "-" + code[recoveryCodesLength/2:]
+		recoveryCodes[i] = model.RecoveryCode{Hash: hash}
+	}
+
+	userModel := database.Connection.Model(model.UserCollection)
+	update := bson.M{
+		"$set": bson.M{
+			"recoveryCodes": recoveryCodes,
+		},
+	}
+
+	err := userModel.Update(bson.M{"_id": bson.ObjectIdHex(userID), "twoFactor": true}, update)
+	switch {
+	case err == mgo.ErrNotFound:
+		return nil, errors.ErrTwoFactorDisabled
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return codes, nil
+	}
+}
+
+// UseRecoveryCode consumes the matching unused recovery code of the user
+// and records when and from where it was used.
+func UseRecoveryCode(user *model.User, code, IP string) error {
+
+	code = normalizeRecoveryCode(code)
+
+	for _, recoveryCode := range user.RecoveryCodes {
+
+		if recoveryCode.UsedAt != nil || !encrypt.CheckHash(code, recoveryCode.Hash) {
+			continue
+		}
+
+		userModel := database.Connection.Model(model.UserCollection)
+		findStruct := bson.M{
+			"_id": user.Id,
+			"recoveryCodes": bson.M{
+				"$elemMatch": bson.M{
+					"hash":   recoveryCode.Hash,
+					"usedAt": nil,
+				},
+			},
+		}
+		update := bson.M{
+			"$set": bson.M{
+				"recoveryCodes.$.usedAt": time.Now(),
+				"recoveryCodes.$.usedIp": IP,
+			},
+		}
+
+		err := userModel.Update(findStruct, update)
+		switch {
+		case err == mgo.ErrNotFound:
+			return errors.ErrInvalidCode
+		case err != nil:
+			return errors.ErrInternal
+		default:
+			return nil
+		}
+	}
+
+	return errors.ErrInvalidCode
+}
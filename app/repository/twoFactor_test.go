@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestTwoFactor(t *testing.T) {
+
+	userBeforeTest()
+	defer userAfterTest()
+
+	user, _ := CreateUser("Irani", "12345678", "freshmanlimited@gmail.com")
+	userID := user.Id.Hex()
+
+	t.Run("CheckUserPassword", func(t *testing.T) {
+
+		t.Run("UserNotFound", func(t *testing.T) {
+			_, err := CheckUserPassword(bson.NewObjectId().Hex(), "12345678")
+			assert.Equal(t, errors.ErrUserNotFound, err)
+		})
+
+		t.Run("PasswordHasNotMatch", func(t *testing.T) {
+			_, err := CheckUserPassword(userID, "123")
+			assert.Equal(t, errors.ErrInvalidCredentials, err)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			_, err := CheckUserPassword(userID, "12345678")
+			assert.Nil(t, err)
+		})
+	})
+
+	t.Run("EnableTwoFactor", func(t *testing.T) {
+
+		t.Run("NotBegun", func(t *testing.T) {
+			assert.Equal(t, errors.ErrTwoFactorNotBegun, EnableTwoFactor(userID, "sealed", 10))
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			assert.Nil(t, SetTwoFactorPending(userID, "sealed"))
+			assert.Nil(t, EnableTwoFactor(userID, "sealed", 10))
+		})
+
+		t.Run("AlreadyEnabled", func(t *testing.T) {
+			assert.Equal(t, errors.ErrTwoFactorEnabled, SetTwoFactorPending(userID, "sealed"))
+		})
+	})
+
+	t.Run("UseTwoFactorStep", func(t *testing.T) {
+
+		t.Run("Success", func(t *testing.T) {
+			assert.Nil(t, UseTwoFactorStep(userID, 11))
+		})
+
+		t.Run("Replayed", func(t *testing.T) {
+			assert.Equal(t, errors.ErrInvalidCode, UseTwoFactorStep(userID, 11))
+		})
+	})
+
+	t.Run("RecoveryCodes", func(t *testing.T) {
+
+		codes, err := GenerateRecoveryCodes(userID)
+		if !assert.Nil(t, err) {
+			return
+		}
+		assert.Len(t, codes, RecoveryCodesCount)
+		assert.True(t, IsRecoveryCode(codes[0]))
+
+		user, _ := GetAccountInfo(userID)
+
+		t.Run("Success", func(t *testing.T) {
+			assert.Nil(t, UseRecoveryCode(user, codes[0], "127.0.0.1"))
+		})
+
+		t.Run("AlreadyUsed", func(t *testing.T) {
+			assert.Equal(t, errors.ErrInvalidCode, UseRecoveryCode(user, codes[0], "127.0.0.1"))
+		})
+
+		t.Run("WrongCode", func(t *testing.T) {
+			assert.Equal(t, errors.ErrInvalidCode, UseRecoveryCode(user, "aaaaa-aaaaa", "127.0.0.1"))
+		})
+	})
+
+	t.Run("DisableTwoFactor", func(t *testing.T) {
+
+		t.Run("Success", func(t *testing.T) {
+			assert.Nil(t, DisableTwoFactor(userID))
+		})
+
+		t.Run("AlreadyDisabled", func(t *testing.T) {
+			assert.Equal(t, errors.ErrTwoFactorDisabled, DisableTwoFactor(userID))
+		})
+	})
+}
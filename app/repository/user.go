@@ -2,13 +2,17 @@ package repository
 
 import (
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/zebresel-com/mongodm"
 	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config"
 	"github.com/thedevsir/frame-backend/config/database"
 	"github.com/thedevsir/frame-backend/services/encrypt"
 	"github.com/thedevsir/frame-backend/services/errors"
 	"github.com/thedevsir/frame-backend/services/paginate"
+	"github.com/thedevsir/frame-backend/services/usernames"
 	mgo "gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -22,12 +26,72 @@ func FindUserByCredentials(username, password string) (*model.User, error) {
 	if strings.Index(username, "@") > -1 {
 		findStruct["email"] = strings.ToLower(username)
 	} else {
-		findStruct["username"] = strings.ToLower(username)
+		findStruct["username"] = usernames.Canonical(username)
 	}
 
 	err := userModel.FindOne(findStruct).Exec(user)
 	_, ok := err.(*mongodm.NotFoundError)
 	switch {
+	case ok:
+		// hashing anyway keeps the response time from telling names apart
+		encrypt.CheckHash(password, decoyHash())
+		return nil, errors.ErrUserNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		match := encrypt.CheckHash(password, user.Password)
+		if !match {
+			return nil, errors.ErrInvalidCredentials
+		}
+	}
+
+	if encrypt.NeedsRehash(user.Password) {
+		rehashUserPassword(user, password)
+	}
+
+	return user, nil
+}
+
+var (
+	decoy     string
+	decoyOnce sync.Once
+)
+
+// decoyHash is hashed with the current hasher, so checking it costs what
+// checking a stored password does.
+func decoyHash() string {
+
+	decoyOnce.Do(func() {
+		decoy, _ = encrypt.Hash("decoy")
+	})
+
+	return decoy
+}
+
+// rehashUserPassword moves a password checked at sign in to the current
+// hasher, a failure only leaves the old hash in place until the next one.
+func rehashUserPassword(user *model.User, password string) {
+
+	hash, err := encrypt.Hash(password)
+	if err != nil {
+		return
+	}
+
+	userModel := database.Connection.Model(model.UserCollection)
+	// the filter skips the update when the password changed meanwhile
+	err = userModel.Update(bson.M{"_id": user.Id, "password": user.Password}, bson.M{"$set": bson.M{"password": hash}})
+	if err == nil {
+		user.Password = hash
+	}
+}
+
+func CheckUserPassword(userID, password string) (*model.User, error) {
+
+	userModel := database.Connection.Model(model.UserCollection)
+	user := &model.User{}
+	err := userModel.FindOne(bson.M{"_id": bson.ObjectIdHex(userID), "isActive": true}).Exec(user)
+	_, ok := err.(*mongodm.NotFoundError)
+	switch {
 	case ok:
 		return nil, errors.ErrUserNotFound
 	case err != nil:
@@ -59,6 +123,11 @@ func ChangePassword(userID, password string, admin bool) error {
 			"password": hash,
 		},
 	}
+	if config.PasswordHistory > 0 {
+		update["$push"] = bson.M{
+			"passwordHistory": bson.M{"$each": []string{hash}, "$slice": -config.PasswordHistory},
+		}
+	}
 
 	err = userModel.Update(findStruct, update)
 	switch {
@@ -66,9 +135,10 @@ func ChangePassword(userID, password string, admin bool) error {
 		return errors.ErrUserNotFound
 	case err != nil:
 		return errors.ErrInternal
-	default:
-		return nil
 	}
+
+	// links sent before the change must not reset or sign in again
+	return InvalidateEmailTokens(userID, model.EmailTokenReset, model.EmailTokenLogin)
 }
 
 func UserActivation(userID string) error {
@@ -90,11 +160,23 @@ func UserActivation(userID string) error {
 	}
 }
 
+// CheckUsername finds the user holding a username, a name given up lately
+// is still held by the user who had it.
 func CheckUsername(username string) (*model.User, error) {
 
 	userModel := database.Connection.Model(model.UserCollection)
 	user := &model.User{}
-	err := userModel.FindOne(bson.M{"username": strings.ToLower(username)}).Exec(user)
+	username = usernames.Canonical(username)
+	findStruct := bson.M{
+		"$or": []bson.M{
+			{"username": username},
+			{"usernameHistory": bson.M{"$elemMatch": bson.M{
+				"username":  username,
+				"changedAt": bson.M{"$gt": time.Now().Add(-config.UsernameReservation)},
+			}}},
+		},
+	}
+	err := userModel.FindOne(findStruct).Exec(user)
 	_, ok := err.(*mongodm.NotFoundError)
 	switch {
 	case ok:
@@ -132,11 +214,14 @@ func CreateUser(username, password, email string) (*model.User, error) {
 		return nil, errors.ErrInternal
 	}
 
-	user.Username = strings.ToLower(username)
+	user.Username = usernames.Canonical(username)
 	user.Password = hash
 	user.Email = strings.ToLower(email)
 	user.IsEmailVerified = false
 	user.IsActive = true
+	if config.PasswordHistory > 0 {
+		user.PasswordHistory = []string{hash}
+	}
 
 	err = user.Save()
 	if err != nil {
@@ -146,23 +231,92 @@ func CreateUser(username, password, email string) (*model.User, error) {
 	return user, nil
 }
 
-func ChangeUsername(userID, username string, admin bool) error {
+// ImportUser stores a user exported from another system with the hash it
+// was exported with, the first sign in replaces the hash with a native one.
+// Existing usernames and emails are reported, nothing is overwritten.
+func ImportUser(username, email, hash string, emailVerified, active bool) (*model.User, error) {
+
+	if _, err := CheckUsername(username); err != nil {
+		return nil, err
+	}
+
+	if _, err := CheckEmail(email); err != nil {
+		return nil, err
+	}
 
 	userModel := database.Connection.Model(model.UserCollection)
-	findStruct := bson.M{"_id": bson.ObjectIdHex(userID)}
+	user := &model.User{}
+	userModel.New(user)
+
+	user.Username = usernames.Canonical(username)
+	user.Password = hash
+	user.Email = strings.ToLower(email)
+	user.IsEmailVerified = emailVerified
+	user.IsActive = active
+
+	if err := user.Save(); err != nil {
+		return nil, errors.ErrInternal
+	}
+
+	return user, nil
+}
+
+// ChangeUsername keeps the previous username in the history of the user.
+// Users can not pick reserved names and have to wait between changes, the
+// admins are not held to either.
+func ChangeUsername(userID, username string, admin bool) error {
+
+	username = usernames.Canonical(username)
+
+	user, err := GetUserByIDFromAdmin(userID)
+	if err != nil {
+		return err
+	}
+
+	if !admin && !user.IsActive {
+		return errors.ErrUserNotFound
+	}
+
+	if user.Username == username {
+		return nil
+	}
+
 	if !admin {
-		findStruct["isActive"] = true
+		if err = usernames.Check(username); err != nil {
+			return err
+		}
+		if user.UsernameChangedAt != nil && time.Since(*user.UsernameChangedAt) < config.UsernameChangeCooldown {
+			return errors.ErrUsernameCooldown
+		}
+	}
+
+	// users may take back the names they gave up
+	if holder, err := CheckUsername(username); err != nil && (holder == nil || holder.Id != user.Id) {
+		return err
 	}
+
+	userModel := database.Connection.Model(model.UserCollection)
+	now := time.Now()
 	update := bson.M{
 		"$set": bson.M{
-			"username": username,
+			"username":          username,
+			"usernameChangedAt": now,
+		},
+		// only the latest names are kept, with the cooldown they cover
+		// the reservation
+		"$push": bson.M{
+			"usernameHistory": bson.M{
+				"$each":  []model.PreviousUsername{{Username: user.Username, ChangedAt: now}},
+				"$slice": -config.UsernameHistoryLimit,
+			},
 		},
 	}
 
-	err := userModel.Update(findStruct, update)
+	// the filter loses against a change made meanwhile
+	err = userModel.Update(bson.M{"_id": user.Id, "username": user.Username}, update)
 	switch {
 	case err == mgo.ErrNotFound:
-		return errors.ErrUserNotFound
+		return errors.ErrUsernameExists
 	case err != nil:
 		return errors.ErrInternal
 	default:
@@ -186,8 +340,26 @@ func GetAccountInfo(userID string) (*model.User, error) {
 	}
 }
 
+// emailTokenActions are the links that went to the address of the user.
+var emailTokenActions = []string{
+	model.EmailTokenVerify,
+	model.EmailTokenReset,
+	model.EmailTokenLogin,
+	model.EmailTokenConfirmEmail,
+	model.EmailTokenCancelEmail,
+	model.EmailTokenRestore,
+	model.EmailTokenUnlock,
+	model.EmailTokenSigninAlert,
+}
+
+// ChangeEmail swaps the email right away and leaves it unverified, users
+// go through RequestEmailChange instead.
 func ChangeEmail(userID, email string, admin bool) error {
 
+	if _, err := CheckEmail(email); err != nil {
+		return err
+	}
+
 	userModel := database.Connection.Model(model.UserCollection)
 	findStruct := bson.M{"_id": bson.ObjectIdHex(userID)}
 	if !admin {
@@ -195,8 +367,11 @@ func ChangeEmail(userID, email string, admin bool) error {
 	}
 	update := bson.M{
 		"$set": bson.M{
-			"IsEmailVerified": false,
-			"email":           email,
+			"isEmailVerified": false,
+			"email":           strings.ToLower(email),
+		},
+		"$unset": bson.M{
+			"pendingEmail": "",
 		},
 	}
 
@@ -206,9 +381,93 @@ func ChangeEmail(userID, email string, admin bool) error {
 		return errors.ErrUserNotFound
 	case err != nil:
 		return errors.ErrInternal
+	}
+
+	// every outstanding link went to the previous address
+	return InvalidateEmailTokens(userID, emailTokenActions...)
+}
+
+// RequestEmailChange keeps the new email aside until it is confirmed, the
+// user still signs in and gets mail at the current one meanwhile.
+func RequestEmailChange(userID, email string) (*model.User, error) {
+
+	if _, err := CheckEmail(email); err != nil {
+		return nil, err
+	}
+
+	userModel := database.Connection.Model(model.UserCollection)
+	user := &model.User{}
+	change := mgo.Change{
+		Update:    bson.M{"$set": bson.M{"pendingEmail": strings.ToLower(email)}},
+		ReturnNew: true,
+	}
+
+	_, err := userModel.Collection.Find(bson.M{"_id": bson.ObjectIdHex(userID), "isActive": true}).Apply(change, user)
+	switch {
+	case err == mgo.ErrNotFound:
+		return nil, errors.ErrUserNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
 	default:
-		return nil
+		return user, nil
+	}
+}
+
+// ConfirmEmailChange switches to the pending email the link was sent to,
+// it is checked again since another account may have taken it meanwhile.
+func ConfirmEmailChange(userID, email string) error {
+
+	if _, err := CheckEmail(email); err != nil {
+		return err
+	}
+
+	userModel := database.Connection.Model(model.UserCollection)
+	findStruct := bson.M{
+		"_id":          bson.ObjectIdHex(userID),
+		"isActive":     true,
+		"pendingEmail": strings.ToLower(email),
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"isEmailVerified": true,
+			"email":           strings.ToLower(email),
+		},
+		"$unset": bson.M{
+			"pendingEmail": "",
+		},
 	}
+
+	err := userModel.Update(findStruct, update)
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrTokenIsNotValid
+	case err != nil:
+		return errors.ErrInternal
+	}
+
+	return InvalidateEmailTokens(userID, emailTokenActions...)
+}
+
+// CancelEmailChange drops the pending email, the confirmation link stops
+// working with it.
+func CancelEmailChange(userID string) error {
+
+	userModel := database.Connection.Model(model.UserCollection)
+	update := bson.M{
+		"$unset": bson.M{
+			"pendingEmail": "",
+		},
+	}
+
+	err := userModel.Update(bson.M{"_id": bson.ObjectIdHex(userID), "pendingEmail": bson.M{"$exists": true}}, update)
+	switch {
+	case err == mgo.ErrNotFound:
+		return errors.ErrTokenIsNotValid
+	case err != nil:
+		return errors.ErrInternal
+	}
+
+	return InvalidateEmailTokens(userID, model.EmailTokenConfirmEmail)
 }
 
 func GetUsers(page, limit int) (*paginate.Paginate, error) {
@@ -257,6 +516,27 @@ func GetUserByID(userID string) (*model.User, error) {
 	}
 }
 
+// GetUserPasswords reads what a new password of the user is checked against,
+// the current hash and the hashes of the passwords set before it.
+func GetUserPasswords(userID string) (*model.User, error) {
+
+	userModel := database.Connection.Model(model.UserCollection)
+	user := &model.User{}
+	result := userModel.FindOne(bson.M{"_id": bson.ObjectIdHex(userID), "isActive": true}).
+		Select(bson.M{"username": 1, "email": 1, "password": 1, "passwordHistory": 1})
+
+	err := result.Exec(user)
+	_, ok := err.(*mongodm.NotFoundError)
+	switch {
+	case ok:
+		return nil, errors.ErrUserNotFound
+	case err != nil:
+		return nil, errors.ErrInternal
+	default:
+		return user, nil
+	}
+}
+
 func GetUserByIDFromAdmin(userID string) (*model.User, error) {
 
 	userModel := database.Connection.Model(model.UserCollection)
@@ -283,6 +563,10 @@ func ChangeUserStatus(userID string, status bool) error {
 			"isActive": status,
 		},
 	}
+	// an admin activating the account calls its deletion off
+	if status {
+		update["$unset"] = bson.M{"deleteAt": ""}
+	}
 
 	err := userModel.UpdateId(bson.ObjectIdHex(userID), update)
 	switch {
@@ -6,7 +6,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/zebresel-com/mongodm"
 	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config"
 	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/encrypt"
 	"github.com/thedevsir/frame-backend/services/errors"
 	"github.com/thedevsir/frame-backend/services/test"
 	"github.com/thedevsir/frame-backend/services/utils"
@@ -44,6 +46,31 @@ func TestUser(t *testing.T) {
 		assert.Nil(t, err)
 	})
 
+	t.Run("ImportUser", func(t *testing.T) {
+
+		hash := "pbkdf2_sha256$1000$seasalt$R1/GfWtwog1T8Ev9VndgDjzkiRzbFr8JpmJtL9cMmQU="
+
+		t.Run("UsernameExists", func(t *testing.T) {
+			_, err := ImportUser(username, "imported@gmail.com", hash, true, true)
+			assert.Equal(t, errors.ErrUsernameExists, err)
+		})
+
+		t.Run("EmailExists", func(t *testing.T) {
+			_, err := ImportUser("imported", email, hash, true, true)
+			assert.Equal(t, errors.ErrEmailExists, err)
+		})
+
+		t.Run("Success", func(t *testing.T) {
+			_, err := ImportUser("imported", "imported@gmail.com", hash, true, true)
+			assert.Nil(t, err)
+
+			result, err := FindUserByCredentials("imported", "letmein")
+			if assert.Nil(t, err) {
+				assert.False(t, encrypt.NeedsRehash(result.Password))
+			}
+		})
+	})
+
 	t.Run("Activation", func(t *testing.T) {
 
 		t.Run("Success", func(t *testing.T) {
@@ -105,6 +132,22 @@ func TestUser(t *testing.T) {
 		t.Run("Success", func(t *testing.T) {
 			assert.Nil(t, ChangeUsername(insertedData.Id.Hex(), username, false))
 		})
+
+		t.Run("HistoryLimit", func(t *testing.T) {
+
+			defer func(limit int) { config.UsernameHistoryLimit = limit }(config.UsernameHistoryLimit)
+			config.UsernameHistoryLimit = 2
+
+			for _, name := range []string{"renamedone", "renamedtwo", username} {
+				assert.Nil(t, ChangeUsername(insertedData.Id.Hex(), name, true))
+			}
+
+			user, _ := GetUserByIDFromAdmin(insertedData.Id.Hex())
+			if assert.Len(t, user.UsernameHistory, 2) {
+				assert.Equal(t, "renamedone", user.UsernameHistory[0].Username)
+				assert.Equal(t, "renamedtwo", user.UsernameHistory[1].Username)
+			}
+		})
 	})
 
 	t.Run("ChangeEmail", func(t *testing.T) {
@@ -118,6 +161,46 @@ func TestUser(t *testing.T) {
 		t.Run("Success", func(t *testing.T) {
 			assert.Nil(t, ChangeEmail(insertedData.Id.Hex(), email, false))
 		})
+
+		t.Run("EmailExists", func(t *testing.T) {
+			assert.Equal(t, errors.ErrEmailExists, ChangeEmail(insertedData.Id.Hex(), "imported@gmail.com", false))
+		})
+	})
+
+	t.Run("EmailChange", func(t *testing.T) {
+
+		pending := "pending@rock.age"
+
+		t.Run("RequestEmailExists", func(t *testing.T) {
+			_, err := RequestEmailChange(insertedData.Id.Hex(), "imported@gmail.com")
+			assert.Equal(t, errors.ErrEmailExists, err)
+		})
+
+		t.Run("Cancel", func(t *testing.T) {
+			user, err := RequestEmailChange(insertedData.Id.Hex(), pending)
+			if assert.Nil(t, err) {
+				assert.Equal(t, email, user.Email)
+				assert.Equal(t, pending, user.PendingEmail)
+			}
+
+			assert.Nil(t, CancelEmailChange(insertedData.Id.Hex()))
+			assert.Equal(t, errors.ErrTokenIsNotValid, ConfirmEmailChange(insertedData.Id.Hex(), pending))
+			assert.Equal(t, errors.ErrTokenIsNotValid, CancelEmailChange(insertedData.Id.Hex()))
+		})
+
+		t.Run("Confirm", func(t *testing.T) {
+			RequestEmailChange(insertedData.Id.Hex(), pending)
+
+			// only the latest request can be confirmed
+			assert.Equal(t, errors.ErrTokenIsNotValid, ConfirmEmailChange(insertedData.Id.Hex(), "other@rock.age"))
+			assert.Nil(t, ConfirmEmailChange(insertedData.Id.Hex(), pending))
+
+			user, _ := GetAccountInfo(insertedData.Id.Hex())
+			assert.Equal(t, pending, user.Email)
+			assert.Empty(t, user.PendingEmail)
+			assert.True(t, user.IsEmailVerified)
+			email = pending
+		})
 	})
 
 	t.Run("GetAccountInfo", func(t *testing.T) {
@@ -155,6 +238,22 @@ func TestUser(t *testing.T) {
 			_, err := FindUserByCredentials(username, "123")
 			assert.Equal(t, errors.ErrInvalidCredentials, err)
 		})
+
+		t.Run("Rehash", func(t *testing.T) {
+
+			old, _ := encrypt.Bcrypt{Cost: 4}.Hash(password)
+			userCollection.UpdateId(insertedData.Id, bson.M{"$set": bson.M{"password": old}})
+
+			result, err := FindUserByCredentials(username, password)
+			if assert.Nil(t, err) {
+				assert.False(t, encrypt.NeedsRehash(result.Password))
+
+				stored := &model.User{}
+				userCollection.FindId(insertedData.Id).Exec(stored)
+				assert.Equal(t, result.Password, stored.Password)
+				assert.True(t, encrypt.CheckHash(password, stored.Password))
+			}
+		})
 	})
 
 	t.Run("GetUserByID", func(t *testing.T) {
@@ -2,6 +2,8 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -9,15 +11,30 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/app/repository"
 	"github.com/thedevsir/frame-backend/config"
 	"github.com/thedevsir/frame-backend/config/database"
 	"github.com/thedevsir/frame-backend/services/encrypt"
-	"github.com/thedevsir/frame-backend/services/utils"
+	"github.com/thedevsir/frame-backend/services/importer"
+	"github.com/thedevsir/frame-backend/services/keyring"
 )
 
 func main() {
 
 	config.Composer(".env")
+	encrypt.Composer()
+
+	connect := func() {
+		db := &database.Composer{
+			Locals:   "resource/locals/locals.json",
+			Addrs:    strings.Split(config.DBAddress, ","),
+			Database: config.DBName,
+			Username: config.DBUsername,
+			Password: config.DBPassword,
+			Source:   config.DBSource,
+		}
+		db.Shoot()
+	}
 
 	var adminPassword string
 	var cmdAdminUserInstall = &cobra.Command{
@@ -25,15 +42,7 @@ func main() {
 		Short: "Create a admin with username and password",
 		Run: func(cmd *cobra.Command, args []string) {
 
-			db := &database.Composer{
-				Locals:   "resource/locals/locals.json",
-				Addrs:    strings.Split(config.DBAddress, ","),
-				Database: config.DBName,
-				Username: config.DBUsername,
-				Password: config.DBPassword,
-				Source:   config.DBSource,
-			}
-			db.Shoot()
+			connect()
 
 			adminModel := database.Connection.Model(model.AdminCollection)
 			admin := &model.Admin{}
@@ -56,7 +65,214 @@ func main() {
 	}
 	cmdAdminUserInstall.Flags().StringVarP(&adminPassword, "password", "p", "admin", "Password of root admin")
 
+	var ring, algorithm string
+	var activate bool
+
+	// ringDir resolves the --ring flag, a new key gets the algorithm of the
+	// active key unless --alg names another one.
+	ringDir := func() (string, error) {
+
+		dir, err := keyring.Dir(ring)
+		if err != nil {
+			return "", err
+		}
+
+		if algorithm == "" {
+			algorithm = config.KeyringAlgorithm
+			manifest, err := keyring.ReadManifest(dir)
+			if err != nil {
+				return "", err
+			}
+			for _, entry := range manifest.Keys {
+				if entry.Active {
+					algorithm = entry.Algorithm
+				}
+			}
+		}
+
+		return dir, nil
+	}
+
+	var cmdKeys = &cobra.Command{
+		Use:   "keys",
+		Short: "Manage the keys that sign user, admin and ID tokens",
+		Long: `Every ring signs new tokens with its active key and verifies tokens with all of its keys.
+Running instances reload the rings every KEYRING_RELOAD, generate a key first and activate
+it once every instance has it. Retire the old key after the tokens it signed have expired.`,
+	}
+	cmdKeys.PersistentFlags().StringVarP(&ring, "ring", "r", keyring.UserRing, "Ring of keys: user, admin or oidc")
+
+	var cmdKeysList = &cobra.Command{
+		Use:   "list",
+		Short: "List the keys of a ring",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			dir, err := ringDir()
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+
+			manifest, err := keyring.ReadManifest(dir)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+
+			for _, entry := range manifest.Keys {
+				status := "verify"
+				if entry.Active {
+					status = "active"
+				}
+				fmt.Printf("%s\t%s\t%s\t%s\n", entry.ID, entry.Algorithm, status, entry.CreatedAt.Format(time.RFC3339))
+			}
+		},
+	}
+
+	var cmdKeysGenerate = &cobra.Command{
+		Use:   "generate",
+		Short: "Add a new key to a ring, it only verifies tokens until it is activated",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			dir, err := ringDir()
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+
+			key, err := keyring.Generate(dir, algorithm, activate)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			fmt.Printf("Key %s (%s) successfully generated!\n", key.ID, key.Algorithm)
+		},
+	}
+	cmdKeysGenerate.Flags().StringVarP(&algorithm, "alg", "a", "", "Algorithm of the key: RS256, ES256 or EdDSA")
+	cmdKeysGenerate.Flags().BoolVar(&activate, "activate", false, "Sign new tokens with the key right away")
+
+	var cmdKeysRotate = &cobra.Command{
+		Use:   "rotate",
+		Short: "Generate a key and activate it, the previous key keeps verifying tokens",
+		Run: func(cmd *cobra.Command, args []string) {
+
+			dir, err := ringDir()
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+
+			key, err := keyring.Generate(dir, algorithm, true)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			fmt.Printf("Key %s (%s) successfully activated!\n", key.ID, key.Algorithm)
+		},
+	}
+	cmdKeysRotate.Flags().StringVarP(&algorithm, "alg", "a", "", "Algorithm of the key: RS256, ES256 or EdDSA")
+
+	var cmdKeysActivate = &cobra.Command{
+		Use:   "activate [kid]",
+		Short: "Sign new tokens with a key of a ring",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			dir, err := ringDir()
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+
+			if err = keyring.Activate(dir, args[0]); err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			fmt.Println("Key successfully activated!")
+		},
+	}
+
+	var cmdKeysRetire = &cobra.Command{
+		Use:   "retire [kid]",
+		Short: "Remove a key from a ring, the tokens it signed are rejected",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			dir, err := ringDir()
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+
+			if err = keyring.Retire(dir, args[0]); err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			fmt.Println("Key successfully retired!")
+		},
+	}
+
+	cmdKeys.AddCommand(cmdKeysList, cmdKeysGenerate, cmdKeysRotate, cmdKeysActivate, cmdKeysRetire)
+
+	var source, format string
+	var firebase importer.FirebaseParams
+
+	var cmdImportUsers = &cobra.Command{
+		Use:   "import-users [file]",
+		Short: "Import users exported from Django or Firebase with their password hashes",
+		Long: `Users keep the hash they were exported with until their first sign in replaces it.
+Firebase hashes are checked with FIREBASE_SIGNER_KEY and the parameters given as flags.
+Users whose username or email already exists are reported and skipped.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+
+			if format == "" {
+				format = strings.TrimPrefix(strings.ToLower(filepath.Ext(args[0])), ".")
+			}
+
+			file, err := os.Open(args[0])
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+			defer file.Close()
+
+			records, err := importer.Read(file, source, format, firebase)
+			if err != nil {
+				fmt.Println(err.Error())
+				return
+			}
+
+			connect()
+
+			imported, skipped := 0, 0
+			for _, record := range records {
+
+				if !record.Valid() {
+					fmt.Printf("#%d %s <%s>: invalid username or email\n", record.Number, record.Username, record.Email)
+					skipped++
+					continue
+				}
+
+				_, err := repository.ImportUser(record.Username, record.Email, record.Hash, record.EmailVerified, record.Active)
+				if err != nil {
+					fmt.Printf("#%d %s <%s>: %v\n", record.Number, record.Username, record.Email, err)
+					skipped++
+					continue
+				}
+				imported++
+			}
+
+			fmt.Printf("%d users imported, %d skipped\n", imported, skipped)
+		},
+	}
+	cmdImportUsers.Flags().StringVarP(&source, "source", "s", importer.SourceDjango, "System the users come from: django or firebase")
+	cmdImportUsers.Flags().StringVarP(&format, "format", "f", "", "Format of the file: json or csv, defaults to its extension")
+	cmdImportUsers.Flags().IntVar(&firebase.Rounds, "rounds", 8, "Rounds of the Firebase password hash")
+	cmdImportUsers.Flags().IntVar(&firebase.MemoryCost, "mem-cost", 14, "Memory cost of the Firebase password hash")
+	cmdImportUsers.Flags().StringVar(&firebase.SaltSeparator, "salt-separator", "Bw==", "Base64 salt separator of the Firebase password hash")
+
 	var rootCmd = &cobra.Command{Use: "cmd"}
-	rootCmd.AddCommand(cmdAdminUserInstall)
+	rootCmd.AddCommand(cmdAdminUserInstall, cmdKeys, cmdImportUsers)
 	rootCmd.Execute()
 }
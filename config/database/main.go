@@ -7,8 +7,10 @@ import (
 
 	"github.com/zebresel-com/mongodm"
 	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config"
 	"github.com/thedevsir/frame-backend/services/utils"
 	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
 )
 
 var Connection *mongodm.Connection
@@ -52,10 +54,22 @@ func (c Composer) Shoot() {
 	}
 
 	models := map[string]mongodm.IDocumentBase{
-		"authAttempts": &model.AuthAttempt{},
-		"sessions":     &model.Session{},
-		"users":        &model.User{},
-		"admin":        &model.Admin{},
+		"authAttempts":     &model.AuthAttempt{},
+		"sessions":         &model.Session{},
+		"users":            &model.User{},
+		"admin":            &model.Admin{},
+		"passkeys":         &model.Passkey{},
+		"oauthClients":     &model.OAuthClient{},
+		"oauthConsents":    &model.OAuthConsent{},
+		"oauthCodes":       &model.OAuthCode{},
+		"oauthTokens":      &model.OAuthToken{},
+		"socialIdentities": &model.SocialIdentity{},
+		"emailTokens":      &model.EmailToken{},
+		"dataExports":      &model.DataExport{},
+		"personalTokens":   &model.PersonalToken{},
+		"accountLocks":     &model.AccountLock{},
+		"rateLimits":       &model.RateLimit{},
+		"knownDevices":     &model.KnownDevice{},
 	}
 
 	for k, v := range models {
@@ -68,16 +82,212 @@ func (c Composer) Shoot() {
 	}
 
 	// Indexes
+
+	// authAttempts predates its indexes, so they are ensured on every start.
+	// A changed AUTH_ATTEMPT_LIFETIME is applied to the TTL index in place
+	// first, this fails harmlessly while the index does not exist yet.
+	command := bson.D{
+		{Name: "collMod", Value: "authAttempts"},
+		{Name: "index", Value: bson.M{
+			"keyPattern":         bson.M{"createdAt": 1},
+			"expireAfterSeconds": int(config.AuthAttemptLifetime / time.Second),
+		}},
+	}
+	Connection.Session.DB(c.Database).Run(command, nil)
+
+	indexes := []mgo.Index{
+		{Key: []string{"username"}},
+		{Key: []string{"createdAt"}, ExpireAfter: config.AuthAttemptLifetime},
+	}
+
+	for _, index := range indexes {
+		err = Connection.Model(model.AuthAttemptCollection).EnsureIndex(index)
+		if err != nil {
+			panic(err)
+		}
+	}
+
 	if !utils.Contains(collections, "sessions") {
 
 		index := mgo.Index{
 			Key:         []string{"expireAt"},
-			ExpireAfter: time.Hour * 24,
+			ExpireAfter: time.Second,
 		}
 
 		err = Connection.Model(model.SessionCollection).EnsureIndex(index)
 		if err != nil {
 			panic(err)
 		}
+	} else {
+
+		// Sessions used to outlive expireAt by a day. Older servers may not
+		// know collMod for indexes, the middleware rejects expired sessions
+		// anyway so the result is ignored.
+		command := bson.D{
+			{Name: "collMod", Value: "sessions"},
+			{Name: "index", Value: bson.M{
+				"keyPattern":         bson.M{"expireAt": 1},
+				"expireAfterSeconds": 1,
+			}},
+		}
+		Connection.Session.DB(c.Database).Run(command, nil)
+	}
+
+	if !utils.Contains(collections, "passkeys") {
+
+		index := mgo.Index{
+			Key:    []string{"credentialId"},
+			Unique: true,
+		}
+
+		err = Connection.Model(model.PasskeyCollection).EnsureIndex(index)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if !utils.Contains(collections, "oauthClients") {
+
+		index := mgo.Index{
+			Key:    []string{"clientId"},
+			Unique: true,
+		}
+
+		err = Connection.Model(model.OAuthClientCollection).EnsureIndex(index)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if !utils.Contains(collections, "oauthConsents") {
+
+		index := mgo.Index{
+			Key:    []string{"userId", "clientId"},
+			Unique: true,
+		}
+
+		err = Connection.Model(model.OAuthConsentCollection).EnsureIndex(index)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if !utils.Contains(collections, "oauthCodes") {
+
+		indexes := []mgo.Index{
+			{Key: []string{"code"}, Unique: true},
+			{Key: []string{"expireAt"}, ExpireAfter: time.Second},
+		}
+
+		for _, index := range indexes {
+			err = Connection.Model(model.OAuthCodeCollection).EnsureIndex(index)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	if !utils.Contains(collections, "oauthTokens") {
+
+		indexes := []mgo.Index{
+			{Key: []string{"token"}, Unique: true},
+			{Key: []string{"expireAt"}, ExpireAfter: time.Second},
+		}
+
+		for _, index := range indexes {
+			err = Connection.Model(model.OAuthTokenCollection).EnsureIndex(index)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	if !utils.Contains(collections, "socialIdentities") {
+
+		indexes := []mgo.Index{
+			{Key: []string{"provider", "subject"}, Unique: true},
+			{Key: []string{"userId", "provider"}, Unique: true},
+		}
+
+		for _, index := range indexes {
+			err = Connection.Model(model.SocialIdentityCollection).EnsureIndex(index)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	if !utils.Contains(collections, "emailTokens") {
+
+		indexes := []mgo.Index{
+			{Key: []string{"token"}, Unique: true},
+			{Key: []string{"expireAt"}, ExpireAfter: time.Second},
+		}
+
+		for _, index := range indexes {
+			err = Connection.Model(model.EmailTokenCollection).EnsureIndex(index)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	if !utils.Contains(collections, "personalTokens") {
+
+		index := mgo.Index{
+			Key:    []string{"token"},
+			Unique: true,
+		}
+
+		err = Connection.Model(model.PersonalTokenCollection).EnsureIndex(index)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	if !utils.Contains(collections, "accountLocks") {
+
+		// a lock is over and its failures are forgotten by then
+		indexes := []mgo.Index{
+			{Key: []string{"key"}, Unique: true},
+			{Key: []string{"lastFailureAt"}, ExpireAfter: config.LockoutWindow + config.LockoutMaxDelay},
+		}
+
+		for _, index := range indexes {
+			err = Connection.Model(model.AccountLockCollection).EnsureIndex(index)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	if !utils.Contains(collections, "rateLimits") {
+
+		indexes := []mgo.Index{
+			{Key: []string{"key", "start"}, Unique: true},
+			{Key: []string{"expireAt"}, ExpireAfter: time.Second},
+		}
+
+		for _, index := range indexes {
+			err = Connection.Model(model.RateLimitCollection).EnsureIndex(index)
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	if !utils.Contains(collections, "knownDevices") {
+
+		indexes := []mgo.Index{
+			{Key: []string{"userId", "key"}, Unique: true},
+			{Key: []string{"lastUsedAt"}, ExpireAfter: config.KnownDeviceLifetime},
+		}
+
+		for _, index := range indexes {
+			err = Connection.Model(model.KnownDeviceCollection).EnsureIndex(index)
+			if err != nil {
+				panic(err)
+			}
+		}
 	}
 }
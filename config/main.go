@@ -1,8 +1,12 @@
 package config
 
 import (
+	"fmt"
+	"net"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/thedevsir/frame-backend/services/utils"
 )
@@ -19,11 +23,61 @@ var (
 	DBPassword string
 	DBSource   string
 
-	AbuseIP         int
-	AbuseIPUsername int
+	AbuseIP             int
+	AbuseIPUsername     int
+	AbuseWindow         time.Duration
+	AuthAttemptLifetime time.Duration
 
-	SigningKey      string
-	AdminSigningKey string
+	TrustedProxies []*net.IPNet
+
+	RateLimitStore  string
+	RateLimitSignup Rate
+	RateLimitResend Rate
+	RateLimitForgot Rate
+	RateLimitSignin Rate
+	RateLimitAvatar Rate
+
+	LockoutThreshold int
+	LockoutDelay     time.Duration
+	LockoutMaxDelay  time.Duration
+	LockoutWindow    time.Duration
+
+	SigningKey    string
+	EncryptionKey string
+
+	KeyringPath      string
+	KeyringAlgorithm string
+	KeyringReload    time.Duration
+
+	KeyringLegacyTokens bool
+	AdminSigningKey     string
+
+	AccessTokenLifetime time.Duration
+
+	SessionLifetime            time.Duration
+	SessionIdleTimeout         time.Duration
+	SessionRememberLifetime    time.Duration
+	SessionRememberIdleTimeout time.Duration
+
+	TwoFactorIssuer            string
+	TwoFactorChallengeLifetime time.Duration
+
+	WebAuthnRPID    string
+	WebAuthnRPName  string
+	WebAuthnOrigins string
+	WebAuthnTimeout time.Duration
+
+	OAuthCodeLifetime         time.Duration
+	OAuthAccessTokenLifetime  time.Duration
+	OAuthRefreshTokenLifetime time.Duration
+	OAuthAuthorizeLink        string
+
+	OIDCIssuer          string
+	OIDCIDTokenLifetime time.Duration
+
+	SocialProviders    string
+	SocialRedirectLink string
+	SocialTimeout      time.Duration
 
 	SMTPHost     string
 	SMTPPort     int
@@ -40,9 +94,48 @@ var (
 
 	EmailVerifyLink string
 	EmailResetLink  string
+	EmailLoginLink  string
+
+	EmailConfirmEmailLink string
+	EmailCancelEmailLink  string
+	EmailRestoreLink      string
+	EmailUnlockLink       string
+	EmailSigninAlertLink  string
+
+	MagicLinkLifetime time.Duration
+
+	KnownDeviceLifetime time.Duration
+	GeoIPDatabase       string
+
+	UsernameReservedList   string
+	UsernameChangeCooldown time.Duration
+	UsernameReservation    time.Duration
+	UsernameHistoryLimit   int
+
+	AccountDeletionGrace    time.Duration
+	AccountDeletionInterval time.Duration
+
+	DataExportLifetime time.Duration
+	DataExportInterval time.Duration
+
+	PasswordMinLength    int
+	PasswordMaxLength    int
+	PasswordMinScore     int
+	PasswordBannedList   string
+	PasswordBreachedPath string
+	PasswordHistory      int
+
+	PasswordHasher string
+	Argon2Time     int
+	Argon2Memory   int
+	Argon2Threads  int
+	BcryptCost     int
+
+	FirebaseSignerKey string
 
 	MinioEndpoint        string
 	MinioBuckets         string
+	MinioExportBucket    string
 	MinioAccessKeyID     string
 	MinioSecretAccessKey string
 
@@ -50,6 +143,61 @@ var (
 	AvatarPictureMaxSize int64
 )
 
+// Rate allows Limit requests per Window, it is written as "5/1h".
+type Rate struct {
+	Limit  int
+	Window time.Duration
+}
+
+func parseRate(value string) (rate Rate, err error) {
+
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return rate, fmt.Errorf("config: rate %q is not written as limit/window", value)
+	}
+
+	if rate.Limit, err = strconv.Atoi(parts[0]); err != nil {
+		return rate, err
+	}
+
+	rate.Window, err = time.ParseDuration(parts[1])
+	return rate, err
+}
+
+// parseTrustedProxies reads a comma separated list of addresses and CIDR
+// ranges, a bare address is a range of its own.
+func parseTrustedProxies(value string) (networks []*net.IPNet, err error) {
+
+	for _, part := range strings.Split(value, ",") {
+
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		if !strings.Contains(part, "/") {
+			ip := net.ParseIP(part)
+			if ip == nil {
+				return nil, fmt.Errorf("config: trusted proxy %q is not an address", part)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(part)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+
+	return networks, nil
+}
+
 func Composer(envPath string) (err error) {
 
 	utils.Env(envPath)
@@ -75,9 +223,153 @@ func Composer(envPath string) (err error) {
 		panic(err)
 	}
 
+	AbuseWindow, err = time.ParseDuration(os.Getenv("ABUSE_WINDOW"))
+	if err != nil {
+		panic(err)
+	}
+
+	AuthAttemptLifetime, err = time.ParseDuration(os.Getenv("AUTH_ATTEMPT_LIFETIME"))
+	if err != nil {
+		panic(err)
+	}
+
+	TrustedProxies, err = parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		panic(err)
+	}
+
+	RateLimitStore = os.Getenv("RATE_LIMIT_STORE")
+
+	RateLimitSignup, err = parseRate(os.Getenv("RATE_LIMIT_SIGNUP"))
+	if err != nil {
+		panic(err)
+	}
+
+	RateLimitResend, err = parseRate(os.Getenv("RATE_LIMIT_RESEND"))
+	if err != nil {
+		panic(err)
+	}
+
+	RateLimitForgot, err = parseRate(os.Getenv("RATE_LIMIT_FORGOT"))
+	if err != nil {
+		panic(err)
+	}
+
+	RateLimitSignin, err = parseRate(os.Getenv("RATE_LIMIT_SIGNIN"))
+	if err != nil {
+		panic(err)
+	}
+
+	RateLimitAvatar, err = parseRate(os.Getenv("RATE_LIMIT_AVATAR"))
+	if err != nil {
+		panic(err)
+	}
+
+	LockoutThreshold, err = strconv.Atoi(os.Getenv("LOCKOUT_THRESHOLD"))
+	if err != nil {
+		panic(err)
+	}
+
+	LockoutDelay, err = time.ParseDuration(os.Getenv("LOCKOUT_DELAY"))
+	if err != nil {
+		panic(err)
+	}
+
+	LockoutMaxDelay, err = time.ParseDuration(os.Getenv("LOCKOUT_MAX_DELAY"))
+	if err != nil {
+		panic(err)
+	}
+
+	LockoutWindow, err = time.ParseDuration(os.Getenv("LOCKOUT_WINDOW"))
+	if err != nil {
+		panic(err)
+	}
+
 	SigningKey = os.Getenv("SIGNING_KEY")
+	EncryptionKey = os.Getenv("ENCRYPTION_KEY")
+
+	KeyringPath = os.Getenv("KEYRING_PATH")
+	KeyringAlgorithm = os.Getenv("KEYRING_ALGORITHM")
+	KeyringReload, err = time.ParseDuration(os.Getenv("KEYRING_RELOAD"))
+	if err != nil {
+		panic(err)
+	}
+
+	KeyringLegacyTokens, err = strconv.ParseBool(os.Getenv("KEYRING_LEGACY_TOKENS"))
+	if err != nil {
+		panic(err)
+	}
 	AdminSigningKey = os.Getenv("ADMIN_SIGNING_KEY")
 
+	AccessTokenLifetime, err = time.ParseDuration(os.Getenv("ACCESS_TOKEN_LIFETIME"))
+	if err != nil {
+		panic(err)
+	}
+
+	SessionLifetime, err = time.ParseDuration(os.Getenv("SESSION_LIFETIME"))
+	if err != nil {
+		panic(err)
+	}
+
+	SessionIdleTimeout, err = time.ParseDuration(os.Getenv("SESSION_IDLE_TIMEOUT"))
+	if err != nil {
+		panic(err)
+	}
+
+	SessionRememberLifetime, err = time.ParseDuration(os.Getenv("SESSION_REMEMBER_LIFETIME"))
+	if err != nil {
+		panic(err)
+	}
+
+	SessionRememberIdleTimeout, err = time.ParseDuration(os.Getenv("SESSION_REMEMBER_IDLE_TIMEOUT"))
+	if err != nil {
+		panic(err)
+	}
+
+	TwoFactorIssuer = os.Getenv("TWO_FACTOR_ISSUER")
+	TwoFactorChallengeLifetime, err = time.ParseDuration(os.Getenv("TWO_FACTOR_CHALLENGE_LIFETIME"))
+	if err != nil {
+		panic(err)
+	}
+
+	WebAuthnRPID = os.Getenv("WEBAUTHN_RP_ID")
+	WebAuthnRPName = os.Getenv("WEBAUTHN_RP_NAME")
+	WebAuthnOrigins = os.Getenv("WEBAUTHN_ORIGINS")
+	WebAuthnTimeout, err = time.ParseDuration(os.Getenv("WEBAUTHN_TIMEOUT"))
+	if err != nil {
+		panic(err)
+	}
+
+	OAuthCodeLifetime, err = time.ParseDuration(os.Getenv("OAUTH_CODE_LIFETIME"))
+	if err != nil {
+		panic(err)
+	}
+
+	OAuthAccessTokenLifetime, err = time.ParseDuration(os.Getenv("OAUTH_ACCESS_TOKEN_LIFETIME"))
+	if err != nil {
+		panic(err)
+	}
+
+	OAuthRefreshTokenLifetime, err = time.ParseDuration(os.Getenv("OAUTH_REFRESH_TOKEN_LIFETIME"))
+	if err != nil {
+		panic(err)
+	}
+
+	OAuthAuthorizeLink = os.Getenv("OAUTH_AUTHORIZE_LINK")
+
+	OIDCIssuer = os.Getenv("OIDC_ISSUER")
+	OIDCIDTokenLifetime, err = time.ParseDuration(os.Getenv("OIDC_ID_TOKEN_LIFETIME"))
+	if err != nil {
+		panic(err)
+	}
+
+	SocialProviders = os.Getenv("SOCIAL_PROVIDERS")
+	SocialRedirectLink = os.Getenv("SOCIAL_REDIRECT_LINK")
+	SocialTimeout, err = time.ParseDuration(os.Getenv("SOCIAL_TIMEOUT"))
+	if err != nil {
+		panic(err)
+	}
+
 	SMTPHost = os.Getenv("SMTP_HOST")
 	SMTPPort, err = strconv.Atoi(os.Getenv("SMTP_PORT"))
 	if err != nil {
@@ -97,9 +389,110 @@ func Composer(envPath string) (err error) {
 
 	EmailVerifyLink = os.Getenv("EMAIL_VERIFY_LINK")
 	EmailResetLink = os.Getenv("EMAIL_RESET_LINK")
+	EmailLoginLink = os.Getenv("EMAIL_LOGIN_LINK")
+	EmailConfirmEmailLink = os.Getenv("EMAIL_CONFIRM_EMAIL_LINK")
+	EmailCancelEmailLink = os.Getenv("EMAIL_CANCEL_EMAIL_LINK")
+	EmailRestoreLink = os.Getenv("EMAIL_RESTORE_LINK")
+	EmailUnlockLink = os.Getenv("EMAIL_UNLOCK_LINK")
+	EmailSigninAlertLink = os.Getenv("EMAIL_SIGNIN_ALERT_LINK")
+
+	MagicLinkLifetime, err = time.ParseDuration(os.Getenv("MAGIC_LINK_LIFETIME"))
+	if err != nil {
+		panic(err)
+	}
+
+	KnownDeviceLifetime, err = time.ParseDuration(os.Getenv("KNOWN_DEVICE_LIFETIME"))
+	if err != nil {
+		panic(err)
+	}
+
+	GeoIPDatabase = os.Getenv("GEOIP_DATABASE")
+
+	UsernameReservedList = os.Getenv("USERNAME_RESERVED_LIST")
+	UsernameChangeCooldown, err = time.ParseDuration(os.Getenv("USERNAME_CHANGE_COOLDOWN"))
+	if err != nil {
+		panic(err)
+	}
+
+	UsernameReservation, err = time.ParseDuration(os.Getenv("USERNAME_RESERVATION"))
+	if err != nil {
+		panic(err)
+	}
+
+	UsernameHistoryLimit, err = strconv.Atoi(os.Getenv("USERNAME_HISTORY_LIMIT"))
+	if err != nil {
+		panic(err)
+	}
+
+	AccountDeletionGrace, err = time.ParseDuration(os.Getenv("ACCOUNT_DELETION_GRACE"))
+	if err != nil {
+		panic(err)
+	}
+
+	AccountDeletionInterval, err = time.ParseDuration(os.Getenv("ACCOUNT_DELETION_INTERVAL"))
+	if err != nil {
+		panic(err)
+	}
+
+	DataExportLifetime, err = time.ParseDuration(os.Getenv("DATA_EXPORT_LIFETIME"))
+	if err != nil {
+		panic(err)
+	}
+
+	DataExportInterval, err = time.ParseDuration(os.Getenv("DATA_EXPORT_INTERVAL"))
+	if err != nil {
+		panic(err)
+	}
+
+	PasswordMinLength, err = strconv.Atoi(os.Getenv("PASSWORD_MIN_LENGTH"))
+	if err != nil {
+		panic(err)
+	}
+
+	PasswordMaxLength, err = strconv.Atoi(os.Getenv("PASSWORD_MAX_LENGTH"))
+	if err != nil {
+		panic(err)
+	}
+
+	PasswordMinScore, err = strconv.Atoi(os.Getenv("PASSWORD_MIN_SCORE"))
+	if err != nil {
+		panic(err)
+	}
+
+	PasswordBannedList = os.Getenv("PASSWORD_BANNED_LIST")
+	PasswordBreachedPath = os.Getenv("PASSWORD_BREACHED_PATH")
+
+	PasswordHistory, err = strconv.Atoi(os.Getenv("PASSWORD_HISTORY"))
+	if err != nil {
+		panic(err)
+	}
+
+	PasswordHasher = os.Getenv("PASSWORD_HASHER")
+	Argon2Time, err = strconv.Atoi(os.Getenv("ARGON2_TIME"))
+	if err != nil {
+		panic(err)
+	}
+
+	Argon2Memory, err = strconv.Atoi(os.Getenv("ARGON2_MEMORY"))
+	if err != nil {
+		panic(err)
+	}
+
+	Argon2Threads, err = strconv.Atoi(os.Getenv("ARGON2_THREADS"))
+	if err != nil {
+		panic(err)
+	}
+
+	BcryptCost, err = strconv.Atoi(os.Getenv("BCRYPT_COST"))
+	if err != nil {
+		panic(err)
+	}
+
+	FirebaseSignerKey = os.Getenv("FIREBASE_SIGNER_KEY")
 
 	MinioEndpoint = os.Getenv("MINIO_ENDPOINT")
 	MinioBuckets = os.Getenv("MINIO_BUCKETS")
+	MinioExportBucket = os.Getenv("MINIO_EXPORT_BUCKET")
 	MinioAccessKeyID = os.Getenv("MINIO_ACCESS_KEY_ID")
 	MinioSecretAccessKey = os.Getenv("MINIO_SECRET_ACCESS_KEY")
 
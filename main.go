@@ -4,6 +4,7 @@ import (
 	"os"
 	"strings"
 
+	"github.com/thedevsir/frame-backend/app/job"
 	"github.com/thedevsir/frame-backend/config"
 	"github.com/thedevsir/frame-backend/config/database"
 
@@ -12,7 +13,14 @@ import (
 	"github.com/thedevsir/frame-backend/config/mail"
 	_ "github.com/thedevsir/frame-backend/docs"
 	"github.com/thedevsir/frame-backend/routes"
+	"github.com/thedevsir/frame-backend/services/encrypt"
+	"github.com/thedevsir/frame-backend/services/geoip"
+	"github.com/thedevsir/frame-backend/services/keyring"
+	"github.com/thedevsir/frame-backend/services/password"
+	"github.com/thedevsir/frame-backend/services/ratelimit"
+	"github.com/thedevsir/frame-backend/services/social"
 	"github.com/thedevsir/frame-backend/services/storage"
+	"github.com/thedevsir/frame-backend/services/usernames"
 	"github.com/thedevsir/frame-backend/services/validation"
 	validator "gopkg.in/go-playground/validator.v9"
 )
@@ -28,8 +36,16 @@ func init() {
 		Source:   config.DBSource,
 	}
 	db.Shoot()
+	encrypt.Composer()
 	storage.Composer()
 	mail.Composer()
+	keyring.Composer()
+	password.Composer()
+	usernames.Composer()
+	social.Composer()
+	ratelimit.Composer()
+	geoip.Composer()
+	job.Composer()
 }
 
 // @title Frame
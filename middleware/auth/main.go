@@ -1,18 +1,91 @@
 package auth
 
 import (
+	"strings"
+
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+	"github.com/thedevsir/frame-backend/app/model"
 	"github.com/thedevsir/frame-backend/app/repository"
+	"github.com/thedevsir/frame-backend/services/errors"
+	j "github.com/thedevsir/frame-backend/services/jwt"
+	"github.com/thedevsir/frame-backend/services/keyring"
+	"github.com/thedevsir/frame-backend/services/request"
+	"gopkg.in/mgo.v2/bson"
 )
 
+// routeScopes names the scope a personal access token needs for a route,
+// the routes missing from it take a session.
+var routeScopes = map[string]string{}
+
+// Scoped lets personal access tokens holding scope call the route.
+func Scoped(route *echo.Route, scope string) *echo.Route {
+
+	routeScopes[route.Method+" "+route.Path] = scope
+	return route
+}
+
+func personalToken(c echo.Context) (string, bool) {
+
+	value := strings.TrimPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	return value, strings.HasPrefix(value, model.PersonalTokenPrefix)
+}
+
+// JWT verifies the bearer token against the keys of the ring and stores it
+// as "user", the way the echo JWT middleware does for a single secret.
+// Personal access tokens are left to Middleware.
+func JWT(ring *keyring.Ring) echo.MiddlewareFunc {
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+
+		return func(c echo.Context) error {
+
+			if _, ok := personalToken(c); ok {
+				return next(c)
+			}
+
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			if !strings.HasPrefix(header, "Bearer ") {
+				return middleware.ErrJWTMissing
+			}
+
+			token, err := j.ParseSignedJWT(header, ring)
+			if err != nil {
+				return &echo.HTTPError{
+					Code:     middleware.ErrJWTInvalid.Code,
+					Message:  middleware.ErrJWTInvalid.Message,
+					Internal: err,
+				}
+			}
+
+			c.Set("user", token)
+			return next(c)
+		}
+	}
+}
+
+// Middleware accepts a session JWT, or a personal access token that grants
+// the scope of the route.
 func Middleware(next echo.HandlerFunc) echo.HandlerFunc {
 
 	return func(c echo.Context) error {
 
-		user := c.Get("user").(*jwt.Token)
+		if value, ok := personalToken(c); ok {
+			return personalTokenMiddleware(c, value, next)
+		}
+
+		user, ok := c.Get("user").(*jwt.Token)
+		if !ok {
+			return middleware.ErrJWTMissing
+		}
+
 		claims := user.Claims.(jwt.MapClaims)
-		SID, session := claims["sid"].(string), claims["session"].(string)
+		SID, okSID := claims["sid"].(string)
+		session, okSession := claims["session"].(string)
+		if !okSID || !okSession || !bson.IsObjectIdHex(SID) {
+			return middleware.ErrJWTInvalid
+		}
 
 		if err := repository.SessionFindByCredentials(session, SID); err != nil {
 			return err
@@ -26,13 +99,47 @@ func Middleware(next echo.HandlerFunc) echo.HandlerFunc {
 	}
 }
 
+func personalTokenMiddleware(c echo.Context, value string, next echo.HandlerFunc) error {
+
+	scope, ok := routeScopes[c.Request().Method+" "+c.Path()]
+	if !ok {
+		return errors.ErrScopeInsufficient
+	}
+
+	token, err := repository.UsePersonalToken(value, request.ClientIP(c))
+	if err != nil {
+		return err
+	}
+
+	if _, err = repository.GetUserByID(token.UserID); err == errors.ErrUserNotFound {
+		return errors.ErrPersonalTokenInvalid
+	} else if err != nil {
+		return err
+	}
+
+	c.Set("personalToken", token)
+	if !request.AuthenticatedUser(c).HasScope(scope) {
+		return errors.ErrScopeInsufficient
+	}
+
+	return next(c)
+}
+
 func AdminMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 
 	return func(c echo.Context) error {
 
-		user := c.Get("user").(*jwt.Token)
+		user, ok := c.Get("user").(*jwt.Token)
+		if !ok {
+			return middleware.ErrJWTMissing
+		}
+
 		claims := user.Claims.(jwt.MapClaims)
-		adminID, session := claims["userId"].(string), claims["session"].(string)
+		adminID, okAdminID := claims["userId"].(string)
+		session, okSession := claims["session"].(string)
+		if !okAdminID || !okSession || !bson.IsObjectIdHex(adminID) {
+			return middleware.ErrJWTInvalid
+		}
 
 		if err := repository.CheckAdminSession(adminID, session); err != nil {
 			return err
@@ -0,0 +1,42 @@
+package rateLimit
+
+import (
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/ratelimit"
+	"github.com/thedevsir/frame-backend/services/request"
+)
+
+// Policy limits the route by the named policy, per signed in user behind
+// the auth middleware and per address otherwise. Routes sharing a policy
+// share its limit.
+func Policy(name string) echo.MiddlewareFunc {
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+
+		return func(c echo.Context) error {
+
+			policy, ok := ratelimit.Policies[name]
+			if !ok {
+				return next(c)
+			}
+
+			key := name + ":ip:" + request.ClientIP(c)
+			if c.Get("user") != nil || c.Get("personalToken") != nil {
+				key = name + ":user:" + request.AuthenticatedUser(c).ID
+			}
+
+			wait, err := ratelimit.Store.Take(key, policy)
+			if err != nil {
+				return err
+			}
+
+			if wait > 0 {
+				c.Response().Header().Set("Retry-After", ratelimit.RetryAfter(wait))
+				return errors.ErrTooManyRequests
+			}
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/matcornic/hermes"
+	"github.com/thedevsir/frame-backend/config"
+)
+
+type AccountDeletion struct {
+	Username     string
+	EmailAddress string
+	Token        string
+	DeleteAt     time.Time
+}
+
+func (a *AccountDeletion) Name() string {
+	return "accountDeletion"
+}
+
+func (a *AccountDeletion) Email() hermes.Email {
+	return hermes.Email{
+		Body: hermes.Body{
+			Name: a.Username,
+			Intros: []string{
+				"Your " + config.EmailAppName + " account was closed and will be deleted with all of its data on " + a.DeleteAt.UTC().Format("January 2, 2006") + ".",
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Changed your mind? Restore your account before then:",
+					Button: hermes.Button{
+						Text: "Restore my account",
+						Link: fmt.Sprintf(config.EmailRestoreLink, a.Token),
+					},
+				},
+			},
+			Outros: []string{
+				"If you did not close your account, restore it and change your password.",
+			},
+			Signature: "Thanks",
+		},
+	}
+}
@@ -0,0 +1,44 @@
+package mail
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/matcornic/hermes"
+	"github.com/thedevsir/frame-backend/config"
+)
+
+type AccountLocked struct {
+	Username     string
+	EmailAddress string
+	Token        string
+	LockedUntil  time.Time
+}
+
+func (a *AccountLocked) Name() string {
+	return "accountLocked"
+}
+
+func (a *AccountLocked) Email() hermes.Email {
+	return hermes.Email{
+		Body: hermes.Body{
+			Name: a.Username,
+			Intros: []string{
+				"Your " + config.EmailAppName + " account was locked after too many failed sign in attempts. Signing in is possible again after " + a.LockedUntil.UTC().Format("January 2, 2006 15:04 MST") + ", longer if the attempts go on.",
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "If it was you, unlock your account now:",
+					Button: hermes.Button{
+						Text: "Unlock my account",
+						Link: fmt.Sprintf(config.EmailUnlockLink, a.Token),
+					},
+				},
+			},
+			Outros: []string{
+				"If it was not you, someone may be guessing your password. Consider changing it and enabling two-factor authentication.",
+			},
+			Signature: "Thanks",
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"fmt"
+
+	"github.com/matcornic/hermes"
+	"github.com/thedevsir/frame-backend/config"
+)
+
+type ConfirmEmail struct {
+	Username     string
+	EmailAddress string
+	Token        string
+}
+
+func (c *ConfirmEmail) Name() string {
+	return "confirmEmail"
+}
+
+func (c *ConfirmEmail) Email() hermes.Email {
+	return hermes.Email{
+		Body: hermes.Body{
+			Name: c.Username,
+			Intros: []string{
+				"You have received this email because \"" + c.EmailAddress + "\" was set as the new email address of your " + config.EmailAppName + " account.",
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Click the button below to confirm it, your account keeps its current address until you do:",
+					Button: hermes.Button{
+						Text: "Confirm email address",
+						Link: fmt.Sprintf(config.EmailConfirmEmailLink, c.Token),
+					},
+				},
+			},
+			Outros: []string{
+				"If you did not change your email address, no further action is required on your part.",
+			},
+			Signature: "Thanks",
+		},
+	}
+}
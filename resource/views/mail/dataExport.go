@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"time"
+
+	"github.com/matcornic/hermes"
+	"github.com/thedevsir/frame-backend/config"
+)
+
+type DataExport struct {
+	Username     string
+	EmailAddress string
+	Link         string
+	ExpireAt     time.Time
+}
+
+func (d *DataExport) Name() string {
+	return "dataExport"
+}
+
+func (d *DataExport) Email() hermes.Email {
+	return hermes.Email{
+		Body: hermes.Body{
+			Name: d.Username,
+			Intros: []string{
+				"The export of your " + config.EmailAppName + " data is ready.",
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "The link works until " + d.ExpireAt.UTC().Format("January 2, 2006 15:04 MST") + ":",
+					Button: hermes.Button{
+						Text: "Download my data",
+						Link: d.Link,
+					},
+				},
+			},
+			Outros: []string{
+				"If you did not ask for your data, change your password.",
+			},
+			Signature: "Thanks",
+		},
+	}
+}
@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"fmt"
+
+	"github.com/matcornic/hermes"
+	"github.com/thedevsir/frame-backend/config"
+)
+
+type EmailChanging struct {
+	Username     string
+	EmailAddress string
+	NewEmail     string
+	Token        string
+}
+
+func (e *EmailChanging) Name() string {
+	return "emailChanging"
+}
+
+func (e *EmailChanging) Email() hermes.Email {
+	return hermes.Email{
+		Body: hermes.Body{
+			Name: e.Username,
+			Intros: []string{
+				"The email address of your " + config.EmailAppName + " account is being changed to \"" + e.NewEmail + "\", it switches once the new address is confirmed.",
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "If you did not ask for this, cancel the change and change your password:",
+					Button: hermes.Button{
+						Color: "#DC4D2F",
+						Text:  "Cancel the change",
+						Link:  fmt.Sprintf(config.EmailCancelEmailLink, e.Token),
+					},
+				},
+			},
+			Signature: "Thanks",
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"fmt"
+
+	"github.com/matcornic/hermes"
+	"github.com/thedevsir/frame-backend/config"
+)
+
+type Login struct {
+	Username     string
+	EmailAddress string
+	Token        string
+}
+
+func (l *Login) Name() string {
+	return "login"
+}
+
+func (l *Login) Email() hermes.Email {
+	return hermes.Email{
+		Body: hermes.Body{
+			Name: l.Username,
+			Intros: []string{
+				"You have received this email because a sign in link for \"" + l.EmailAddress + "\" account was requested.",
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Click the button below to sign in to " + config.EmailAppName + ", the link works once and expires in " + config.MagicLinkLifetime.String() + ":",
+					Button: hermes.Button{
+						Text: "Sign in",
+						Link: fmt.Sprintf(config.EmailLoginLink, l.Token),
+					},
+				},
+			},
+			Outros: []string{
+				"If you did not request a sign in link, no further action is required on your part.",
+			},
+			Signature: "Thanks",
+		},
+	}
+}
@@ -2,6 +2,7 @@ package mail
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thedevsir/frame-backend/services/utils"
@@ -30,4 +31,81 @@ func TestTemplates(t *testing.T) {
 		assert.Equal(t, "reset", resetBody.Name())
 		assert.NotPanics(t, func() { GenerateTemplate(resetBody.Email()) })
 	})
+
+	t.Run("Login", func(t *testing.T) {
+		loginBody := Login{
+			Username:     "fakeUser",
+			EmailAddress: "fakeEmail",
+			Token:        "fakeToken",
+		}
+		assert.Equal(t, "login", loginBody.Name())
+		assert.NotPanics(t, func() { GenerateTemplate(loginBody.Email()) })
+	})
+
+	t.Run("ConfirmEmail", func(t *testing.T) {
+		confirmBody := ConfirmEmail{
+			Username:     "fakeUser",
+			EmailAddress: "fakeEmail",
+			Token:        "fakeToken",
+		}
+		assert.Equal(t, "confirmEmail", confirmBody.Name())
+		assert.NotPanics(t, func() { GenerateTemplate(confirmBody.Email()) })
+	})
+
+	t.Run("EmailChanging", func(t *testing.T) {
+		changingBody := EmailChanging{
+			Username:     "fakeUser",
+			EmailAddress: "fakeEmail",
+			NewEmail:     "fakeNewEmail",
+			Token:        "fakeToken",
+		}
+		assert.Equal(t, "emailChanging", changingBody.Name())
+		assert.NotPanics(t, func() { GenerateTemplate(changingBody.Email()) })
+	})
+
+	t.Run("AccountDeletion", func(t *testing.T) {
+		deletionBody := AccountDeletion{
+			Username:     "fakeUser",
+			EmailAddress: "fakeEmail",
+			Token:        "fakeToken",
+			DeleteAt:     time.Now(),
+		}
+		assert.Equal(t, "accountDeletion", deletionBody.Name())
+		assert.NotPanics(t, func() { GenerateTemplate(deletionBody.Email()) })
+	})
+
+	t.Run("DataExport", func(t *testing.T) {
+		exportBody := DataExport{
+			Username:     "fakeUser",
+			EmailAddress: "fakeEmail",
+			Link:         "fakeLink",
+			ExpireAt:     time.Now(),
+		}
+		assert.Equal(t, "dataExport", exportBody.Name())
+		assert.NotPanics(t, func() { GenerateTemplate(exportBody.Email()) })
+	})
+
+	t.Run("AccountLocked", func(t *testing.T) {
+		lockedBody := AccountLocked{
+			Username:     "fakeUser",
+			EmailAddress: "fakeEmail",
+			Token:        "fakeToken",
+			LockedUntil:  time.Now(),
+		}
+		assert.Equal(t, "accountLocked", lockedBody.Name())
+		assert.NotPanics(t, func() { GenerateTemplate(lockedBody.Email()) })
+	})
+
+	t.Run("SigninAlert", func(t *testing.T) {
+		alertBody := SigninAlert{
+			Username:     "fakeUser",
+			EmailAddress: "fakeEmail",
+			Token:        "fakeToken",
+			Device:       "fakeDevice",
+			Location:     "fakeLocation",
+			SignedInAt:   time.Now(),
+		}
+		assert.Equal(t, "signinAlert", alertBody.Name())
+		assert.NotPanics(t, func() { GenerateTemplate(alertBody.Email()) })
+	})
 }
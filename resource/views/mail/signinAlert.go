@@ -0,0 +1,49 @@
+package mail
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/matcornic/hermes"
+	"github.com/thedevsir/frame-backend/config"
+)
+
+type SigninAlert struct {
+	Username     string
+	EmailAddress string
+	Token        string
+	Device       string
+	Location     string
+	SignedInAt   time.Time
+}
+
+func (s *SigninAlert) Name() string {
+	return "signinAlert"
+}
+
+func (s *SigninAlert) Email() hermes.Email {
+	return hermes.Email{
+		Body: hermes.Body{
+			Name: s.Username,
+			Intros: []string{
+				"There was a new sign in to your " + config.EmailAppName + " account from a device it was not used on before.",
+			},
+			Dictionary: []hermes.Entry{
+				{Key: "Device", Value: s.Device},
+				{Key: "Location", Value: s.Location},
+				{Key: "Time", Value: s.SignedInAt.UTC().Format("January 2, 2006 15:04 MST")},
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "If it was you, there is nothing to do. If it was not, sign that device out and reset your password:",
+					Button: hermes.Button{
+						Color: "#DC4D2F",
+						Text:  "This wasn't me",
+						Link:  fmt.Sprintf(config.EmailSigninAlertLink, s.Token),
+					},
+				},
+			},
+			Signature: "Thanks",
+		},
+	}
+}
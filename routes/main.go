@@ -2,12 +2,15 @@ package routes
 
 import (
 	"github.com/labstack/echo"
-	"github.com/labstack/echo/middleware"
 	"github.com/swaggo/echo-swagger"
 	c "github.com/thedevsir/frame-backend/app/controller"
+	"github.com/thedevsir/frame-backend/app/model"
 	"github.com/thedevsir/frame-backend/config"
 	"github.com/thedevsir/frame-backend/middleware/auth"
 	"github.com/thedevsir/frame-backend/middleware/objectId"
+	"github.com/thedevsir/frame-backend/middleware/rateLimit"
+	"github.com/thedevsir/frame-backend/services/keyring"
+	"github.com/thedevsir/frame-backend/services/ratelimit"
 	"github.com/thedevsir/frame-backend/services/response"
 )
 
@@ -20,42 +23,101 @@ func Composer() *echo.Echo {
 		User := endpoints.Group("/users")
 		{
 			User.GET("/get/:id", c.GetUser).Name = "client get-user"
-			User.POST("/signup", c.Signup).Name = "client new-user"
-			User.POST("/signup/resend", c.Resend).Name = "client send-verification-email"
+			User.GET("/jwks", c.TokenKeys).Name = "client get-token-keys"
+			User.POST("/signup", c.Signup, rateLimit.Policy(ratelimit.Signup)).Name = "client new-user"
+			User.POST("/signup/resend", c.Resend, rateLimit.Policy(ratelimit.Resend)).Name = "client send-verification-email"
 			User.POST("/signup/verification", c.Verification).Name = "client check-verification-token"
-			User.POST("/signin", c.Signin).Name = "client let-user-in"
-			User.POST("/signin/forgot", c.Forgot).Name = "client forgot-password"
+			User.POST("/signin", c.Signin, rateLimit.Policy(ratelimit.Signin)).Name = "client let-user-in"
+			User.POST("/signin/refresh", c.RefreshSession).Name = "client refresh-session"
+			User.POST("/signin/2fa", c.SigninTwoFactor, rateLimit.Policy(ratelimit.Signin)).Name = "client check-two-factor"
+			User.POST("/signin/passkey/begin", c.BeginPasskeySignin).Name = "client begin-passkey-signin"
+			User.POST("/signin/passkey", c.SigninPasskey).Name = "client check-passkey"
+			User.POST("/signin/social/:provider/begin", c.BeginSocialSignin).Name = "client begin-social-signin"
+			User.POST("/signin/social/:provider", c.SigninSocial).Name = "client check-social-signin"
+			User.POST("/signin/magic", c.SendMagicLink).Name = "client send-magic-link"
+			User.POST("/signin/magic/verify", c.SigninMagicLink).Name = "client check-magic-link"
+			User.POST("/signin/forgot", c.Forgot, rateLimit.Policy(ratelimit.Forgot)).Name = "client forgot-password"
 			User.PUT("/signin/reset", c.Reset).Name = "client reset-password"
+			User.POST("/signin/unlock", c.UnlockAccount).Name = "client unlock-account"
+			User.POST("/signin/deny", c.DenySignin).Name = "client deny-signin"
+			User.POST("/email/confirm", c.ConfirmEmailChange).Name = "client confirm-email-change"
+			User.POST("/email/cancel", c.CancelEmailChange).Name = "client cancel-email-change"
+			User.POST("/account/restore", c.RestoreAccount).Name = "client restore-account"
 			{
 				Auth := User.Group("/auth")
-				Auth.Use(middleware.JWT([]byte(config.SigningKey)))
+				Auth.Use(auth.JWT(keyring.User))
 				Auth.Use(auth.Middleware)
-				Auth.GET("/mine", c.GetAccount).Name = "client get-account"
-				Auth.PUT("/username", c.ChangeUsername).Name = "client change-username"
+				auth.Scoped(Auth.GET("/mine", c.GetAccount), model.ScopeAccountRead).Name = "client get-account"
+				auth.Scoped(Auth.PUT("/username", c.ChangeUsername), model.ScopeAccountWrite).Name = "client change-username"
 				Auth.PUT("/email", c.ChangeEmail).Name = "client change-email"
 				Auth.PUT("/password", c.ChangePassword).Name = "client change-password"
-				Auth.PUT("/avatar", c.PutAvatar).Name = "client put-avatar"
-				Auth.DELETE("/avatar", c.DeleteAvatar).Name = "client delete-avatar"
-				Auth.GET("/sessions", c.Sessions).Name = "client get-sessions"
+				auth.Scoped(Auth.PUT("/avatar", c.PutAvatar, rateLimit.Policy(ratelimit.Avatar)), model.ScopeAccountWrite).Name = "client put-avatar"
+				auth.Scoped(Auth.DELETE("/avatar", c.DeleteAvatar), model.ScopeAccountWrite).Name = "client delete-avatar"
+				auth.Scoped(Auth.GET("/sessions", c.Sessions), model.ScopeAccountRead).Name = "client get-sessions"
+				Auth.PUT("/sessions/:id", c.LabelSession).Name = "client label-session"
 				Auth.DELETE("/signout", c.Signout).Name = "client delete-session"
+				Auth.DELETE("/signout/others", c.SignoutOthers).Name = "client delete-other-sessions"
+				Auth.DELETE("/signout/all", c.SignoutEverywhere).Name = "client delete-all-sessions"
+				Auth.POST("/2fa", c.EnrollTwoFactor).Name = "client enroll-two-factor"
+				Auth.PUT("/2fa", c.ConfirmTwoFactor).Name = "client confirm-two-factor"
+				Auth.DELETE("/2fa", c.DisableTwoFactor).Name = "client disable-two-factor"
+				Auth.GET("/2fa/recovery", c.RecoveryCodes).Name = "client get-recovery-codes"
+				Auth.POST("/2fa/recovery", c.RegenerateRecoveryCodes).Name = "client regenerate-recovery-codes"
+				Auth.POST("/passkeys/register", c.BeginPasskeyRegistration).Name = "client begin-passkey-registration"
+				Auth.POST("/passkeys", c.RegisterPasskey).Name = "client register-passkey"
+				auth.Scoped(Auth.GET("/passkeys", c.Passkeys), model.ScopeAccountRead).Name = "client get-passkeys"
+				Auth.DELETE("/passkeys/:id", c.RemovePasskey).Name = "client remove-passkey"
+				auth.Scoped(Auth.GET("/oauth/consents", c.OAuthConsents), model.ScopeAccountRead).Name = "client get-oauth-consents"
+				Auth.DELETE("/oauth/consents/:clientId", c.RevokeOAuthConsent).Name = "client revoke-oauth-consent"
+				auth.Scoped(Auth.GET("/social", c.SocialIdentities), model.ScopeAccountRead).Name = "client get-social-identities"
+				Auth.POST("/social/:provider/begin", c.BeginSocialLink).Name = "client begin-social-link"
+				Auth.POST("/social/:provider", c.LinkSocial).Name = "client link-social"
+				Auth.DELETE("/social/:provider", c.UnlinkSocial).Name = "client unlink-social"
+				Auth.DELETE("/account", c.DeleteAccount).Name = "client delete-account"
+				auth.Scoped(Auth.POST("/export", c.RequestDataExport), model.ScopeAccountExport).Name = "client request-data-export"
+				Auth.POST("/tokens", c.CreatePersonalToken).Name = "client create-personal-token"
+				Auth.GET("/tokens", c.PersonalTokens).Name = "client get-personal-tokens"
+				Auth.DELETE("/tokens/:id", c.RevokePersonalToken).Name = "client revoke-personal-token"
+			}
+		}
+		endpoints.GET("/.well-known/openid-configuration", c.OpenIDConfiguration).Name = "oidc configuration"
+		OAuth := endpoints.Group("/oauth")
+		{
+			OAuth.POST("/token", c.Token).Name = "oauth token"
+			OAuth.POST("/introspect", c.Introspect).Name = "oauth introspect"
+			OAuth.POST("/revoke", c.Revoke).Name = "oauth revoke"
+			OAuth.GET("/userinfo", c.UserInfo).Name = "oauth userinfo"
+			OAuth.POST("/userinfo", c.UserInfo).Name = "oauth post-userinfo"
+			OAuth.GET("/jwks", c.JWKS).Name = "oauth jwks"
+			{
+				Auth := OAuth.Group("/authorize")
+				Auth.Use(auth.JWT(keyring.User))
+				Auth.Use(auth.Middleware)
+				Auth.GET("", c.Authorize).Name = "oauth authorize"
+				Auth.POST("", c.ApproveAuthorization).Name = "oauth approve-authorization"
 			}
 		}
 		Admin := endpoints.Group("/admin")
 		{
 			Admin.POST("/signin", c.AdminSignin).Name = "admin let-admin-in"
 			Auth := Admin.Group("/auth")
-			Auth.Use(middleware.JWT([]byte(config.AdminSigningKey)))
+			Auth.Use(auth.JWT(keyring.Admin))
 			Auth.Use(auth.AdminMiddleware)
 			User := Auth.Group("/users")
 			{
 				User.GET("/get/all", c.AdminGetAllUsers).Name = "admin get-users"
 				User.GET("/get/:id", c.AdminGetUser).Name = "admin get-user"
+				User.GET("/sessions/:id", c.AdminGetUserSessions).Name = "admin get-user-sessions"
 				User.PUT("/status/:id", c.AdminChangeUserStatus).Name = "admin change-user-status"
 				User.PUT("/email/:id", c.AdminChnageEmail).Name = "admin change-email"
 				User.PUT("/username/:id", c.AdminChangeUsername).Name = "admin change-username"
 				User.PUT("/password/:id", c.AdminChangePassword).Name = "admin change-password"
 				User.PUT("/avatar/:id", c.AdminPutAvatar).Name = "admin put-avatar"
 				User.DELETE("/avatar/:id", c.AdminDeleteAvatar).Name = "admin delete-avatar"
+				User.POST("/export/:id", c.AdminRequestDataExport).Name = "admin request-data-export"
+				User.GET("/exports/:id", c.AdminGetDataExport).Name = "admin get-data-export"
+				User.GET("/lock/:id", c.AdminGetAccountLock).Name = "admin get-account-lock"
+				User.DELETE("/lock/:id", c.AdminUnlockAccount).Name = "admin unlock-account"
 			}
 			AdminManage := Auth.Group("/admin-manage")
 			{
@@ -66,6 +128,14 @@ func Composer() *echo.Echo {
 				AdminManage.PUT("/username/:id", c.ChangeAdminUsername).Name = "admin update-admin"
 				AdminManage.PUT("/password/:id", c.ChangeAdminPassword).Name = "admin update-admin-password"
 			}
+			OAuthClient := Auth.Group("/oauth-clients")
+			{
+				OAuthClient.GET("/get/all", c.AdminGetOAuthClients).Name = "admin get-oauth-clients"
+				OAuthClient.GET("/get/:id", c.AdminGetOAuthClient).Name = "admin get-oauth-client"
+				OAuthClient.POST("/create", c.AdminCreateOAuthClient).Name = "admin new-oauth-client"
+				OAuthClient.PUT("/status/:id", c.AdminChangeOAuthClientStatus).Name = "admin change-oauth-client-status"
+				OAuthClient.DELETE("/:id", c.AdminRemoveOAuthClient).Name = "admin remove-oauth-client"
+			}
 			Auth.DELETE("/signout", c.AdminSignout).Name = "admin delete-session"
 		}
 	}
@@ -3,6 +3,7 @@ package auth
 import (
 	"github.com/dgrijalva/jwt-go"
 	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/keyring"
 )
 
 type (
@@ -17,24 +18,92 @@ type (
 		ID      string `json:"userId"`
 		jwt.StandardClaims
 	}
+	MFAToken struct {
+		Action   string `json:"action"`
+		ID       string `json:"userId"`
+		Username string `json:"username"`
+		Remember bool   `json:"remember,omitempty"`
+		jwt.StandardClaims
+	}
+	PasskeyToken struct {
+		Action    string `json:"action"`
+		ID        string `json:"userId,omitempty"`
+		Challenge string `json:"challenge"`
+		jwt.StandardClaims
+	}
+	SocialToken struct {
+		Action   string `json:"action"`
+		ID       string `json:"userId,omitempty"`
+		Provider string `json:"provider"`
+		State    string `json:"state"`
+		Verifier string `json:"verifier"`
+		jwt.StandardClaims
+	}
+	IDToken struct {
+		AuthTime int64  `json:"auth_time,omitempty"`
+		Nonce    string `json:"nonce,omitempty"`
+		jwt.StandardClaims
+	}
 )
 
-func (j *UserToken) Create(signingKey []byte) (string, error) {
+// Create signs the token with the active key of the ring, tokens signed
+// by an older key stay valid until that key is retired.
+func (j *UserToken) Create(ring *keyring.Ring) (string, error) {
+
+	tokenString, err := ring.Sign(j)
+	if err != nil {
+		return "", errors.ErrInternal
+	}
+	return "Bearer " + tokenString, nil
+}
+
+func (j *AdminToken) Create(ring *keyring.Ring) (string, error) {
+
+	tokenString, err := ring.Sign(j)
+	if err != nil {
+		return "", errors.ErrInternal
+	}
+	return "Bearer " + tokenString, nil
+}
+
+func (j *MFAToken) Create(signingKey []byte) (string, error) {
 
+	j.Action = "mfa"
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, j)
 	tokenString, err := token.SignedString(signingKey)
 	if err != nil {
 		return "", errors.ErrInternal
 	}
-	return "Bearer " + tokenString, nil
+	return tokenString, nil
 }
 
-func (j *AdminToken) Create(signingKey []byte) (string, error) {
+func (j *PasskeyToken) Create(signingKey []byte) (string, error) {
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, j)
 	tokenString, err := token.SignedString(signingKey)
 	if err != nil {
 		return "", errors.ErrInternal
 	}
-	return "Bearer " + tokenString, nil
+	return tokenString, nil
+}
+
+func (j *SocialToken) Create(signingKey []byte) (string, error) {
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, j)
+	tokenString, err := token.SignedString(signingKey)
+	if err != nil {
+		return "", errors.ErrInternal
+	}
+	return tokenString, nil
+}
+
+// Create signs the ID token with the active key of the ring, relying parties
+// verify it with the published JWKS instead of a shared secret.
+func (j *IDToken) Create(ring *keyring.Ring) (string, error) {
+
+	tokenString, err := ring.Sign(j)
+	if err != nil {
+		return "", errors.ErrInternal
+	}
+	return tokenString, nil
 }
@@ -1,14 +1,19 @@
 package auth
 
 import (
+	"strings"
 	"testing"
 
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/services/keyring"
 )
 
 func TestJWTUserToken(t *testing.T) {
 
+	key, _ := keyring.GenerateKey(keyring.ES256)
+	ring := keyring.NewRing(key)
+
 	t.Run("UserToken", func(t *testing.T) {
 		composer := &UserToken{
 			"session",
@@ -16,7 +21,11 @@ func TestJWTUserToken(t *testing.T) {
 			"ID",
 			jwt.StandardClaims{},
 		}
-		assert.NotPanics(t, func() { composer.Create([]byte("secret")) })
+		token, err := composer.Create(ring)
+		if assert.Nil(t, err) {
+			_, err = ring.Parse(strings.TrimPrefix(token, "Bearer "), &jwt.MapClaims{})
+			assert.Nil(t, err)
+		}
 	})
 
 	t.Run("AdminToken", func(t *testing.T) {
@@ -25,6 +34,59 @@ func TestJWTUserToken(t *testing.T) {
 			"ID",
 			jwt.StandardClaims{},
 		}
-		assert.NotPanics(t, func() { composer.Create([]byte("secret")) })
+		token, err := composer.Create(ring)
+		if assert.Nil(t, err) {
+			_, err = ring.Parse(strings.TrimPrefix(token, "Bearer "), &jwt.MapClaims{})
+			assert.Nil(t, err)
+		}
+	})
+
+	t.Run("MFAToken", func(t *testing.T) {
+		composer := &MFAToken{
+			ID:       "ID",
+			Username: "username",
+		}
+		token, err := composer.Create([]byte("secret"))
+		assert.Nil(t, err)
+		assert.Equal(t, "mfa", composer.Action)
+		assert.NotContains(t, token, "Bearer")
+	})
+
+	t.Run("PasskeyToken", func(t *testing.T) {
+		composer := &PasskeyToken{
+			Action:    "passkey-signin",
+			Challenge: "challenge",
+		}
+		token, err := composer.Create([]byte("secret"))
+		assert.Nil(t, err)
+		assert.NotContains(t, token, "Bearer")
+	})
+
+	t.Run("SocialToken", func(t *testing.T) {
+		composer := &SocialToken{
+			Action:   "social-signin",
+			Provider: "github",
+			State:    "state",
+		}
+		token, err := composer.Create([]byte("secret"))
+		assert.Nil(t, err)
+		assert.NotContains(t, token, "Bearer")
+	})
+
+	t.Run("IDToken", func(t *testing.T) {
+		composer := &IDToken{
+			Nonce: "nonce",
+			StandardClaims: jwt.StandardClaims{
+				Subject: "ID",
+			},
+		}
+		token, err := composer.Create(ring)
+		assert.Nil(t, err)
+
+		claims := &IDToken{}
+		_, err = ring.Parse(token, claims)
+		if assert.Nil(t, err) {
+			assert.Equal(t, "nonce", claims.Nonce)
+		}
 	})
 }
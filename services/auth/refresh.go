@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+
+	"github.com/thedevsir/frame-backend/services/errors"
+)
+
+// A refresh token is "<sid>.<generation>.<mac>". The MAC is keyed with the
+// secret seed of the session, so every token the session ever issued can be
+// recognized without storing it, and an old generation shows up as reuse.
+
+func refreshTokenMAC(seed, SID string, generation int64) string {
+
+	mac := hmac.New(sha256.New, []byte(seed))
+	mac.Write([]byte(SID + "." + strconv.FormatInt(generation, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func NewRefreshToken(seed, SID string, generation int64) string {
+	return SID + "." + strconv.FormatInt(generation, 10) + "." + refreshTokenMAC(seed, SID, generation)
+}
+
+// ParseRefreshToken reads the session and generation of a refresh token, it
+// must still be checked against the seed of the session.
+func ParseRefreshToken(token string) (SID string, generation int64, err error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return "", 0, errors.ErrRefreshTokenInvalid
+	}
+
+	generation, err = strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || generation < 0 {
+		return "", 0, errors.ErrRefreshTokenInvalid
+	}
+
+	return parts[0], generation, nil
+}
+
+func CheckRefreshToken(token, seed string) bool {
+
+	SID, generation, err := ParseRefreshToken(token)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal([]byte(token), []byte(NewRefreshToken(seed, SID, generation)))
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/services/errors"
+)
+
+func TestRefreshToken(t *testing.T) {
+
+	token := NewRefreshToken("seed", "5b4a3c2d1e0f000000000000", 3)
+
+	t.Run("ParseRefreshToken", func(t *testing.T) {
+
+		SID, generation, err := ParseRefreshToken(token)
+		if assert.Nil(t, err) {
+			assert.Equal(t, "5b4a3c2d1e0f000000000000", SID)
+			assert.Equal(t, int64(3), generation)
+		}
+
+		for _, invalid := range []string{"", "sid.1", "sid.x.mac", "sid.-1.mac", ".1.mac"} {
+			_, _, err = ParseRefreshToken(invalid)
+			assert.Equal(t, errors.ErrRefreshTokenInvalid, err)
+		}
+	})
+
+	t.Run("CheckRefreshToken", func(t *testing.T) {
+
+		assert.True(t, CheckRefreshToken(token, "seed"))
+		assert.False(t, CheckRefreshToken(token, "another seed"))
+
+		// the generation is covered by the MAC
+		forged := strings.Replace(token, ".3.", ".4.", 1)
+		assert.False(t, CheckRefreshToken(forged, "seed"))
+	})
+}
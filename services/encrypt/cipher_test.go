@@ -0,0 +1,31 @@
+package encrypt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCipher(t *testing.T) {
+
+	key := []byte("secret")
+	var sealed string
+	var err error
+
+	t.Run("Seal", func(t *testing.T) {
+		sealed, err = Seal("JBSWY3DPEHPK3PXP", key)
+		assert.Nil(t, err)
+		assert.NotEqual(t, "JBSWY3DPEHPK3PXP", sealed)
+	})
+
+	t.Run("Open", func(t *testing.T) {
+		plain, err := Open(sealed, key)
+		assert.Nil(t, err)
+		assert.Equal(t, "JBSWY3DPEHPK3PXP", plain)
+	})
+
+	t.Run("WrongKey", func(t *testing.T) {
+		_, err := Open(sealed, []byte("another"))
+		assert.Error(t, err)
+	})
+}
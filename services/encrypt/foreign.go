@@ -0,0 +1,170 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Users imported from other systems keep the hashes they were exported
+// with until their first sign in, these verifiers only check them.
+
+type (
+	// DjangoPBKDF2 checks pbkdf2_sha256$<iterations>$<salt>$<base64 key>
+	// and pbkdf2_sha1 hashes.
+	DjangoPBKDF2 struct{}
+	// DjangoBcrypt checks bcrypt_sha256$<bcrypt> hashes of the hex SHA-256
+	// of the password and plain bcrypt$<bcrypt> hashes.
+	DjangoBcrypt struct{}
+	// SaltedSHA checks sha1$<salt>$<hex>, sha256$ and sha512$ hashes of the
+	// salt followed by the password.
+	SaltedSHA struct{}
+	// FirebaseScrypt checks firebase-scrypt$<rounds>$<memory cost>$<salt
+	// separator>$<salt>$<hash>, the base64 fields as Firebase exports them.
+	// The signer key is shared by the whole project, so it is configured
+	// instead of stored with every hash.
+	FirebaseScrypt struct {
+		SignerKey []byte
+	}
+)
+
+const firebaseScryptPrefix = "firebase-scrypt$"
+
+var pbkdf2Digests = map[string]func() hash.Hash{
+	"pbkdf2_sha256": sha256.New,
+	"pbkdf2_sha1":   sha1.New,
+}
+
+var saltedDigests = map[string]func() hash.Hash{
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+func (DjangoPBKDF2) Owns(hash string) bool {
+	return pbkdf2Digests[strings.SplitN(hash, "$", 2)[0]] != nil
+}
+
+func (DjangoPBKDF2) Check(password, hash string) bool {
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || pbkdf2Digests[parts[0]] == nil {
+		return false
+	}
+
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false
+	}
+
+	expected, err := base64.StdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	key := pbkdf2.Key([]byte(password), []byte(parts[2]), iterations, len(expected), pbkdf2Digests[parts[0]])
+	return subtle.ConstantTimeCompare(key, expected) == 1
+}
+
+func (DjangoBcrypt) Owns(hash string) bool {
+	return strings.HasPrefix(hash, "bcrypt_sha256$") || strings.HasPrefix(hash, "bcrypt$")
+}
+
+func (DjangoBcrypt) Check(password, hash string) bool {
+
+	if strings.HasPrefix(hash, "bcrypt_sha256$") {
+		sum := sha256.Sum256([]byte(password))
+		password = hex.EncodeToString(sum[:])
+	}
+
+	hash = hash[strings.Index(hash, "$")+1:]
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func (SaltedSHA) Owns(hash string) bool {
+	return saltedDigests[strings.SplitN(hash, "$", 2)[0]] != nil
+}
+
+func (SaltedSHA) Check(password, hash string) bool {
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 3 || saltedDigests[parts[0]] == nil {
+		return false
+	}
+
+	expected, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	digest := saltedDigests[parts[0]]()
+	digest.Write([]byte(parts[1] + password))
+	return subtle.ConstantTimeCompare(digest.Sum(nil), expected) == 1
+}
+
+// FirebaseScryptHash encodes the fields of a Firebase export as a hash
+// FirebaseScrypt owns.
+func FirebaseScryptHash(rounds, memoryCost int, saltSeparator, salt, hash string) string {
+	return fmt.Sprintf("%s%d$%d$%s$%s$%s", firebaseScryptPrefix, rounds, memoryCost, saltSeparator, salt, hash)
+}
+
+func (FirebaseScrypt) Owns(hash string) bool {
+	return strings.HasPrefix(hash, firebaseScryptPrefix)
+}
+
+// Check derives a key with scrypt from the password and the salt followed
+// by the separator, the hash is the signer key encrypted with it in
+// AES-256-CTR mode.
+func (f FirebaseScrypt) Check(password, hash string) bool {
+
+	parts := strings.Split(strings.TrimPrefix(hash, firebaseScryptPrefix), "$")
+	if len(f.SignerKey) == 0 || len(parts) != 5 {
+		return false
+	}
+
+	rounds, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return false
+	}
+
+	memoryCost, err := strconv.Atoi(parts[1])
+	if err != nil || memoryCost < 1 || memoryCost > 30 {
+		return false
+	}
+
+	fields := make([][]byte, 3)
+	for i, field := range parts[2:] {
+		if fields[i], err = base64.StdEncoding.DecodeString(field); err != nil {
+			return false
+		}
+	}
+	separator, salt, expected := fields[0], fields[1], fields[2]
+
+	key, err := scrypt.Key([]byte(password), append(salt, separator...), 1<<uint(memoryCost), rounds, 1, 32)
+	if err != nil {
+		return false
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return false
+	}
+
+	sealed := make([]byte, len(f.SignerKey))
+	cipher.NewCTR(block, make([]byte, aes.BlockSize)).XORKeyStream(sealed, f.SignerKey)
+	return hmac.Equal(sealed, expected)
+}
@@ -0,0 +1,57 @@
+package encrypt
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestForeignHashes(t *testing.T) {
+
+	t.Run("DjangoPBKDF2", func(t *testing.T) {
+		for _, hash := range []string{
+			"pbkdf2_sha256$1000$seasalt$R1/GfWtwog1T8Ev9VndgDjzkiRzbFr8JpmJtL9cMmQU=",
+			"pbkdf2_sha1$1000$seasalt$FR1Hz/2XTwvhcvkmbwDDrzMsEnQ=",
+		} {
+			assert.True(t, CheckHash("letmein", hash))
+			assert.False(t, CheckHash("letmeout", hash))
+			assert.True(t, NeedsRehash(hash))
+		}
+	})
+
+	t.Run("DjangoBcrypt", func(t *testing.T) {
+		// Django hashes the hex SHA-256 of the password
+		sha, _ := bcrypt.GenerateFromPassword([]byte("1c8bfe8f801d79745c4631d09fff36c82aa37fc4cce4fc946683d7b336b63032"), 4)
+		plain, _ := bcrypt.GenerateFromPassword([]byte("letmein"), 4)
+
+		for _, hash := range []string{"bcrypt_sha256$" + string(sha), "bcrypt$" + string(plain)} {
+			assert.True(t, CheckHash("letmein", hash))
+			assert.False(t, CheckHash("letmeout", hash))
+		}
+	})
+
+	t.Run("SaltedSHA", func(t *testing.T) {
+		for _, hash := range []string{
+			"sha1$seasalt$fec3530984afba6bade3347b7140d1a7da7da8c7",
+			"sha512$seasalt$ee17cd3bc801005474cd4b66d6a58e5c2bdf47966f105c760e688032472a5757bcd543162122133f742af42cce818a078fd4f8f63d6aa3e96114250384aab030",
+		} {
+			assert.True(t, CheckHash("letmein", hash))
+			assert.False(t, CheckHash("letmeout", hash))
+		}
+	})
+
+	t.Run("FirebaseScrypt", func(t *testing.T) {
+		// the sample project of https://github.com/firebase/scrypt
+		key, _ := base64.StdEncoding.DecodeString("jxspr8Ki0RYycVU8zykbdLGjFQ3McFUH0uiiTvC8pVMXAn210wjLNmdZJzxUECKbm0QsEmYUSDzZvpjeJ9WmXA==")
+		hash := FirebaseScryptHash(8, 14, "Bw==", "42xEC+ixf3L2lw==", "lSrfV15cpx95/sZS2W9c9Kp6i/LVgQNDNC/qzrCnh1SAyZvqmZqAjTdn3aoItz+VHjoZilo78198JAdRuid5lQ==")
+
+		assert.False(t, CheckHash("user1password", hash))
+
+		defer func(verifiers []Verifier) { Verifiers = verifiers }(Verifiers)
+		Verifiers = append(Verifiers, FirebaseScrypt{SignerKey: key})
+		assert.True(t, CheckHash("user1password", hash))
+		assert.False(t, CheckHash("user2password", hash))
+	})
+}
@@ -0,0 +1,136 @@
+package encrypt
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// A Verifier checks passwords against the hashes of one algorithm, hashes
+// carry their own parameters so they keep verifying after those change.
+type Verifier interface {
+	// Owns reports whether the hash was made by this algorithm.
+	Owns(hash string) bool
+	Check(password, hash string) bool
+}
+
+// A Hasher also makes new hashes.
+type Hasher interface {
+	Verifier
+	Hash(password string) (string, error)
+	// Outdated reports whether a hash it owns was made with other parameters.
+	Outdated(hash string) bool
+}
+
+type (
+	// Argon2id hashes are encoded as $argon2id$v=19$m=<KiB>,t=<passes>,p=<threads>$<salt>$<key>
+	Argon2id struct {
+		Time    uint32
+		Memory  uint32
+		Threads uint8
+	}
+	Bcrypt struct {
+		Cost int
+	}
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var argon2Encoding = base64.RawStdEncoding
+
+type argon2Hash struct {
+	Argon2id
+	salt []byte
+	key  []byte
+}
+
+func (a Argon2id) Hash(password string) (string, error) {
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, argon2KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Time, a.Threads,
+		argon2Encoding.EncodeToString(salt), argon2Encoding.EncodeToString(key),
+	), nil
+}
+
+func (Argon2id) Owns(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func parseArgon2(hash string) (*argon2Hash, bool) {
+
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, false
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, false
+	}
+
+	parsed := &argon2Hash{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &parsed.Memory, &parsed.Time, &parsed.Threads); err != nil {
+		return nil, false
+	}
+
+	var err error
+	if parsed.salt, err = argon2Encoding.DecodeString(parts[4]); err != nil {
+		return nil, false
+	}
+	if parsed.key, err = argon2Encoding.DecodeString(parts[5]); err != nil || len(parsed.key) == 0 {
+		return nil, false
+	}
+
+	return parsed, true
+}
+
+func (Argon2id) Check(password, hash string) bool {
+
+	parsed, ok := parseArgon2(hash)
+	if !ok {
+		return false
+	}
+
+	key := argon2.IDKey([]byte(password), parsed.salt, parsed.Time, parsed.Memory, parsed.Threads, uint32(len(parsed.key)))
+	return subtle.ConstantTimeCompare(key, parsed.key) == 1
+}
+
+func (a Argon2id) Outdated(hash string) bool {
+
+	parsed, ok := parseArgon2(hash)
+	return !ok || parsed.Argon2id != a || len(parsed.key) != argon2KeyLength
+}
+
+func (b Bcrypt) Hash(password string) (string, error) {
+
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+	return string(bytes), err
+}
+
+func (Bcrypt) Owns(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (Bcrypt) Check(password, hash string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func (b Bcrypt) Outdated(hash string) bool {
+
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != b.Cost
+}
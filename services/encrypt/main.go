@@ -1,15 +1,79 @@
 package encrypt
 
 import (
-	"golang.org/x/crypto/bcrypt"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+
+	"github.com/thedevsir/frame-backend/config"
+)
+
+const (
+	HasherArgon2id = "argon2id"
+	HasherBcrypt   = "bcrypt"
+)
+
+var ErrUnknownHasher = errors.New("encrypt: unknown password hasher")
+
+// Default makes every new hash, Verifiers are the algorithms existing
+// hashes may have been made with, imported ones included.
+var (
+	Default   Hasher = Argon2id{Time: 3, Memory: 64 * 1024, Threads: 4}
+	Verifiers        = []Verifier{Argon2id{}, Bcrypt{}, DjangoPBKDF2{}, DjangoBcrypt{}, SaltedSHA{}}
 )
 
+// Composer builds the default hasher from the configured algorithm and
+// costs.
+func Composer() {
+
+	switch config.PasswordHasher {
+	case HasherArgon2id:
+		Default = Argon2id{
+			Time:    uint32(config.Argon2Time),
+			Memory:  uint32(config.Argon2Memory),
+			Threads: uint8(config.Argon2Threads),
+		}
+	case HasherBcrypt:
+		Default = Bcrypt{Cost: config.BcryptCost}
+	default:
+		panic(ErrUnknownHasher)
+	}
+
+	if config.FirebaseSignerKey != "" {
+		key, err := base64.StdEncoding.DecodeString(config.FirebaseSignerKey)
+		if err != nil {
+			panic(err)
+		}
+		Verifiers = append(Verifiers, FirebaseScrypt{SignerKey: key})
+	}
+}
+
 func Hash(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytes), err
+	return Default.Hash(password)
 }
 
 func CheckHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+
+	for _, verifier := range Verifiers {
+		if verifier.Owns(hash) {
+			return verifier.Check(password, hash)
+		}
+	}
+
+	return false
+}
+
+// NeedsRehash reports whether a hash was made with another algorithm or
+// other costs than the default hasher, it is meant to be called after the
+// password was checked, while the plain password is still known.
+func NeedsRehash(hash string) bool {
+	return !Default.Owns(hash) || Default.Outdated(hash)
+}
+
+// Digest is meant for random tokens that have to be looked up by value,
+// bcrypt salts would make that impossible.
+func Digest(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
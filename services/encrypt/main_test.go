@@ -1,12 +1,13 @@
 package encrypt
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestBcrypt(t *testing.T) {
+func TestHash(t *testing.T) {
 
 	var result, passwd = "", "12345"
 	var err error
@@ -14,10 +15,51 @@ func TestBcrypt(t *testing.T) {
 	t.Run("Hash", func(t *testing.T) {
 		result, err = Hash(passwd)
 		assert.Nil(t, err)
+		assert.True(t, strings.HasPrefix(result, "$argon2id$v=19$m=65536,t=3,p=4$"))
 	})
 
 	t.Run("CheckHash", func(t *testing.T) {
-		err := CheckHash(passwd, result)
-		assert.True(t, err)
+		assert.True(t, CheckHash(passwd, result))
+		assert.False(t, CheckHash("54321", result))
+		assert.False(t, CheckHash(passwd, "not a hash"))
 	})
+
+	t.Run("Bcrypt", func(t *testing.T) {
+		hash, err := Bcrypt{Cost: 4}.Hash(passwd)
+		if assert.Nil(t, err) {
+			assert.True(t, CheckHash(passwd, hash))
+			assert.False(t, CheckHash("54321", hash))
+		}
+	})
+
+	t.Run("NeedsRehash", func(t *testing.T) {
+		assert.False(t, NeedsRehash(result))
+
+		bcryptHash, _ := Bcrypt{Cost: 4}.Hash(passwd)
+		assert.True(t, NeedsRehash(bcryptHash))
+
+		weaker, _ := Argon2id{Time: 1, Memory: 8 * 1024, Threads: 1}.Hash(passwd)
+		assert.True(t, NeedsRehash(weaker))
+		assert.True(t, CheckHash(passwd, weaker))
+
+		defer func(hasher Hasher) { Default = hasher }(Default)
+		Default = Bcrypt{Cost: 4}
+		assert.True(t, NeedsRehash(result))
+		assert.False(t, NeedsRehash(bcryptHash))
+	})
+}
+
+func TestArgon2id(t *testing.T) {
+
+	// made by the reference implementation: argon2 somesalt -id -t 2 -m 16 -p 1
+	hash := "$argon2id$v=19$m=65536,t=2,p=1$c29tZXNhbHQ$CTFhFdXPJO1aFaMaO6Mm5c8y7cJHAph8ArZWb2GRPPc"
+	assert.True(t, Argon2id{}.Check("password", hash))
+	assert.False(t, Argon2id{}.Check("Password", hash))
+}
+
+func TestDigest(t *testing.T) {
+
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", Digest(""))
+	assert.Equal(t, Digest("token"), Digest("token"))
+	assert.NotEqual(t, Digest("token"), Digest("Token"))
 }
@@ -0,0 +1,36 @@
+package encrypt
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"math/big"
+)
+
+// Lowercase letters and digits without the easily confused 0, 1, l and o
+const codeAlphabet = "23456789abcdefghijkmnpqrstuvwxyz"
+
+func RandomCode(length int) (string, error) {
+
+	max := big.NewInt(int64(len(codeAlphabet)))
+	code := make([]byte, length)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = codeAlphabet[n.Int64()]
+	}
+
+	return string(code), nil
+}
+
+// RandomToken returns size random bytes encoded as unpadded base64url.
+func RandomToken(size int) (string, error) {
+
+	token := make([]byte, size)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
@@ -0,0 +1,32 @@
+package encrypt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRandomCode(t *testing.T) {
+
+	first, err := RandomCode(10)
+	assert.Nil(t, err)
+	assert.Len(t, first, 10)
+
+	for _, r := range first {
+		assert.True(t, strings.ContainsRune(codeAlphabet, r))
+	}
+
+	second, _ := RandomCode(10)
+	assert.NotEqual(t, first, second)
+}
+
+func TestRandomToken(t *testing.T) {
+
+	first, err := RandomToken(32)
+	assert.Nil(t, err)
+	assert.Len(t, first, 43)
+
+	second, _ := RandomToken(32)
+	assert.NotEqual(t, first, second)
+}
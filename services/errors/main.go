@@ -22,8 +22,46 @@ var (
 	ErrUserNotFound       = echo.NewHTTPError(http.StatusNotFound, "requested user not found")
 	ErrAdminNotFound      = echo.NewHTTPError(http.StatusNotFound, "requested admin not found")
 	ErrUsernameExists     = echo.NewHTTPError(http.StatusConflict, "requested username is already exists")
+	ErrUsernameReserved   = echo.NewHTTPError(http.StatusConflict, "requested username is reserved")
+	ErrUsernameCooldown   = echo.NewHTTPError(http.StatusTooManyRequests, "username was changed recently, try again later")
 	ErrEmailExists        = echo.NewHTTPError(http.StatusConflict, "account with this email is alreay registered")
 	ErrAttemptsReached    = echo.NewHTTPError(http.StatusRequestTimeout, "maximum number of auth attempts reached")
+	ErrAccountLocked      = echo.NewHTTPError(http.StatusTooManyRequests, "too many failed signins, try again later")
+	ErrTooManyRequests    = echo.NewHTTPError(http.StatusTooManyRequests, "too many requests, try again later")
+	ErrAccountNotLocked   = echo.NewHTTPError(http.StatusNotFound, "account is not locked")
 	ErrInvalidCredentials = echo.NewHTTPError(http.StatusForbidden, "credentials are invalid")
 	ErrSessionNotFound    = echo.NewHTTPError(http.StatusNotFound, "session not found")
+	ErrInvalidCode        = echo.NewHTTPError(http.StatusForbidden, "verification code is invalid")
+	ErrTwoFactorEnabled   = echo.NewHTTPError(http.StatusBadRequest, "two-factor authentication is already enabled")
+	ErrTwoFactorDisabled  = echo.NewHTTPError(http.StatusBadRequest, "two-factor authentication is not enabled")
+	ErrTwoFactorNotBegun  = echo.NewHTTPError(http.StatusBadRequest, "two-factor enrollment has not been started")
+	ErrPasskeyInvalid     = echo.NewHTTPError(http.StatusForbidden, "passkey response is not valid")
+	ErrPasskeyNotFound    = echo.NewHTTPError(http.StatusNotFound, "passkey not found")
+	ErrPasskeyExists      = echo.NewHTTPError(http.StatusConflict, "passkey is already registered")
+
+	ErrOAuthClientNotFound  = echo.NewHTTPError(http.StatusNotFound, "oauth client not found")
+	ErrOAuthConsentNotFound = echo.NewHTTPError(http.StatusNotFound, "oauth consent not found")
+	ErrOAuthGrantNotFound   = echo.NewHTTPError(http.StatusNotFound, "oauth grant not found")
+	ErrRedirectURIInvalid   = echo.NewHTTPError(http.StatusBadRequest, "redirect uri is not registered for this client")
+
+	ErrSocialProviderNotFound = echo.NewHTTPError(http.StatusNotFound, "social provider is not configured")
+	ErrSocialSigninFailed     = echo.NewHTTPError(http.StatusForbidden, "social provider could not verify the account")
+	ErrSocialIdentityNotFound = echo.NewHTTPError(http.StatusNotFound, "social account is not linked")
+	ErrSocialIdentityExists   = echo.NewHTTPError(http.StatusConflict, "social account is already linked to a user")
+	ErrSocialProviderLinked   = echo.NewHTTPError(http.StatusConflict, "an account of this provider is already linked")
+	ErrSocialEmailExists      = echo.NewHTTPError(http.StatusConflict, "account with this email exists, sign in to link the provider")
+	ErrSocialEmailRequired    = echo.NewHTTPError(http.StatusBadRequest, "social account has no email address")
+	ErrSocialEmailUnverified  = echo.NewHTTPError(http.StatusForbidden, "verify the email address with the social provider first")
+
+	ErrRefreshTokenInvalid = echo.NewHTTPError(http.StatusUnauthorized, "refresh token is invalid or expired")
+	ErrRefreshTokenReused  = echo.NewHTTPError(http.StatusUnauthorized, "refresh token was already used, the session is revoked")
+	ErrSessionExpired      = echo.NewHTTPError(http.StatusUnauthorized, "session has expired, sign in again")
+
+	ErrDataExportNotFound = echo.NewHTTPError(http.StatusNotFound, "data export not found")
+	ErrDataExportPending  = echo.NewHTTPError(http.StatusConflict, "a data export is already being prepared")
+
+	ErrPersonalTokenNotFound = echo.NewHTTPError(http.StatusNotFound, "personal access token not found")
+	ErrPersonalTokenInvalid  = echo.NewHTTPError(http.StatusUnauthorized, "personal access token is invalid, expired or revoked")
+	ErrScopeInvalid          = echo.NewHTTPError(http.StatusBadRequest, "requested scope can not be granted")
+	ErrScopeInsufficient     = echo.NewHTTPError(http.StatusForbidden, "token does not grant the scope this endpoint needs")
 )
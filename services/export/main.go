@@ -0,0 +1,69 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// extensions of the content types stored for users
+var extensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Archive writes the files of a data export into a zip.
+type Archive struct {
+	zip *zip.Writer
+}
+
+func NewArchive(w io.Writer) *Archive {
+	return &Archive{zip.NewWriter(w)}
+}
+
+// JSON adds v as an indented JSON file.
+func (a *Archive) JSON(name string, v interface{}) error {
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	w, err := a.create(name + ".json")
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// File adds the content of r, named with the extension of its content type.
+func (a *Archive) File(name, contentType string, r io.Reader) error {
+
+	name += extensions[contentType]
+
+	w, err := a.create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func (a *Archive) create(name string) (io.Writer, error) {
+	return a.zip.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+}
+
+// Close finishes the zip, it does not close the underlying writer.
+func (a *Archive) Close() error {
+	return a.zip.Close()
+}
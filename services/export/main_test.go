@@ -0,0 +1,41 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchive(t *testing.T) {
+
+	buf := &bytes.Buffer{}
+	archive := NewArchive(buf)
+
+	assert.NoError(t, archive.JSON("account", map[string]string{"username": "amir"}))
+	assert.NoError(t, archive.File("avatar", "image/png", strings.NewReader("png")))
+	assert.NoError(t, archive.File("notes", "application/x-unknown", strings.NewReader("notes")))
+	assert.NoError(t, archive.Close())
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	files := map[string]string{}
+	for _, f := range reader.File {
+		r, _ := f.Open()
+		data, _ := ioutil.ReadAll(r)
+		r.Close()
+		files[f.Name] = string(data)
+	}
+
+	assert.Equal(t, map[string]string{
+		"account.json": "{\n  \"username\": \"amir\"\n}",
+		"avatar.png":   "png",
+		"notes":        "notes",
+	}, files)
+}
@@ -0,0 +1,81 @@
+package geoip
+
+import (
+	"io/ioutil"
+	"net"
+
+	"github.com/thedevsir/frame-backend/config"
+)
+
+// Location is where an address approximately is, names are in English.
+type Location struct {
+	Country     string
+	CountryCode string
+	City        string
+}
+
+// DB is nil when no database is configured, addresses are not located then.
+var DB *Reader
+
+func Composer() {
+
+	if config.GeoIPDatabase == "" {
+		return
+	}
+
+	file, err := ioutil.ReadFile(config.GeoIPDatabase)
+	if err != nil {
+		panic(err)
+	}
+
+	if DB, err = NewReader(file); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup locates ip with DB, the location is empty when it can not be.
+func Lookup(ip string) Location {
+
+	address := net.ParseIP(ip)
+	if DB == nil || address == nil {
+		return Location{}
+	}
+
+	record, err := DB.Lookup(address)
+	if err != nil {
+		return Location{}
+	}
+
+	return Location{
+		Country:     lookupString(record, "country", "names", "en"),
+		CountryCode: lookupString(record, "country", "iso_code"),
+		City:        lookupString(record, "city", "names", "en"),
+	}
+}
+
+func lookupString(record interface{}, path ...string) string {
+
+	for _, key := range path {
+		values, ok := record.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		record = values[key]
+	}
+
+	value, _ := record.(string)
+	return value
+}
+
+// String names the city and the country, whichever are known.
+func (l Location) String() string {
+
+	switch {
+	case l.City != "" && l.Country != "":
+		return l.City + ", " + l.Country
+	case l.Country != "":
+		return l.Country
+	default:
+		return l.City
+	}
+}
@@ -0,0 +1,153 @@
+package geoip
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// database writes a MaxMind DB with the networks of the records, the
+// records are data section offsets.
+func database(ipVersion, recordSize uint, data []byte, networks map[string]int) []byte {
+
+	const empty = -1
+	nodes := [][2]int{{empty, empty}}
+
+	for network, offset := range networks {
+		_, ipNet, _ := net.ParseCIDR(network)
+		ip := ipNet.IP
+		bits, _ := ipNet.Mask.Size()
+
+		// IPv4 networks go under ::/96
+		if ipVersion == 6 && len(ip) == net.IPv4len {
+			ip = append(make(net.IP, 12), ip...)
+			bits += 96
+		}
+
+		node := 0
+		for i := 0; i < bits; i++ {
+			bit := int(ip[i/8]>>(7-uint(i%8))) & 1
+			if i == bits-1 {
+				nodes[node][bit] = -offset - 2
+				break
+			}
+			if nodes[node][bit] == empty {
+				nodes = append(nodes, [2]int{empty, empty})
+				nodes[node][bit] = len(nodes) - 1
+			}
+			node = nodes[node][bit]
+		}
+	}
+
+	count := uint(len(nodes))
+	value := func(record int) uint {
+		switch {
+		case record == empty:
+			return count
+		case record < 0:
+			return count + dataSeparator + uint(-record-2)
+		default:
+			return uint(record)
+		}
+	}
+
+	file := []byte{}
+	for _, node := range nodes {
+		left, right := value(node[0]), value(node[1])
+		switch recordSize {
+		case 24:
+			file = append(file, byte(left>>16), byte(left>>8), byte(left), byte(right>>16), byte(right>>8), byte(right))
+		case 28:
+			file = append(file, byte(left>>16), byte(left>>8), byte(left), byte(left>>24<<4|right>>24), byte(right>>16), byte(right>>8), byte(right))
+		case 32:
+			file = append(file, byte(left>>24), byte(left>>16), byte(left>>8), byte(left), byte(right>>24), byte(right>>16), byte(right>>8), byte(right))
+		}
+	}
+
+	file = append(file, make([]byte, dataSeparator)...)
+	file = append(file, data...)
+	file = append(file, metadataStart...)
+	file = append(file, encodeMap(
+		"node_count", encodeUint(6, count),
+		"record_size", encodeUint(5, recordSize),
+		"ip_version", encodeUint(5, ipVersion),
+		"database_type", encodeString("GeoLite2-City"),
+	)...)
+
+	return file
+}
+
+func encodeString(value string) []byte {
+	return append([]byte{byte(2<<5 | len(value))}, value...)
+}
+
+func encodeUint(kind byte, value uint) []byte {
+
+	payload := []byte{}
+	for ; value > 0; value >>= 8 {
+		payload = append([]byte{byte(value)}, payload...)
+	}
+
+	return append([]byte{kind<<5 | byte(len(payload))}, payload...)
+}
+
+func encodeMap(pairs ...interface{}) []byte {
+
+	encoded := []byte{byte(7<<5 | len(pairs)/2)}
+	for i := 0; i < len(pairs); i += 2 {
+		encoded = append(encoded, encodeString(pairs[i].(string))...)
+		encoded = append(encoded, pairs[i+1].([]byte)...)
+	}
+
+	return encoded
+}
+
+func TestLookup(t *testing.T) {
+
+	country := encodeMap(
+		"iso_code", encodeString("GB"),
+		"names", encodeMap("en", encodeString("United Kingdom")),
+	)
+	city := encodeMap("names", encodeMap("en", encodeString("London")))
+
+	// the second record points at the country of the first
+	london := encodeMap("city", city, "country", country)
+	countryOffset := len(london) - len(country)
+	data := append(london, encodeMap("country", []byte{1 << 5, byte(countryOffset)})...)
+
+	networks := map[string]int{
+		"81.2.69.0/24": 0,
+		"81.2.70.0/24": len(london),
+	}
+
+	for _, version := range []uint{4, 6} {
+		for _, size := range []uint{24, 28, 32} {
+
+			reader, err := NewReader(database(version, size, data, networks))
+			if !assert.Nil(t, err) {
+				continue
+			}
+			DB = reader
+
+			assert.Equal(t, Location{"United Kingdom", "GB", "London"}, Lookup("81.2.69.160"))
+			assert.Equal(t, Location{"United Kingdom", "GB", ""}, Lookup("81.2.70.1"))
+			assert.Equal(t, Location{}, Lookup("81.2.71.1"))
+			assert.Equal(t, Location{}, Lookup("2001:db8::1"))
+			assert.Equal(t, Location{}, Lookup("not an address"))
+		}
+	}
+
+	DB = nil
+	assert.Equal(t, Location{}, Lookup("81.2.69.160"))
+
+	_, err := NewReader([]byte("not a database"))
+	assert.Equal(t, errDatabase, err)
+}
+
+func TestLocationString(t *testing.T) {
+
+	assert.Equal(t, "London, United Kingdom", Location{"United Kingdom", "GB", "London"}.String())
+	assert.Equal(t, "United Kingdom", Location{Country: "United Kingdom"}.String())
+	assert.Empty(t, Location{}.String())
+}
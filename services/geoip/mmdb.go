@@ -0,0 +1,307 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"net"
+)
+
+var (
+	errDatabase = errors.New("geoip: database is not valid")
+	errIPv6     = errors.New("geoip: database has no IPv6 addresses")
+)
+
+// metadataStart marks the metadata at the end of a MaxMind DB file.
+var metadataStart = []byte("\xab\xcd\xefMaxMind.com")
+
+const (
+	metadataMaxSize = 128 * 1024
+	dataSeparator   = 16
+	decodeMaxDepth  = 16
+)
+
+// Reader looks addresses up in a database of the MaxMind DB format, the
+// format GeoLite2 and GeoIP2 databases are shipped in.
+type Reader struct {
+	tree       []byte
+	data       []byte
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint
+}
+
+func NewReader(file []byte) (*Reader, error) {
+
+	start := bytes.LastIndex(file, metadataStart)
+	if start == -1 || len(file)-start > metadataMaxSize {
+		return nil, errDatabase
+	}
+
+	metadata := file[start+len(metadataStart):]
+	value, _, err := decode(metadata, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, errDatabase
+	}
+
+	r := &Reader{
+		nodeCount:  metadataUint(meta, "node_count"),
+		recordSize: metadataUint(meta, "record_size"),
+		ipVersion:  metadataUint(meta, "ip_version"),
+	}
+
+	if r.recordSize != 24 && r.recordSize != 28 && r.recordSize != 32 {
+		return nil, errDatabase
+	}
+
+	treeSize := r.nodeCount * r.recordSize / 4
+	if treeSize+dataSeparator > uint(start) {
+		return nil, errDatabase
+	}
+
+	r.tree = file[:treeSize]
+	r.data = file[treeSize+dataSeparator : start]
+
+	// IPv4 addresses are found under ::/96 of IPv6 databases
+	if r.ipVersion == 6 {
+		for i := 0; i < 96 && r.ipv4Start < r.nodeCount; i++ {
+			if r.ipv4Start, err = r.record(r.ipv4Start, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return r, nil
+}
+
+func metadataUint(meta map[string]interface{}, key string) uint {
+
+	value, _ := meta[key].(uint64)
+	return uint(value)
+}
+
+// Lookup decodes the record of the network ip is in, it is nil when the
+// database has none.
+func (r *Reader) Lookup(ip net.IP) (interface{}, error) {
+
+	node := uint(0)
+	if ipv4 := ip.To4(); ipv4 != nil {
+		ip, node = ipv4, r.ipv4Start
+	} else if r.ipVersion == 4 {
+		return nil, errIPv6
+	}
+
+	var err error
+	for i := 0; i < len(ip)*8 && node < r.nodeCount; i++ {
+		bit := uint(ip[i/8]>>(7-uint(i%8))) & 1
+		if node, err = r.record(node, bit); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case node == r.nodeCount:
+		return nil, nil
+	case node < r.nodeCount:
+		return nil, errDatabase
+	}
+
+	offset := node - r.nodeCount - dataSeparator
+	if offset >= uint(len(r.data)) {
+		return nil, errDatabase
+	}
+
+	value, _, err := decode(r.data, offset, 0)
+	return value, err
+}
+
+// record reads the left or the right record of a node of the search tree.
+func (r *Reader) record(node, bit uint) (uint, error) {
+
+	size := r.recordSize / 4
+	if (node+1)*size > uint(len(r.tree)) {
+		return 0, errDatabase
+	}
+	b := r.tree[node*size : (node+1)*size]
+
+	switch r.recordSize {
+	case 24:
+		b = b[bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]), nil
+	case 28:
+		if bit == 0 {
+			return uint(b[3]&0xf0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2]), nil
+		}
+		return uint(b[3]&0x0f)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6]), nil
+	default:
+		return uint(binary.BigEndian.Uint32(b[bit*4:])), nil
+	}
+}
+
+// decode reads the value at offset of the data section and returns the
+// offset after it. Maps are returned as map[string]interface{}, integers as
+// uint64 or int64 and floats as float64.
+func decode(data []byte, offset uint, depth int) (interface{}, uint, error) {
+
+	if offset >= uint(len(data)) || depth > decodeMaxDepth {
+		return nil, 0, errDatabase
+	}
+
+	control := data[offset]
+	offset++
+	kind := uint(control >> 5)
+
+	if kind == 1 {
+		pointer, next, err := decodePointer(data, offset, control)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := decode(data, pointer, depth+1)
+		return value, next, err
+	}
+
+	// extended types keep their type in the next byte
+	if kind == 0 {
+		if offset >= uint(len(data)) {
+			return nil, 0, errDatabase
+		}
+		kind = 7 + uint(data[offset])
+		offset++
+	}
+
+	size, offset, err := decodeSize(data, offset, uint(control&0x1f))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch kind {
+	case 7:
+		value := make(map[string]interface{}, size)
+		for i := uint(0); i < size; i++ {
+			var key, item interface{}
+			if key, offset, err = decode(data, offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			name, ok := key.(string)
+			if !ok {
+				return nil, 0, errDatabase
+			}
+			if item, offset, err = decode(data, offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			value[name] = item
+		}
+		return value, offset, nil
+	case 11:
+		value := make([]interface{}, 0, size)
+		for i := uint(0); i < size; i++ {
+			var item interface{}
+			if item, offset, err = decode(data, offset, depth+1); err != nil {
+				return nil, 0, err
+			}
+			value = append(value, item)
+		}
+		return value, offset, nil
+	case 14:
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(data)) {
+		return nil, 0, errDatabase
+	}
+	payload, next := data[offset:offset+size], offset+size
+
+	switch kind {
+	case 2:
+		return string(payload), next, nil
+	case 3:
+		if size != 8 {
+			return nil, 0, errDatabase
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(payload)), next, nil
+	case 4:
+		return append([]byte{}, payload...), next, nil
+	case 5, 6, 9:
+		if size > 8 {
+			return nil, 0, errDatabase
+		}
+		return decodeUint(payload), next, nil
+	case 8:
+		if size > 4 {
+			return nil, 0, errDatabase
+		}
+		return int64(int32(decodeUint(payload))), next, nil
+	case 10:
+		// uint128 is left as its bytes
+		return append([]byte{}, payload...), next, nil
+	case 15:
+		if size != 4 {
+			return nil, 0, errDatabase
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(payload))), next, nil
+	default:
+		return nil, 0, errDatabase
+	}
+}
+
+func decodeUint(payload []byte) uint64 {
+
+	value := uint64(0)
+	for _, b := range payload {
+		value = value<<8 | uint64(b)
+	}
+
+	return value
+}
+
+func decodeSize(data []byte, offset, size uint) (uint, uint, error) {
+
+	if size < 29 {
+		return size, offset, nil
+	}
+
+	extra := size - 28
+	if offset+extra > uint(len(data)) {
+		return 0, 0, errDatabase
+	}
+	value := uint(decodeUint(data[offset : offset+extra]))
+
+	switch extra {
+	case 1:
+		value += 29
+	case 2:
+		value += 285
+	default:
+		value += 65821
+	}
+
+	return value, offset + extra, nil
+}
+
+func decodePointer(data []byte, offset uint, control byte) (uint, uint, error) {
+
+	size := uint(control>>3)&0x3 + 1
+	if offset+size > uint(len(data)) {
+		return 0, 0, errDatabase
+	}
+	value := uint(decodeUint(data[offset : offset+size]))
+	prefix := uint(control & 0x7)
+
+	switch size {
+	case 1:
+		value |= prefix << 8
+	case 2:
+		value = (value | prefix<<16) + 2048
+	case 3:
+		value = (value | prefix<<24) + 526336
+	}
+
+	return value, offset + size, nil
+}
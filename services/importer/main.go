@@ -0,0 +1,232 @@
+package importer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/thedevsir/frame-backend/services/encrypt"
+)
+
+const (
+	SourceDjango   = "django"
+	SourceFirebase = "firebase"
+
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+var (
+	ErrUnknownSource = errors.New("importer: unknown source, use django or firebase")
+	ErrUnknownFormat = errors.New("importer: unknown format, use json or csv")
+	ErrMissingColumn = errors.New("importer: the csv header needs username, email and password columns")
+)
+
+var (
+	usernamePattern = regexp.MustCompile(`^[a-z0-9]{3,50}$`)
+	emailPattern    = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	notAlphanumeric = regexp.MustCompile(`[^a-z0-9]`)
+)
+
+type (
+	// Record is a user of an export, Number is its position in the file so
+	// the report can point at it.
+	Record struct {
+		Number        int
+		Username      string
+		Email         string
+		Hash          string
+		EmailVerified bool
+		Active        bool
+	}
+	// FirebaseParams are the password hash parameters of the Firebase
+	// project, they are shown in the console next to the signer key.
+	FirebaseParams struct {
+		Rounds        int
+		MemoryCost    int
+		SaltSeparator string
+	}
+)
+
+// Valid reports whether the record would pass the signup validation.
+func (r Record) Valid() bool {
+	return usernamePattern.MatchString(r.Username) && emailPattern.MatchString(r.Email)
+}
+
+// Read parses an export of the source into records, hashes keep the format
+// encrypt verifies them with.
+func Read(reader io.Reader, source, format string, params FirebaseParams) ([]Record, error) {
+
+	switch {
+	case source == SourceDjango && format == FormatJSON:
+		return readDjangoJSON(reader)
+	case source == SourceDjango && format == FormatCSV:
+		return readDjangoCSV(reader)
+	case source == SourceFirebase && format == FormatJSON:
+		return readFirebaseJSON(reader, params)
+	case source == SourceFirebase && format == FormatCSV:
+		return readFirebaseCSV(reader, params)
+	case source != SourceDjango && source != SourceFirebase:
+		return nil, ErrUnknownSource
+	default:
+		return nil, ErrUnknownFormat
+	}
+}
+
+// readDjangoJSON reads the output of manage.py dumpdata auth.user.
+func readDjangoJSON(reader io.Reader) ([]Record, error) {
+
+	var objects []struct {
+		Fields struct {
+			Username string `json:"username"`
+			Email    string `json:"email"`
+			Password string `json:"password"`
+			IsActive *bool  `json:"is_active"`
+		} `json:"fields"`
+	}
+	if err := json.NewDecoder(reader).Decode(&objects); err != nil {
+		return nil, err
+	}
+
+	records := []Record{}
+	for i, object := range objects {
+		records = append(records, Record{
+			Number:   i + 1,
+			Username: strings.ToLower(object.Fields.Username),
+			Email:    strings.ToLower(object.Fields.Email),
+			Hash:     object.Fields.Password,
+			Active:   object.Fields.IsActive == nil || *object.Fields.IsActive,
+		})
+	}
+
+	return records, nil
+}
+
+// readDjangoCSV reads an auth_user table export with a header row.
+func readDjangoCSV(reader io.Reader) ([]Record, error) {
+
+	rows, err := csv.NewReader(reader).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return []Record{}, nil
+	}
+
+	columns := map[string]int{}
+	for i, name := range rows[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"username", "email", "password"} {
+		if _, ok := columns[name]; !ok {
+			return nil, ErrMissingColumn
+		}
+	}
+
+	records := []Record{}
+	for i, row := range rows[1:] {
+		record := Record{
+			Number:   i + 2,
+			Username: strings.ToLower(row[columns["username"]]),
+			Email:    strings.ToLower(row[columns["email"]]),
+			Hash:     row[columns["password"]],
+			Active:   true,
+		}
+		if column, ok := columns["is_active"]; ok {
+			record.Active = parseBool(row[column], true)
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// readFirebaseJSON reads the output of firebase auth:export --format=json.
+func readFirebaseJSON(reader io.Reader, params FirebaseParams) ([]Record, error) {
+
+	var export struct {
+		Users []struct {
+			LocalID       string `json:"localId"`
+			Email         string `json:"email"`
+			EmailVerified bool   `json:"emailVerified"`
+			PasswordHash  string `json:"passwordHash"`
+			Salt          string `json:"salt"`
+			Disabled      bool   `json:"disabled"`
+		} `json:"users"`
+	}
+	if err := json.NewDecoder(reader).Decode(&export); err != nil {
+		return nil, err
+	}
+
+	records := []Record{}
+	for i, user := range export.Users {
+		record := firebaseRecord(i+1, user.LocalID, user.Email, user.PasswordHash, user.Salt, params)
+		record.EmailVerified = user.EmailVerified
+		record.Active = !user.Disabled
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// readFirebaseCSV reads the output of firebase auth:export --format=csv,
+// which has no header and starts with the UID, email, email verified,
+// password hash and salt columns.
+func readFirebaseCSV(reader io.Reader, params FirebaseParams) ([]Record, error) {
+
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+
+	rows, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	records := []Record{}
+	for i, row := range rows {
+		for len(row) < 5 {
+			row = append(row, "")
+		}
+		record := firebaseRecord(i+1, row[0], row[1], row[3], row[4], params)
+		record.EmailVerified = parseBool(row[2], false)
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// firebaseRecord names the user after the local part of the email, Firebase
+// has no usernames. The UID is used when too little of it is left.
+func firebaseRecord(number int, UID, email, hash, salt string, params FirebaseParams) Record {
+
+	email = strings.ToLower(email)
+	username := notAlphanumeric.ReplaceAllString(strings.Split(email, "@")[0], "")
+	if len(username) < 3 {
+		username = strings.ToLower(UID)
+	}
+	if len(username) > 50 {
+		username = username[:50]
+	}
+
+	record := Record{Number: number, Username: username, Email: email, Active: true}
+	if hash != "" {
+		record.Hash = encrypt.FirebaseScryptHash(params.Rounds, params.MemoryCost, params.SaltSeparator, salt, hash)
+	}
+
+	return record
+}
+
+func parseBool(value string, fallback bool) bool {
+
+	parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return fallback
+	}
+
+	return parsed
+}
@@ -0,0 +1,93 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRead(t *testing.T) {
+
+	firebase := FirebaseParams{Rounds: 8, MemoryCost: 14, SaltSeparator: "Bw=="}
+
+	t.Run("DjangoJSON", func(t *testing.T) {
+
+		data := `[
+			{"model": "auth.user", "pk": 1, "fields": {"username": "Jane", "email": "Jane@example.com", "password": "pbkdf2_sha256$1000$salt$key", "is_active": true}},
+			{"model": "auth.user", "pk": 2, "fields": {"username": "john", "email": "john@example.com", "password": "sha1$salt$hex", "is_active": false}}
+		]`
+
+		records, err := Read(strings.NewReader(data), SourceDjango, FormatJSON, firebase)
+		if assert.Nil(t, err) && assert.Len(t, records, 2) {
+			assert.Equal(t, Record{Number: 1, Username: "jane", Email: "jane@example.com", Hash: "pbkdf2_sha256$1000$salt$key", Active: true}, records[0])
+			assert.False(t, records[1].Active)
+		}
+	})
+
+	t.Run("DjangoCSV", func(t *testing.T) {
+
+		data := "id,username,email,password,is_active\n1,jane,jane@example.com,bcrypt$hash,0\n"
+
+		records, err := Read(strings.NewReader(data), SourceDjango, FormatCSV, firebase)
+		if assert.Nil(t, err) && assert.Len(t, records, 1) {
+			assert.Equal(t, Record{Number: 2, Username: "jane", Email: "jane@example.com", Hash: "bcrypt$hash"}, records[0])
+		}
+
+		_, err = Read(strings.NewReader("id,username\n1,jane\n"), SourceDjango, FormatCSV, firebase)
+		assert.Equal(t, ErrMissingColumn, err)
+	})
+
+	t.Run("FirebaseJSON", func(t *testing.T) {
+
+		data := `{"users": [
+			{"localId": "Qx7Uid", "email": "jane.doe@example.com", "emailVerified": true, "passwordHash": "aGFzaA==", "salt": "c2FsdA=="},
+			{"localId": "Zy9Uid", "email": "j@example.com", "disabled": true}
+		]}`
+
+		records, err := Read(strings.NewReader(data), SourceFirebase, FormatJSON, firebase)
+		if assert.Nil(t, err) && assert.Len(t, records, 2) {
+			assert.Equal(t, Record{
+				Number:        1,
+				Username:      "janedoe",
+				Email:         "jane.doe@example.com",
+				Hash:          "firebase-scrypt$8$14$Bw==$c2FsdA==$aGFzaA==",
+				EmailVerified: true,
+				Active:        true,
+			}, records[0])
+
+			// too little of the email is left for a username
+			assert.Equal(t, "zy9uid", records[1].Username)
+			assert.Empty(t, records[1].Hash)
+			assert.False(t, records[1].Active)
+		}
+	})
+
+	t.Run("FirebaseCSV", func(t *testing.T) {
+
+		data := "Qx7Uid,jane@example.com,true,aGFzaA==,c2FsdA==,Jane Doe,,,,\n"
+
+		records, err := Read(strings.NewReader(data), SourceFirebase, FormatCSV, firebase)
+		if assert.Nil(t, err) && assert.Len(t, records, 1) {
+			assert.Equal(t, "jane", records[0].Username)
+			assert.True(t, records[0].EmailVerified)
+			assert.Equal(t, "firebase-scrypt$8$14$Bw==$c2FsdA==$aGFzaA==", records[0].Hash)
+		}
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+
+		_, err := Read(strings.NewReader(""), "rails", FormatJSON, firebase)
+		assert.Equal(t, ErrUnknownSource, err)
+
+		_, err = Read(strings.NewReader(""), SourceDjango, "xml", firebase)
+		assert.Equal(t, ErrUnknownFormat, err)
+	})
+}
+
+func TestRecordValid(t *testing.T) {
+
+	assert.True(t, Record{Username: "jane", Email: "jane@example.com"}.Valid())
+	assert.False(t, Record{Username: "jane.doe", Email: "jane@example.com"}.Valid())
+	assert.False(t, Record{Username: "jane", Email: "jane"}.Valid())
+}
@@ -6,14 +6,33 @@ import (
 
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/keyring"
 )
 
-type EmailData struct {
-	Action   string
-	UserID   string
-	Email    string
-	Username string
-}
+type (
+	EmailData struct {
+		ID       string
+		Action   string
+		UserID   string
+		Email    string
+		Username string
+	}
+	MFAData struct {
+		UserID   string
+		Username string
+		Remember bool
+	}
+	PasskeyData struct {
+		UserID    string
+		Challenge string
+	}
+	SocialData struct {
+		UserID   string
+		Provider string
+		State    string
+		Verifier string
+	}
+)
 
 func ParseJWT(tokenString string, secret []byte) (*jwt.Token, error) {
 
@@ -32,6 +51,35 @@ func ParseJWT(tokenString string, secret []byte) (*jwt.Token, error) {
 	return nil, err
 }
 
+// ParseSignedJWT verifies a user or admin token against the keys of its ring.
+// A legacy token shares its secret with email and challenge tokens, so it
+// has to carry the claims of an access token.
+func ParseSignedJWT(tokenString string, ring *keyring.Ring) (*jwt.Token, error) {
+
+	tokenString = strings.Replace(tokenString, "Bearer ", "", 1)
+	token, err := ring.Parse(tokenString, jwt.MapClaims{})
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.Valid {
+		return nil, errors.ErrTokenIsNotValid
+	}
+
+	if _, ok := token.Header["kid"].(string); !ok {
+		claims := token.Claims.(jwt.MapClaims)
+		_, action := claims["action"]
+		_, session := claims["session"].(string)
+		if action || !session {
+			return nil, errors.ErrTokenIsNotValid
+		}
+	}
+
+	return token, nil
+}
+
+// ParseEmailToken parses the token of an email link. Other tokens signed
+// with the same key lack its claims and are denied.
 func ParseEmailToken(token string, secret []byte) (*EmailData, error) {
 
 	data, err := ParseJWT(token, secret)
@@ -39,10 +87,89 @@ func ParseEmailToken(token string, secret []byte) (*EmailData, error) {
 		return nil, errors.ErrTokenIsNotValid
 	}
 	claims := data.Claims.(jwt.MapClaims)
+	ID, _ := claims["jti"].(string)
+	action, okAction := claims["action"].(string)
+	userID, okUserID := claims["userId"].(string)
+	email, okEmail := claims["email"].(string)
+	username, okUsername := claims["username"].(string)
+	if !okAction || !okUserID || !okEmail || !okUsername {
+		return nil, errors.ErrAccessDenied
+	}
 	return &EmailData{
-		Action:   claims["action"].(string),
-		UserID:   claims["userId"].(string),
-		Email:    claims["email"].(string),
-		Username: claims["username"].(string),
+		ID:       ID,
+		Action:   action,
+		UserID:   userID,
+		Email:    email,
+		Username: username,
+	}, nil
+}
+
+func ParseMFAToken(token string, secret []byte) (*MFAData, error) {
+
+	data, err := ParseJWT(token, secret)
+	if err != nil {
+		return nil, errors.ErrTokenIsNotValid
+	}
+	claims := data.Claims.(jwt.MapClaims)
+	if action, _ := claims["action"].(string); action != "mfa" {
+		return nil, errors.ErrTokenIsNotValid
+	}
+	userID, _ := claims["userId"].(string)
+	username, _ := claims["username"].(string)
+	remember, _ := claims["remember"].(bool)
+	return &MFAData{
+		UserID:   userID,
+		Username: username,
+		Remember: remember,
+	}, nil
+}
+
+// ParsePasskeyToken parses a passkey ceremony token, action tells the
+// registration and signin ceremonies apart.
+func ParsePasskeyToken(token, action string, secret []byte) (*PasskeyData, error) {
+
+	data, err := ParseJWT(token, secret)
+	if err != nil {
+		return nil, errors.ErrTokenIsNotValid
+	}
+	claims := data.Claims.(jwt.MapClaims)
+	if claimed, _ := claims["action"].(string); claimed != action {
+		return nil, errors.ErrTokenIsNotValid
+	}
+	userID, _ := claims["userId"].(string)
+	challenge, _ := claims["challenge"].(string)
+	if challenge == "" {
+		return nil, errors.ErrTokenIsNotValid
+	}
+	return &PasskeyData{
+		UserID:    userID,
+		Challenge: challenge,
+	}, nil
+}
+
+// ParseSocialToken parses the token that carries a social signin or link
+// between leaving for the provider and coming back.
+func ParseSocialToken(token, action string, secret []byte) (*SocialData, error) {
+
+	data, err := ParseJWT(token, secret)
+	if err != nil {
+		return nil, errors.ErrTokenIsNotValid
+	}
+	claims := data.Claims.(jwt.MapClaims)
+	if claimed, _ := claims["action"].(string); claimed != action {
+		return nil, errors.ErrTokenIsNotValid
+	}
+	userID, _ := claims["userId"].(string)
+	provider, _ := claims["provider"].(string)
+	state, _ := claims["state"].(string)
+	verifier, _ := claims["verifier"].(string)
+	if provider == "" || state == "" || verifier == "" {
+		return nil, errors.ErrTokenIsNotValid
+	}
+	return &SocialData{
+		UserID:   userID,
+		Provider: provider,
+		State:    state,
+		Verifier: verifier,
 	}, nil
 }
@@ -2,10 +2,16 @@ package jwt
 
 import (
 	"testing"
+	"time"
 
+	jwt "github.com/dgrijalva/jwt-go"
 	"gopkg.in/mgo.v2/bson"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/auth"
+	"github.com/thedevsir/frame-backend/services/errors"
+	"github.com/thedevsir/frame-backend/services/keyring"
 	"github.com/thedevsir/frame-backend/services/mail"
 )
 
@@ -43,9 +49,120 @@ func TestParseJWT(t *testing.T) {
 	}
 }
 
+func TestParseSignedJWT(t *testing.T) {
+
+	key, _ := keyring.GenerateKey(keyring.EdDSA)
+	ring := keyring.NewRing(key)
+
+	composer := &auth.UserToken{Session: "session", SID: "SID", ID: "ID"}
+	token, _ := composer.Create(ring)
+
+	parsed, err := ParseSignedJWT(token, ring)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "SID", parsed.Claims.(jwt.MapClaims)["sid"])
+	}
+
+	other, _ := keyring.GenerateKey(keyring.EdDSA)
+	_, err = ParseSignedJWT(token, keyring.NewRing(other))
+	assert.NotNil(t, err)
+
+	t.Run("Legacy", func(t *testing.T) {
+
+		legacy, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, composer).SignedString(secret)
+		_, err := ParseSignedJWT(legacy, ring)
+		assert.NotNil(t, err)
+
+		ring.AcceptLegacy(secret)
+		defer ring.AcceptLegacy(nil)
+
+		parsed, err := ParseSignedJWT(legacy, ring)
+		if assert.Nil(t, err) {
+			assert.Equal(t, "SID", parsed.Claims.(jwt.MapClaims)["sid"])
+		}
+
+		// email and challenge tokens share the secret
+		emailToken, _ := mail.MakeEmailToken("verify", "ID", bson.NewObjectId().Hex(), "username", "email", secret)
+		_, err = ParseSignedJWT(emailToken, ring)
+		assert.Equal(t, errors.ErrTokenIsNotValid, err)
+	})
+}
+
 func TestParseEmailToken(t *testing.T) {
-	token, err := mail.MakeEmailToken("verify", bson.NewObjectId().Hex(), "username", "email", secret)
+	token, err := mail.MakeEmailToken("verify", "ID", bson.NewObjectId().Hex(), "username", "email", secret)
 	data, err := ParseEmailToken(token, secret)
 	assert.Nil(t, err)
 	assert.IsType(t, &EmailData{}, data)
+
+	config.MagicLinkLifetime = time.Minute
+	token, _ = mail.MakeLoginToken("ID", bson.NewObjectId().Hex(), "username", "email", secret)
+	data, err = ParseEmailToken(token, secret)
+	if assert.Nil(t, err) {
+		assert.Equal(t, "login", data.Action)
+		assert.Equal(t, "ID", data.ID)
+	}
+
+	composer := &auth.MFAToken{ID: "ID", Username: "username"}
+	token, _ = composer.Create(secret)
+	_, err = ParseEmailToken(token, secret)
+	assert.Equal(t, errors.ErrAccessDenied, err)
+}
+
+func TestParseMFAToken(t *testing.T) {
+
+	t.Run("Success", func(t *testing.T) {
+		composer := &auth.MFAToken{ID: "ID", Username: "username", Remember: true}
+		token, _ := composer.Create(secret)
+		data, err := ParseMFAToken(token, secret)
+		if assert.Nil(t, err) {
+			assert.Equal(t, "ID", data.UserID)
+			assert.Equal(t, "username", data.Username)
+			assert.True(t, data.Remember)
+		}
+	})
+
+	t.Run("WrongAction", func(t *testing.T) {
+		token, _ := mail.MakeEmailToken("verify", "ID", bson.NewObjectId().Hex(), "username", "email", secret)
+		_, err := ParseMFAToken(token, secret)
+		assert.Equal(t, errors.ErrTokenIsNotValid, err)
+	})
+}
+
+func TestParsePasskeyToken(t *testing.T) {
+
+	composer := &auth.PasskeyToken{Action: "passkey-register", ID: "ID", Challenge: "challenge"}
+	token, _ := composer.Create(secret)
+
+	t.Run("Success", func(t *testing.T) {
+		data, err := ParsePasskeyToken(token, "passkey-register", secret)
+		if assert.Nil(t, err) {
+			assert.Equal(t, "ID", data.UserID)
+			assert.Equal(t, "challenge", data.Challenge)
+		}
+	})
+
+	t.Run("WrongAction", func(t *testing.T) {
+		_, err := ParsePasskeyToken(token, "passkey-signin", secret)
+		assert.Equal(t, errors.ErrTokenIsNotValid, err)
+	})
+}
+
+func TestParseSocialToken(t *testing.T) {
+
+	composer := &auth.SocialToken{Action: "social-link", ID: "ID", Provider: "github", State: "state", Verifier: "verifier"}
+	token, _ := composer.Create(secret)
+
+	t.Run("Success", func(t *testing.T) {
+		data, err := ParseSocialToken(token, "social-link", secret)
+		if assert.Nil(t, err) {
+			assert.Equal(t, "ID", data.UserID)
+			assert.Equal(t, "github", data.Provider)
+			assert.Equal(t, "state", data.State)
+			assert.Equal(t, "verifier", data.Verifier)
+		}
+	})
+
+	t.Run("WrongAction", func(t *testing.T) {
+		_, err := ParseSocialToken(token, "social-signin", secret)
+		assert.Equal(t, errors.ErrTokenIsNotValid, err)
+	})
 }
@@ -0,0 +1,423 @@
+package keyring
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"path/filepath"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/thedevsir/frame-backend/config"
+)
+
+const (
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+
+	UserRing  = "user"
+	AdminRing = "admin"
+	OIDCRing  = "oidc"
+
+	// a token naming a key the ring does not know reloads the ring at most
+	// this often, keys generated by another instance are picked up early
+	unknownKeyReload = 10 * time.Second
+)
+
+var (
+	ErrUnsupportedKey = errors.New("keyring: key type is not supported")
+	ErrUnknownKey     = errors.New("keyring: token is signed by an unknown key")
+	ErrRingNotFound   = errors.New("keyring: ring not found")
+	ErrNoKeys         = errors.New("keyring: ring has no keys, run `cmd keys generate` against a key directory shared by every instance")
+)
+
+var Rings = []string{UserRing, AdminRing, OIDCRing}
+
+var (
+	// User signs the access tokens of users, its public keys are published
+	// so other services can verify them.
+	User *Ring
+	// Admin signs the access tokens of admins.
+	Admin *Ring
+	// OIDC signs ID tokens, its public keys are published as the JWKS.
+	OIDC *Ring
+)
+
+type (
+	Key struct {
+		ID        string
+		Algorithm string
+		Signer    crypto.Signer
+	}
+	Ring struct {
+		dir      string
+		mutex    sync.RWMutex
+		active   *Key
+		keys     map[string]*Key
+		loadedAt time.Time
+		// legacy verifies HS256 tokens issued before the ring
+		legacy []byte
+	}
+	JWK struct {
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+		N   string `json:"n,omitempty"`
+		E   string `json:"e,omitempty"`
+		Crv string `json:"crv,omitempty"`
+		X   string `json:"x,omitempty"`
+		Y   string `json:"y,omitempty"`
+	}
+	JWKS struct {
+		Keys []JWK `json:"keys"`
+	}
+)
+
+// Composer opens the rings under the configured path. In DEV mode a ring
+// without keys gets one on first start, elsewhere every instance has to
+// share the keys generated with the cmd. The rings are reloaded
+// periodically so keys rotated with the cmd reach every running instance.
+func Composer() {
+
+	User = compose(UserRing, config.KeyringAlgorithm)
+	Admin = compose(AdminRing, config.KeyringAlgorithm)
+	// RS256 is the one algorithm every OpenID Connect relying party supports
+	OIDC = compose(OIDCRing, RS256)
+
+	// tokens signed with the secrets the rings replaced stay valid until
+	// they expire
+	if config.KeyringLegacyTokens {
+		User.AcceptLegacy([]byte(config.SigningKey))
+		Admin.AcceptLegacy([]byte(config.AdminSigningKey))
+	}
+
+	if config.KeyringReload > 0 {
+		go reload(config.KeyringReload, User, Admin, OIDC)
+	}
+}
+
+func compose(name, algorithm string) *Ring {
+
+	dir, _ := Dir(name)
+
+	ring, err := Open(dir)
+	if err == ErrEmptyRing {
+		// instances generating their own keys would reject each other's tokens
+		if config.Mode != "DEV" {
+			panic(ErrNoKeys)
+		}
+		if _, err = Generate(dir, algorithm, true); err == nil {
+			ring, err = Open(dir)
+		}
+	}
+
+	if err != nil {
+		panic(err)
+	}
+
+	return ring
+}
+
+func reload(interval time.Duration, rings ...*Ring) {
+
+	for range time.Tick(interval) {
+		for _, ring := range rings {
+			// a ring that fails to load keeps the keys it has
+			if err := ring.Reload(); err != nil {
+				log.Println(err)
+			}
+		}
+	}
+}
+
+// Dir is the directory a named ring is stored in.
+func Dir(name string) (string, error) {
+
+	for _, ring := range Rings {
+		if ring == name {
+			return filepath.Join(config.KeyringPath, name), nil
+		}
+	}
+
+	return "", ErrRingNotFound
+}
+
+func GenerateKey(algorithm string) (*Key, error) {
+
+	var signer crypto.Signer
+	var err error
+
+	switch algorithm {
+	case RS256:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case ES256:
+		signer, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case EdDSA:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKey(signer)
+}
+
+// ParseKey reads a PEM encoded PKCS#8, PKCS#1 or SEC 1 private key.
+func ParseKey(data []byte) (*Key, error) {
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrUnsupportedKey
+	}
+
+	var private interface{}
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		private, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		private, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		private, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := private.(crypto.Signer)
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+
+	return NewKey(signer)
+}
+
+// NewKey identifies a key by its RFC 7638 thumbprint.
+func NewKey(signer crypto.Signer) (*Key, error) {
+
+	key := &Key{Signer: signer}
+
+	switch public := signer.Public().(type) {
+	case *rsa.PublicKey:
+		key.Algorithm = RS256
+	case *ecdsa.PublicKey:
+		if public.Curve != elliptic.P256() {
+			return nil, ErrUnsupportedKey
+		}
+		key.Algorithm = ES256
+	case ed25519.PublicKey:
+		key.Algorithm = EdDSA
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	jwk := key.JWK()
+	var thumbprint []byte
+	switch jwk.Kty {
+	case "RSA":
+		thumbprint, _ = json.Marshal(map[string]string{"e": jwk.E, "kty": jwk.Kty, "n": jwk.N})
+	case "EC":
+		thumbprint, _ = json.Marshal(map[string]string{"crv": jwk.Crv, "kty": jwk.Kty, "x": jwk.X, "y": jwk.Y})
+	default:
+		// RFC 8037 section 2
+		thumbprint, _ = json.Marshal(map[string]string{"crv": jwk.Crv, "kty": jwk.Kty, "x": jwk.X})
+	}
+	sum := sha256.Sum256(thumbprint)
+	key.ID = base64.RawURLEncoding.EncodeToString(sum[:])
+
+	return key, nil
+}
+
+// Marshal encodes the private key as PKCS#8 PEM.
+func (k *Key) Marshal() ([]byte, error) {
+
+	der, err := x509.MarshalPKCS8PrivateKey(k.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+func (k *Key) JWK() JWK {
+
+	encode := func(b []byte) string {
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	jwk := JWK{Use: "sig", Alg: k.Algorithm, Kid: k.ID}
+
+	switch public := k.Signer.Public().(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = encode(public.N.Bytes())
+		jwk.E = encode(big.NewInt(int64(public.E)).Bytes())
+	case *ecdsa.PublicKey:
+		x, y := make([]byte, 32), make([]byte, 32)
+		public.X.FillBytes(x)
+		public.Y.FillBytes(y)
+		jwk.Kty = "EC"
+		jwk.Crv = "P-256"
+		jwk.X = encode(x)
+		jwk.Y = encode(y)
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = encode(public)
+	}
+
+	return jwk
+}
+
+func (k *Key) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// NewRing keeps its keys in memory only, Open loads a stored ring.
+func NewRing(active *Key, others ...*Key) *Ring {
+
+	ring := &Ring{active: active, keys: map[string]*Key{active.ID: active}}
+	for _, key := range others {
+		ring.keys[key.ID] = key
+	}
+
+	return ring
+}
+
+// AcceptLegacy lets the ring verify HS256 tokens without a key ID signed
+// with secret, an empty secret accepts none.
+func (r *Ring) AcceptLegacy(secret []byte) {
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.legacy = nil
+	if len(secret) > 0 {
+		r.legacy = secret
+	}
+}
+
+// Reload replaces the keys of a stored ring with the ones on disk.
+func (r *Ring) Reload() error {
+
+	if r.dir == "" {
+		return nil
+	}
+
+	r.mutex.Lock()
+	r.loadedAt = time.Now()
+	r.mutex.Unlock()
+
+	active, keys, err := load(r.dir)
+	if err != nil {
+		return err
+	}
+
+	r.mutex.Lock()
+	r.active, r.keys = active, keys
+	r.mutex.Unlock()
+
+	return nil
+}
+
+// Active is the key new tokens are signed with.
+func (r *Ring) Active() *Key {
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.active
+}
+
+func (r *Ring) key(kid string) (*Key, bool) {
+
+	r.mutex.RLock()
+	key, ok := r.keys[kid]
+	stale := r.dir != "" && time.Since(r.loadedAt) > unknownKeyReload
+	r.mutex.RUnlock()
+
+	if !ok && stale && r.Reload() == nil {
+		r.mutex.RLock()
+		key, ok = r.keys[kid]
+		r.mutex.RUnlock()
+	}
+
+	return key, ok
+}
+
+func (r *Ring) legacySecret() []byte {
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.legacy
+}
+
+func (r *Ring) JWKS() JWKS {
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	jwks := JWKS{Keys: []JWK{r.active.JWK()}}
+	for id, key := range r.keys {
+		if id != r.active.ID {
+			jwks.Keys = append(jwks.Keys, key.JWK())
+		}
+	}
+
+	return jwks
+}
+
+// Sign signs claims with the active key and names it in the kid header.
+func (r *Ring) Sign(claims jwt.Claims) (string, error) {
+
+	active := r.Active()
+
+	token := jwt.NewWithClaims(active.method(), claims)
+	token.Header["kid"] = active.ID
+
+	return token.SignedString(active.Signer)
+}
+
+// Parse verifies a token against the key named by its kid header.
+func (r *Ring) Parse(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+
+	return jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+
+		kid, _ := token.Header["kid"].(string)
+		if legacy := r.legacySecret(); kid == "" && legacy != nil && token.Method == jwt.SigningMethodHS256 {
+			return legacy, nil
+		}
+
+		key, ok := r.key(kid)
+		if !ok {
+			return nil, ErrUnknownKey
+		}
+
+		if token.Method.Alg() != key.Algorithm {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return key.Signer.Public(), nil
+	})
+}
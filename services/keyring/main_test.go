@@ -0,0 +1,126 @@
+package keyring
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"io"
+	"math/big"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type publicOnly struct {
+	key crypto.PublicKey
+}
+
+func (p publicOnly) Public() crypto.PublicKey {
+	return p.key
+}
+
+func (p publicOnly) Sign(io.Reader, []byte, crypto.SignerOpts) ([]byte, error) {
+	return nil, errors.New("public key only")
+}
+
+func TestKey(t *testing.T) {
+
+	t.Run("Thumbprint", func(t *testing.T) {
+
+		// RFC 7638 section 3.1
+		n, _ := base64.RawURLEncoding.DecodeString("0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw")
+		key, err := NewKey(publicOnly{&rsa.PublicKey{N: new(big.Int).SetBytes(n), E: 65537}})
+		if assert.Nil(t, err) {
+			assert.Equal(t, "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", key.ID)
+			assert.Equal(t, "AQAB", key.JWK().E)
+		}
+	})
+
+	t.Run("ThumbprintEd25519", func(t *testing.T) {
+
+		// RFC 8037 appendix A.3
+		x, _ := base64.RawURLEncoding.DecodeString("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")
+		key, err := NewKey(publicOnly{ed25519.PublicKey(x)})
+		if assert.Nil(t, err) {
+			assert.Equal(t, "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k", key.ID)
+			assert.Equal(t, EdDSA, key.Algorithm)
+		}
+	})
+
+	for _, algorithm := range []string{RS256, ES256, EdDSA} {
+		t.Run("Marshal"+algorithm, func(t *testing.T) {
+
+			key, err := GenerateKey(algorithm)
+			if !assert.Nil(t, err) {
+				return
+			}
+
+			data, err := key.Marshal()
+			assert.Nil(t, err)
+
+			parsed, err := ParseKey(data)
+			if assert.Nil(t, err) {
+				assert.Equal(t, key.ID, parsed.ID)
+				assert.Equal(t, algorithm, parsed.Algorithm)
+			}
+		})
+	}
+
+	t.Run("UnsupportedAlgorithm", func(t *testing.T) {
+		_, err := GenerateKey("HS256")
+		assert.Equal(t, ErrUnsupportedKey, err)
+	})
+
+	t.Run("InvalidPEM", func(t *testing.T) {
+		_, err := ParseKey([]byte("not a key"))
+		assert.Equal(t, ErrUnsupportedKey, err)
+	})
+}
+
+func TestRing(t *testing.T) {
+
+	old, _ := GenerateKey(EdDSA)
+	active, _ := GenerateKey(RS256)
+	ring := NewRing(active, old)
+
+	t.Run("JWKS", func(t *testing.T) {
+		jwks := ring.JWKS()
+		if assert.Len(t, jwks.Keys, 2) {
+			assert.Equal(t, active.ID, jwks.Keys[0].Kid)
+			assert.Equal(t, "OKP", jwks.Keys[1].Kty)
+		}
+	})
+
+	t.Run("Sign", func(t *testing.T) {
+
+		signed, err := ring.Sign(jwt.StandardClaims{Subject: "user"})
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		claims := &jwt.StandardClaims{}
+		token, err := ring.Parse(signed, claims)
+		if assert.Nil(t, err) {
+			assert.Equal(t, active.ID, token.Header["kid"])
+			assert.Equal(t, "user", claims.Subject)
+		}
+	})
+
+	t.Run("OlderKey", func(t *testing.T) {
+
+		signed, _ := NewRing(old).Sign(jwt.StandardClaims{Subject: "user"})
+		_, err := ring.Parse(signed, &jwt.StandardClaims{})
+		assert.Nil(t, err)
+	})
+
+	t.Run("UnknownKey", func(t *testing.T) {
+
+		other, _ := GenerateKey(ES256)
+		signed, _ := NewRing(other).Sign(jwt.StandardClaims{Subject: "user"})
+		_, err := ring.Parse(signed, &jwt.StandardClaims{})
+		assert.NotNil(t, err)
+	})
+}
@@ -0,0 +1,240 @@
+package keyring
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// A stored ring is a directory holding one PKCS#8 PEM file per key, named
+// after its kid, and a manifest naming the active key. Every key in the
+// manifest verifies tokens; a key is retired by removing it.
+
+const manifestFile = "keyring.json"
+
+var (
+	ErrEmptyRing    = errors.New("keyring: ring has no keys")
+	ErrNoActiveKey  = errors.New("keyring: ring has no active key")
+	ErrKeyNotFound  = errors.New("keyring: key not found")
+	ErrKeyMismatch  = errors.New("keyring: key file does not hold the key named by the manifest")
+	ErrRetireActive = errors.New("keyring: the active key can not be retired")
+)
+
+type (
+	Entry struct {
+		ID        string    `json:"kid"`
+		Algorithm string    `json:"alg"`
+		Active    bool      `json:"active"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+	Manifest struct {
+		Keys []Entry `json:"keys"`
+	}
+)
+
+// Open loads the ring stored in dir.
+func Open(dir string) (*Ring, error) {
+
+	ring := &Ring{dir: dir}
+	if err := ring.Reload(); err != nil {
+		return nil, err
+	}
+
+	return ring, nil
+}
+
+func load(dir string) (*Key, map[string]*Key, error) {
+
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(manifest.Keys) == 0 {
+		return nil, nil, ErrEmptyRing
+	}
+
+	var active *Key
+	keys := map[string]*Key{}
+
+	for _, entry := range manifest.Keys {
+
+		data, err := ioutil.ReadFile(keyFile(dir, entry.ID))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key, err := ParseKey(data)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if key.ID != entry.ID {
+			return nil, nil, ErrKeyMismatch
+		}
+
+		keys[key.ID] = key
+		if entry.Active {
+			active = key
+		}
+	}
+
+	if active == nil {
+		return nil, nil, ErrNoActiveKey
+	}
+
+	return active, keys, nil
+}
+
+func keyFile(dir, kid string) string {
+	return filepath.Join(dir, kid+".pem")
+}
+
+// ReadManifest reads the manifest of the ring stored in dir, a ring that
+// does not exist yet has no keys.
+func ReadManifest(dir string) (*Manifest, error) {
+
+	manifest := &Manifest{}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, manifestFile))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// write replaces the manifest with a rename, so a running instance never
+// reads it half written.
+func (m *Manifest) write(dir string) error {
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	temp := filepath.Join(dir, manifestFile+".tmp")
+	if err = ioutil.WriteFile(temp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(temp, filepath.Join(dir, manifestFile))
+}
+
+func (m *Manifest) find(kid string) int {
+
+	for i, entry := range m.Keys {
+		if entry.ID == kid {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (m *Manifest) activate(kid string) {
+
+	for i := range m.Keys {
+		m.Keys[i].Active = m.Keys[i].ID == kid
+	}
+}
+
+// Generate adds a new key to the ring stored in dir. A key that is not
+// activated only verifies tokens, so it can reach every instance before it
+// signs anything. The first key of a ring is always activated.
+func Generate(dir, algorithm string, activate bool) (*Key, error) {
+
+	key, err := GenerateKey(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := key.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = ioutil.WriteFile(keyFile(dir, key.ID), data, 0600); err != nil {
+		return nil, err
+	}
+
+	manifest.Keys = append(manifest.Keys, Entry{
+		ID:        key.ID,
+		Algorithm: key.Algorithm,
+		CreatedAt: time.Now(),
+	})
+	if activate || len(manifest.Keys) == 1 {
+		manifest.activate(key.ID)
+	}
+
+	if err = manifest.write(dir); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// Activate makes a key of the ring stored in dir sign new tokens, the key
+// it replaces keeps verifying the tokens it signed until it is retired.
+func Activate(dir, kid string) error {
+
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	if manifest.find(kid) < 0 {
+		return ErrKeyNotFound
+	}
+
+	manifest.activate(kid)
+	return manifest.write(dir)
+}
+
+// Retire removes a key from the ring stored in dir, the tokens it signed
+// are rejected from then on.
+func Retire(dir, kid string) error {
+
+	manifest, err := ReadManifest(dir)
+	if err != nil {
+		return err
+	}
+
+	i := manifest.find(kid)
+	if i < 0 {
+		return ErrKeyNotFound
+	}
+
+	if manifest.Keys[i].Active {
+		return ErrRetireActive
+	}
+
+	manifest.Keys = append(manifest.Keys[:i], manifest.Keys[i+1:]...)
+	if err = manifest.write(dir); err != nil {
+		return err
+	}
+
+	if err = os.Remove(keyFile(dir, kid)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package keyring
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/config"
+)
+
+func TestCompose(t *testing.T) {
+
+	root, _ := ioutil.TempDir("", "keyring")
+	defer os.RemoveAll(root)
+
+	defer func(path, mode string) { config.KeyringPath, config.Mode = path, mode }(config.KeyringPath, config.Mode)
+	config.KeyringPath = root
+
+	config.Mode = "PRODUCTION"
+	assert.PanicsWithValue(t, ErrNoKeys, func() { compose(UserRing, ES256) })
+
+	config.Mode = "DEV"
+	assert.NotNil(t, compose(UserRing, ES256).Active())
+}
+
+func TestStore(t *testing.T) {
+
+	root, _ := ioutil.TempDir("", "keyring")
+	defer os.RemoveAll(root)
+	dir := filepath.Join(root, UserRing)
+
+	t.Run("Empty", func(t *testing.T) {
+		_, err := Open(dir)
+		assert.Equal(t, ErrEmptyRing, err)
+	})
+
+	first, err := Generate(dir, ES256, false)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	ring, err := Open(dir)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	// the first key of a ring signs even when it is not activated
+	assert.Equal(t, first.ID, ring.Active().ID)
+	signed, _ := ring.Sign(jwt.StandardClaims{Subject: "user"})
+
+	t.Run("Generate", func(t *testing.T) {
+
+		next, err := Generate(dir, EdDSA, false)
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		assert.Nil(t, ring.Reload())
+		assert.Equal(t, first.ID, ring.Active().ID)
+		assert.Len(t, ring.JWKS().Keys, 2)
+
+		assert.Nil(t, Activate(dir, next.ID))
+		assert.Nil(t, ring.Reload())
+		assert.Equal(t, next.ID, ring.Active().ID)
+	})
+
+	t.Run("Rotation", func(t *testing.T) {
+
+		// tokens signed before the rotation stay valid
+		_, err := ring.Parse(signed, &jwt.StandardClaims{})
+		assert.Nil(t, err)
+	})
+
+	t.Run("Activate", func(t *testing.T) {
+		assert.Equal(t, ErrKeyNotFound, Activate(dir, "unknown"))
+	})
+
+	t.Run("Retire", func(t *testing.T) {
+
+		assert.Equal(t, ErrRetireActive, Retire(dir, ring.Active().ID))
+		assert.Equal(t, ErrKeyNotFound, Retire(dir, "unknown"))
+
+		assert.Nil(t, Retire(dir, first.ID))
+		assert.Nil(t, ring.Reload())
+
+		_, err := ring.Parse(signed, &jwt.StandardClaims{})
+		assert.NotNil(t, err)
+
+		_, err = os.Stat(filepath.Join(dir, first.ID+".pem"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("UnknownKeyReload", func(t *testing.T) {
+
+		// another instance rotated the ring
+		rotated, _ := Generate(dir, ES256, true)
+		signed, _ := NewRing(rotated).Sign(jwt.StandardClaims{Subject: "user"})
+
+		ring.loadedAt = ring.loadedAt.Add(-unknownKeyReload)
+		_, err := ring.Parse(signed, &jwt.StandardClaims{})
+		assert.Nil(t, err)
+	})
+}
@@ -18,7 +18,38 @@ type EmailToken struct {
 	jwt.StandardClaims
 }
 
-func MakeEmailToken(action, userID, username, email string, secret []byte) (string, error) {
+const EmailTokenLifetime = time.Hour * 24
+
+// MakeEmailToken makes the token of a verification or reset link, ID names
+// the stored token that lets the link be used once.
+func MakeEmailToken(action, ID, userID, username, email string, secret []byte) (string, error) {
+	return makeEmailToken(action, ID, userID, username, email, EmailTokenLifetime, secret)
+}
+
+// MakeLoginToken makes the token of a magic link, it expires sooner.
+func MakeLoginToken(ID, userID, username, email string, secret []byte) (string, error) {
+	return makeEmailToken("login", ID, userID, username, email, config.MagicLinkLifetime, secret)
+}
+
+// MakeRestoreToken makes the token of the link that restores a deleted
+// account, it lasts as long as the grace period.
+func MakeRestoreToken(ID, userID, username, email string, secret []byte) (string, error) {
+	return makeEmailToken("restore", ID, userID, username, email, config.AccountDeletionGrace, secret)
+}
+
+// MakeUnlockToken makes the token of the link that unlocks an account, it
+// lasts as long as the longest lock.
+func MakeUnlockToken(ID, userID, username, email string, secret []byte) (string, error) {
+	return makeEmailToken("unlock", ID, userID, username, email, config.LockoutMaxDelay, secret)
+}
+
+// MakeSigninAlertToken makes the token of the link that undoes a signin, it
+// lasts as long as a remembered session can.
+func MakeSigninAlertToken(ID, userID, username, email string, secret []byte) (string, error) {
+	return makeEmailToken("signinAlert", ID, userID, username, email, config.SessionRememberLifetime, secret)
+}
+
+func makeEmailToken(action, ID, userID, username, email string, lifetime time.Duration, secret []byte) (string, error) {
 
 	claims := EmailToken{
 		action,
@@ -26,7 +57,8 @@ func MakeEmailToken(action, userID, username, email string, secret []byte) (stri
 		email,
 		username,
 		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 24).Unix(),
+			Id:        ID,
+			ExpiresAt: time.Now().Add(lifetime).Unix(),
 		},
 	}
 
@@ -92,3 +124,201 @@ func SendResetMail(username, email, token string) error {
 
 	return nil
 }
+
+func SendLoginMail(username, email, token string) error {
+
+	loginBody := mail.Login{
+		Username:     username,
+		EmailAddress: email,
+		Token:        token,
+	}
+
+	emailBody, emailText, err := mail.GenerateTemplate(loginBody.Email())
+	if err != nil {
+		return err
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", config.EmailFrom)
+	m.SetHeader("To", loginBody.EmailAddress)
+	m.SetHeader("Subject", "Sign in to "+config.EmailAppName)
+	m.SetBody("text/plain", emailText)
+	m.AddAlternative("text/html", emailBody)
+
+	if err := Mail.Connection.DialAndSend(m); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func SendConfirmEmailMail(username, email, token string) error {
+
+	confirmBody := mail.ConfirmEmail{
+		Username:     username,
+		EmailAddress: email,
+		Token:        token,
+	}
+
+	emailBody, emailText, err := mail.GenerateTemplate(confirmBody.Email())
+	if err != nil {
+		return err
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", config.EmailFrom)
+	m.SetHeader("To", confirmBody.EmailAddress)
+	m.SetHeader("Subject", "Confirm your new email address")
+	m.SetBody("text/plain", emailText)
+	m.AddAlternative("text/html", emailBody)
+
+	if err := Mail.Connection.DialAndSend(m); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SendEmailChangingMail tells the current address about a pending change,
+// with a link that cancels it.
+func SendEmailChangingMail(username, email, newEmail, token string) error {
+
+	changingBody := mail.EmailChanging{
+		Username:     username,
+		EmailAddress: email,
+		NewEmail:     newEmail,
+		Token:        token,
+	}
+
+	emailBody, emailText, err := mail.GenerateTemplate(changingBody.Email())
+	if err != nil {
+		return err
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", config.EmailFrom)
+	m.SetHeader("To", changingBody.EmailAddress)
+	m.SetHeader("Subject", "Your email address is being changed")
+	m.SetBody("text/plain", emailText)
+	m.AddAlternative("text/html", emailBody)
+
+	if err := Mail.Connection.DialAndSend(m); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func SendAccountDeletionMail(username, email, token string, deleteAt time.Time) error {
+
+	deletionBody := &mail.AccountDeletion{
+		Username:     username,
+		EmailAddress: email,
+		Token:        token,
+		DeleteAt:     deleteAt,
+	}
+
+	emailBody, emailText, err := mail.GenerateTemplate(deletionBody.Email())
+	if err != nil {
+		return err
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", config.EmailFrom)
+	m.SetHeader("To", deletionBody.EmailAddress)
+	m.SetHeader("Subject", "Your account will be deleted")
+	m.SetBody("text/plain", emailText)
+	m.AddAlternative("text/html", emailBody)
+
+	if err := Mail.Connection.DialAndSend(m); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func SendDataExportMail(username, email, link string, expireAt time.Time) error {
+
+	exportBody := &mail.DataExport{
+		Username:     username,
+		EmailAddress: email,
+		Link:         link,
+		ExpireAt:     expireAt,
+	}
+
+	emailBody, emailText, err := mail.GenerateTemplate(exportBody.Email())
+	if err != nil {
+		return err
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", config.EmailFrom)
+	m.SetHeader("To", exportBody.EmailAddress)
+	m.SetHeader("Subject", "Your data export is ready")
+	m.SetBody("text/plain", emailText)
+	m.AddAlternative("text/html", emailBody)
+
+	if err := Mail.Connection.DialAndSend(m); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func SendAccountLockedMail(username, email, token string, lockedUntil time.Time) error {
+
+	lockedBody := &mail.AccountLocked{
+		Username:     username,
+		EmailAddress: email,
+		Token:        token,
+		LockedUntil:  lockedUntil,
+	}
+
+	emailBody, emailText, err := mail.GenerateTemplate(lockedBody.Email())
+	if err != nil {
+		return err
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", config.EmailFrom)
+	m.SetHeader("To", lockedBody.EmailAddress)
+	m.SetHeader("Subject", "Your account was locked")
+	m.SetBody("text/plain", emailText)
+	m.AddAlternative("text/html", emailBody)
+
+	if err := Mail.Connection.DialAndSend(m); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func SendSigninAlertMail(username, email, token, device, location string, signedInAt time.Time) error {
+
+	alertBody := &mail.SigninAlert{
+		Username:     username,
+		EmailAddress: email,
+		Token:        token,
+		Device:       device,
+		Location:     location,
+		SignedInAt:   signedInAt,
+	}
+
+	emailBody, emailText, err := mail.GenerateTemplate(alertBody.Email())
+	if err != nil {
+		return err
+	}
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", config.EmailFrom)
+	m.SetHeader("To", alertBody.EmailAddress)
+	m.SetHeader("Subject", "New sign in to your account")
+	m.SetBody("text/plain", emailText)
+	m.AddAlternative("text/html", emailBody)
+
+	if err := Mail.Connection.DialAndSend(m); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package mail
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/thedevsir/frame-backend/config"
@@ -11,7 +12,7 @@ import (
 func TestMakeEmailToken(t *testing.T) {
 
 	assert.NotPanics(t, func() {
-		MakeEmailToken("verify", "userID", "username", "@", []byte("secret"))
+		MakeEmailToken("verify", "ID", "userID", "username", "@", []byte("secret"))
 	})
 }
 
@@ -27,4 +28,32 @@ func TestSendMail(t *testing.T) {
 	t.Run("SendResetMail", func(t *testing.T) {
 		assert.NoError(t, SendResetMail("username", "freshmanlimited@gmail.com", "token"))
 	})
+
+	t.Run("SendLoginMail", func(t *testing.T) {
+		assert.NoError(t, SendLoginMail("username", "freshmanlimited@gmail.com", "token"))
+	})
+
+	t.Run("SendConfirmEmailMail", func(t *testing.T) {
+		assert.NoError(t, SendConfirmEmailMail("username", "freshmanlimited@gmail.com", "token"))
+	})
+
+	t.Run("SendEmailChangingMail", func(t *testing.T) {
+		assert.NoError(t, SendEmailChangingMail("username", "freshmanlimited@gmail.com", "new@gmail.com", "token"))
+	})
+
+	t.Run("SendAccountDeletionMail", func(t *testing.T) {
+		assert.NoError(t, SendAccountDeletionMail("username", "freshmanlimited@gmail.com", "token", time.Now()))
+	})
+
+	t.Run("SendDataExportMail", func(t *testing.T) {
+		assert.NoError(t, SendDataExportMail("username", "freshmanlimited@gmail.com", "https://example.com/export.zip", time.Now()))
+	})
+
+	t.Run("SendAccountLockedMail", func(t *testing.T) {
+		assert.NoError(t, SendAccountLockedMail("username", "freshmanlimited@gmail.com", "token", time.Now()))
+	})
+
+	t.Run("SendSigninAlertMail", func(t *testing.T) {
+		assert.NoError(t, SendSigninAlertMail("username", "freshmanlimited@gmail.com", "token", "Mozilla/5.0", "127.0.0.1", time.Now()))
+	})
 }
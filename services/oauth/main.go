@@ -0,0 +1,162 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	GrantAuthorizationCode = "authorization_code"
+	GrantRefreshToken      = "refresh_token"
+	GrantClientCredentials = "client_credentials"
+
+	ResponseTypeCode    = "code"
+	ChallengeMethodS256 = "S256"
+	TokenTypeBearer     = "Bearer"
+
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// Error is an RFC 6749 error response, it is either written as JSON by the
+// token endpoint or appended to the redirect URI by the authorize endpoint.
+type Error struct {
+	Status      int    `json:"-"`
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+var (
+	ErrInvalidRequest          = &Error{http.StatusBadRequest, "invalid_request", "request is missing a parameter or is malformed"}
+	ErrInvalidClient           = &Error{http.StatusUnauthorized, "invalid_client", "client authentication failed"}
+	ErrInvalidGrant            = &Error{http.StatusBadRequest, "invalid_grant", "authorization grant is invalid, expired or revoked"}
+	ErrUnauthorizedClient      = &Error{http.StatusBadRequest, "unauthorized_client", "client is not allowed to use this grant type"}
+	ErrUnsupportedGrantType    = &Error{http.StatusBadRequest, "unsupported_grant_type", "grant type is not supported"}
+	ErrUnsupportedResponseType = &Error{http.StatusBadRequest, "unsupported_response_type", "response type is not supported"}
+	ErrInvalidScope            = &Error{http.StatusBadRequest, "invalid_scope", "requested scope is not allowed"}
+	ErrAccessDenied            = &Error{http.StatusForbidden, "access_denied", "resource owner denied the request"}
+	ErrServerError             = &Error{http.StatusInternalServerError, "server_error", "internal server error"}
+
+	// RFC 6750 errors of the resource endpoints
+	ErrInvalidToken      = &Error{http.StatusUnauthorized, "invalid_token", "access token is invalid, expired or revoked"}
+	ErrInsufficientScope = &Error{http.StatusForbidden, "insufficient_scope", "access token does not cover the required scope"}
+)
+
+// ParseScope splits a space delimited scope parameter, duplicates are dropped.
+func ParseScope(scope string) []string {
+
+	scopes := []string{}
+	for _, s := range strings.Fields(scope) {
+		if !Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+
+	return scopes
+}
+
+func FormatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+func Contains(scopes []string, scope string) bool {
+
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Covers reports whether every requested scope is part of granted.
+func Covers(granted, requested []string) bool {
+
+	for _, s := range requested {
+		if !Contains(granted, s) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ValidCodeVerifier checks the RFC 7636 verifier syntax, 43 to 128
+// characters of [A-Z] / [a-z] / [0-9] / "-" / "." / "_" / "~".
+func ValidCodeVerifier(verifier string) bool {
+
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+
+	for _, r := range verifier {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case r == '-', r == '.', r == '_', r == '~':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// CodeChallenge derives the S256 challenge of a verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyCodeChallenge only accepts S256, the plain method would leak the
+// verifier through the authorization request.
+func VerifyCodeChallenge(verifier, challenge, method string) bool {
+
+	if method != ChallengeMethodS256 || !ValidCodeVerifier(verifier) {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(CodeChallenge(verifier)), []byte(challenge)) == 1
+}
+
+// MatchRedirectURI compares redirect URIs exactly as registered.
+func MatchRedirectURI(registered []string, redirectURI string) bool {
+	return redirectURI != "" && Contains(registered, redirectURI)
+}
+
+// RedirectURL appends params to the query of a registered redirect URI.
+func RedirectURL(redirectURI string, params url.Values) (string, error) {
+
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	for k, v := range params {
+		query[k] = v
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// ErrorRedirectURL builds the redirect that reports err back to the client.
+func ErrorRedirectURL(redirectURI, state string, err *Error) (string, error) {
+
+	params := url.Values{"error": {err.Code}, "error_description": {err.Description}}
+	if state != "" {
+		params.Set("state", state)
+	}
+
+	return RedirectURL(redirectURI, params)
+}
@@ -0,0 +1,81 @@
+package oauth
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScope(t *testing.T) {
+
+	t.Run("ParseScope", func(t *testing.T) {
+		assert.Equal(t, []string{"openid", "profile"}, ParseScope(" openid profile  openid "))
+		assert.Empty(t, ParseScope(""))
+	})
+
+	t.Run("FormatScope", func(t *testing.T) {
+		assert.Equal(t, "openid profile", FormatScope([]string{"openid", "profile"}))
+	})
+
+	t.Run("Covers", func(t *testing.T) {
+		assert.True(t, Covers([]string{"openid", "profile"}, []string{"profile"}))
+		assert.True(t, Covers([]string{"openid"}, []string{}))
+		assert.False(t, Covers([]string{"openid"}, []string{"openid", "email"}))
+	})
+}
+
+func TestPKCE(t *testing.T) {
+
+	// RFC 7636 appendix B
+	verifier := "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	challenge := "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+
+	t.Run("CodeChallenge", func(t *testing.T) {
+		assert.Equal(t, challenge, CodeChallenge(verifier))
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		assert.True(t, VerifyCodeChallenge(verifier, challenge, ChallengeMethodS256))
+	})
+
+	t.Run("PlainMethod", func(t *testing.T) {
+		assert.False(t, VerifyCodeChallenge(verifier, verifier, "plain"))
+	})
+
+	t.Run("WrongVerifier", func(t *testing.T) {
+		assert.False(t, VerifyCodeChallenge(strings.Repeat("a", 43), challenge, ChallengeMethodS256))
+	})
+
+	t.Run("ValidCodeVerifier", func(t *testing.T) {
+		assert.False(t, ValidCodeVerifier(strings.Repeat("a", 42)))
+		assert.False(t, ValidCodeVerifier(strings.Repeat("a", 129)))
+		assert.False(t, ValidCodeVerifier(strings.Repeat("a", 42)+"+"))
+		assert.True(t, ValidCodeVerifier(strings.Repeat("a", 40)+"-._~"))
+	})
+}
+
+func TestRedirect(t *testing.T) {
+
+	t.Run("MatchRedirectURI", func(t *testing.T) {
+		registered := []string{"https://app.example/callback"}
+		assert.True(t, MatchRedirectURI(registered, "https://app.example/callback"))
+		assert.False(t, MatchRedirectURI(registered, "https://app.example/callback/"))
+		assert.False(t, MatchRedirectURI(registered, ""))
+	})
+
+	t.Run("RedirectURL", func(t *testing.T) {
+		redirect, err := RedirectURL("https://app.example/callback?app=1", url.Values{"code": {"abc"}})
+		assert.Nil(t, err)
+		assert.Equal(t, "https://app.example/callback?app=1&code=abc", redirect)
+	})
+
+	t.Run("ErrorRedirectURL", func(t *testing.T) {
+		redirect, err := ErrorRedirectURL("https://app.example/callback", "xyz", ErrAccessDenied)
+		assert.Nil(t, err)
+		u, _ := url.Parse(redirect)
+		assert.Equal(t, "access_denied", u.Query().Get("error"))
+		assert.Equal(t, "xyz", u.Query().Get("state"))
+	})
+}
@@ -0,0 +1,93 @@
+package password
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/config"
+)
+
+type (
+	// Input is what the rules check a new password against, History holds
+	// the hashes of the passwords the user must not use again.
+	Input struct {
+		Password string
+		Username string
+		Email    string
+		History  []string
+	}
+	Violation struct {
+		Rule    string `json:"rule"`
+		Message string `json:"message"`
+	}
+	Rule interface {
+		Check(input Input) *Violation
+	}
+	Policy struct {
+		Rules []Rule
+	}
+)
+
+// Default is the policy every password set through the API has to pass.
+var Default = &Policy{}
+
+// Composer builds the default policy from the configuration, the banned
+// and breached rules are left out when their files are not configured.
+func Composer() {
+
+	rules := []Rule{
+		Length{Min: config.PasswordMinLength, Max: config.PasswordMaxLength},
+		Strength{MinScore: config.PasswordMinScore},
+	}
+
+	if config.PasswordBannedList != "" {
+		banned, err := LoadBanned(config.PasswordBannedList)
+		if err != nil {
+			panic(err)
+		}
+		rules = append(rules, banned)
+	}
+
+	if config.PasswordBreachedPath != "" {
+		breached, err := OpenBreached(config.PasswordBreachedPath)
+		if err != nil {
+			panic(err)
+		}
+		rules = append(rules, breached)
+	}
+
+	if config.PasswordHistory > 0 {
+		rules = append(rules, History{})
+	}
+
+	Default = NewPolicy(rules...)
+}
+
+func NewPolicy(rules ...Rule) *Policy {
+	return &Policy{Rules: rules}
+}
+
+// Check runs every rule, so all the violations are reported at once.
+func (p *Policy) Check(input Input) []Violation {
+
+	violations := []Violation{}
+	for _, rule := range p.Rules {
+		if violation := rule.Check(input); violation != nil {
+			violations = append(violations, *violation)
+		}
+	}
+
+	return violations
+}
+
+// Validate checks a password against the default policy, the violations
+// are the message of the error.
+func Validate(input Input) error {
+
+	violations := Default.Check(input)
+	if len(violations) > 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, violations)
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package password
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/services/encrypt"
+)
+
+func rules(violations []Violation) []string {
+
+	names := []string{}
+	for _, violation := range violations {
+		names = append(names, violation.Rule)
+	}
+
+	return names
+}
+
+func TestPolicy(t *testing.T) {
+
+	t.Run("Length", func(t *testing.T) {
+
+		policy := NewPolicy(Length{Min: 8, Max: 12})
+		assert.Equal(t, []string{"length"}, rules(policy.Check(Input{Password: "short"})))
+		assert.Equal(t, []string{"length"}, rules(policy.Check(Input{Password: "much too long password"})))
+		assert.Empty(t, policy.Check(Input{Password: "just right"}))
+	})
+
+	t.Run("Strength", func(t *testing.T) {
+
+		policy := NewPolicy(Strength{MinScore: 2})
+		assert.Equal(t, []string{"strength"}, rules(policy.Check(Input{Password: "12345678"})))
+		assert.Equal(t, []string{"strength"}, rules(policy.Check(Input{Password: "amirbrad", Username: "amirbrad"})))
+		assert.Empty(t, policy.Check(Input{Password: "Tr0ub4dor&3"}))
+	})
+
+	t.Run("Banned", func(t *testing.T) {
+
+		path := filepath.Join(os.TempDir(), "banned.txt")
+		ioutil.WriteFile(path, []byte("password\n\nLetMeIn\n"), 0600)
+		defer os.Remove(path)
+
+		banned, err := LoadBanned(path)
+		if assert.Nil(t, err) {
+			policy := NewPolicy(banned)
+			assert.Equal(t, []string{"banned"}, rules(policy.Check(Input{Password: "letmein"})))
+			assert.Empty(t, policy.Check(Input{Password: "let me in"}))
+		}
+	})
+
+	t.Run("Breached", func(t *testing.T) {
+
+		dir, _ := ioutil.TempDir("", "breached")
+		defer os.RemoveAll(dir)
+
+		// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+		ioutil.WriteFile(filepath.Join(dir, "5BAA6.txt"), []byte("003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\r\n"), 0600)
+
+		policy := NewPolicy(Breached{Path: dir})
+		assert.Equal(t, []string{"breached"}, rules(policy.Check(Input{Password: "password"})))
+		assert.Empty(t, policy.Check(Input{Password: "not in the corpus"}))
+
+		t.Run("Unreadable", func(t *testing.T) {
+
+			// a range file that can not be read fails closed
+			os.Remove(filepath.Join(dir, "5BAA6.txt"))
+			os.Mkdir(filepath.Join(dir, "5BAA6.txt"), 0700)
+			assert.Equal(t, []string{"breached"}, rules(policy.Check(Input{Password: "password"})))
+		})
+
+		t.Run("OpenBreached", func(t *testing.T) {
+
+			_, err := OpenBreached(dir)
+			assert.Nil(t, err)
+
+			_, err = OpenBreached(filepath.Join(dir, "missing"))
+			assert.NotNil(t, err)
+		})
+	})
+
+	t.Run("History", func(t *testing.T) {
+
+		hash, _ := encrypt.Hash("12345678")
+		policy := NewPolicy(History{})
+		assert.Equal(t, []string{"history"}, rules(policy.Check(Input{Password: "12345678", History: []string{hash}})))
+		assert.Empty(t, policy.Check(Input{Password: "87654321", History: []string{hash}}))
+	})
+
+	t.Run("Validate", func(t *testing.T) {
+
+		Default = NewPolicy(Length{Min: 8}, NewBanned("password"))
+		defer func() { Default = &Policy{} }()
+
+		err := Validate(Input{Password: "pass"})
+		he, ok := err.(*echo.HTTPError)
+		if assert.True(t, ok) {
+			assert.Equal(t, 400, he.Code)
+			assert.Equal(t, []string{"length"}, rules(he.Message.([]Violation)))
+		}
+
+		assert.Nil(t, Validate(Input{Password: "long enough"}))
+	})
+}
@@ -0,0 +1,160 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/thedevsir/frame-backend/services/encrypt"
+)
+
+type (
+	Length struct {
+		Min int
+		Max int
+	}
+	Strength struct {
+		MinScore int
+	}
+	Banned struct {
+		words map[string]bool
+	}
+	// Breached looks passwords up in an offline copy of a breached password
+	// corpus split by the first five characters of the SHA-1 hash, the way
+	// the k-anonymity range API serves it: <Path>/<PREFIX>.txt holds one
+	// "SUFFIX:COUNT" line per hash.
+	Breached struct {
+		Path string
+	}
+	History struct{}
+)
+
+func (l Length) Check(input Input) *Violation {
+
+	length := utf8.RuneCountInString(input.Password)
+	switch {
+	case length < l.Min:
+		return &Violation{Rule: "length", Message: fmt.Sprintf("password must be at least %d characters", l.Min)}
+	case l.Max > 0 && length > l.Max:
+		return &Violation{Rule: "length", Message: fmt.Sprintf("password must be at most %d characters", l.Max)}
+	default:
+		return nil
+	}
+}
+
+func (s Strength) Check(input Input) *Violation {
+
+	if Score(input.Password, input.Username, strings.Split(input.Email, "@")[0]) < s.MinScore {
+		return &Violation{Rule: "strength", Message: "password is too easy to guess"}
+	}
+
+	return nil
+}
+
+// LoadBanned reads a list of banned passwords, one per line.
+func LoadBanned(path string) (*Banned, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	banned := &Banned{words: map[string]bool{}}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if word := strings.TrimSpace(scanner.Text()); word != "" {
+			banned.words[strings.ToLower(word)] = true
+		}
+	}
+
+	return banned, scanner.Err()
+}
+
+func NewBanned(words ...string) *Banned {
+
+	banned := &Banned{words: map[string]bool{}}
+	for _, word := range words {
+		banned.words[strings.ToLower(word)] = true
+	}
+
+	return banned
+}
+
+func (b *Banned) Check(input Input) *Violation {
+
+	if b.words[strings.ToLower(input.Password)] {
+		return &Violation{Rule: "banned", Message: "password is too common"}
+	}
+
+	return nil
+}
+
+// OpenBreached checks that path is a directory the breached rule can read
+// its range files from.
+func OpenBreached(path string) (Breached, error) {
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Breached{}, err
+	}
+
+	if !info.IsDir() {
+		return Breached{}, fmt.Errorf("password: %s is not a directory", path)
+	}
+
+	return Breached{Path: path}, nil
+}
+
+// Check rejects the password when the corpus can not be read, a broken
+// corpus must not let breached passwords through.
+func (b Breached) Check(input Input) *Violation {
+
+	sum := sha1.Sum([]byte(input.Password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	unavailable := &Violation{Rule: "breached", Message: "password could not be checked against breached passwords, try again later"}
+
+	// a missing range file means no breached hash has that prefix
+	file, err := os.Open(filepath.Join(b.Path, hash[:5]+".txt"))
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		log.Println(err)
+		return unavailable
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		suffix := strings.SplitN(strings.TrimSpace(scanner.Text()), ":", 2)[0]
+		if strings.ToUpper(suffix) == hash[5:] {
+			return &Violation{Rule: "breached", Message: "password has appeared in a data breach"}
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		log.Println(err)
+		return unavailable
+	}
+
+	return nil
+}
+
+func (History) Check(input Input) *Violation {
+
+	for _, hash := range input.History {
+		if encrypt.CheckHash(input.Password, hash) {
+			return &Violation{Rule: "history", Message: "password was used recently"}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package password
+
+import (
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Score estimates how hard a password is to guess, from 0 to 4. The bits
+// of entropy of its character classes are counted over an effective length
+// in which repeated characters, runs like "1234" or "cba" and the user's own
+// inputs add nothing.
+func Score(password string, inputs ...string) int {
+
+	lower := strings.ToLower(password)
+	for _, input := range inputs {
+		if input = strings.ToLower(input); utf8.RuneCountInString(input) >= 3 {
+			lower = strings.Replace(lower, input, "\x00", -1)
+		}
+	}
+
+	runes := []rune(lower)
+	effective, run, step := 0, 0, 0
+	for i, r := range runes {
+		if i > 0 {
+			if d := int(r - runes[i-1]); d >= -1 && d <= 1 && (run == 1 || d == step) {
+				step = d
+				run++
+				continue
+			}
+		}
+		effective++
+		run = 1
+	}
+
+	if effective == 0 {
+		return 0
+	}
+
+	bits := float64(effective) * math.Log2(float64(pool(password)))
+	switch {
+	case bits < 28:
+		return 0
+	case bits < 36:
+		return 1
+	case bits < 50:
+		return 2
+	case bits < 64:
+		return 3
+	default:
+		return 4
+	}
+}
+
+// pool is the number of characters in the classes the password uses.
+func pool(password string) int {
+
+	var lower, upper, digit, symbol, other bool
+	for _, r := range password {
+		switch {
+		case r > unicode.MaxASCII:
+			other = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	size := 0
+	if lower {
+		size += 26
+	}
+	if upper {
+		size += 26
+	}
+	if digit {
+		size += 10
+	}
+	if symbol {
+		size += 33
+	}
+	if other {
+		size += 100
+	}
+
+	return size
+}
@@ -0,0 +1,20 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScore(t *testing.T) {
+
+	assert.Equal(t, 0, Score(""))
+	assert.Equal(t, 0, Score("aaaaaaaaaaaa"))
+	assert.Equal(t, 0, Score("1234567890"))
+	assert.Equal(t, 0, Score("abcdefghij"))
+	assert.Equal(t, 2, Score("sjdkfhqwp"))
+	assert.Equal(t, 4, Score("Tr0ub4dor&3-staple"))
+
+	// the user's own inputs do not make a password stronger
+	assert.True(t, Score("amirbrad2019") > Score("amirbrad2019", "amirbrad"))
+}
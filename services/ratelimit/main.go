@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/thedevsir/frame-backend/config"
+)
+
+const (
+	Signup = "signup"
+	Resend = "resend"
+	Forgot = "forgot"
+	Signin = "signin"
+	Avatar = "avatar"
+)
+
+// Policy allows Limit hits on a key per Window, a Limit of zero lets every
+// hit through.
+type Policy struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Limiter counts hits with a sliding window: the hits of the previous
+// window are weighted by how much of it the last Window still covers.
+type Limiter interface {
+	// Take counts a hit on key and returns zero when it is allowed,
+	// otherwise how long to wait before trying again.
+	Take(key string, policy Policy) (time.Duration, error)
+	// Peek returns the wait of a hit on key without counting it.
+	Peek(key string, policy Policy) (time.Duration, error)
+}
+
+var (
+	// Store keeps the hits, it is in memory until Composer picks the store
+	// of the config.
+	Store Limiter = NewMemory()
+	// Policies are the named limits of routes.
+	Policies = map[string]Policy{}
+)
+
+func Composer() {
+
+	switch config.RateLimitStore {
+	case "", "memory":
+		Store = NewMemory()
+	case "mongo":
+		Store = NewMongo()
+	default:
+		panic("ratelimit: unknown store " + config.RateLimitStore)
+	}
+
+	for name, rate := range map[string]config.Rate{
+		Signup: config.RateLimitSignup,
+		Resend: config.RateLimitResend,
+		Forgot: config.RateLimitForgot,
+		Signin: config.RateLimitSignin,
+		Avatar: config.RateLimitAvatar,
+	} {
+		Policies[name] = Policy{Limit: rate.Limit, Window: rate.Window}
+	}
+}
+
+// RetryAfter is a wait as the whole seconds of a Retry-After header.
+func RetryAfter(wait time.Duration) string {
+	return strconv.Itoa(int((wait + time.Second - 1) / time.Second))
+}
+
+// window is the start of the window now falls in.
+func window(now time.Time, policy Policy) time.Time {
+	return now.Truncate(policy.Window)
+}
+
+// wait is how long the next hit has to wait when previous and current hits
+// were counted in the windows around now.
+func wait(now time.Time, policy Policy, previous, current int) time.Duration {
+
+	if policy.Limit <= 0 {
+		return 0
+	}
+
+	elapsed := now.Sub(window(now, policy))
+	weight := 1 - float64(elapsed)/float64(policy.Window)
+	if float64(previous)*weight+float64(current+1) <= float64(policy.Limit) {
+		return 0
+	}
+
+	// the previous window slides out of sight
+	if current < policy.Limit {
+		fits := 1 - float64(policy.Limit-current-1)/float64(previous)
+		return share(fits, policy) - elapsed
+	}
+
+	// the current window has to slide out of sight with the next one
+	fits := 1 - float64(policy.Limit-1)/float64(current)
+	return policy.Window - elapsed + share(fits, policy)
+}
+
+func share(fraction float64, policy Policy) time.Duration {
+	return time.Duration(math.Round(fraction * float64(policy.Window)))
+}
+
+// taken is the wait a hit gets from Take once it is counted in current,
+// a rejected hit counts against the next one too.
+func taken(now time.Time, policy Policy, previous, current int) time.Duration {
+
+	if wait(now, policy, previous, current-1) == 0 {
+		return 0
+	}
+
+	return wait(now, policy, previous, current)
+}
@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemory(t *testing.T) {
+
+	policy := Policy{Limit: 3, Window: time.Minute}
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemory()
+	store.now = func() time.Time { return now }
+
+	t.Run("Take", func(t *testing.T) {
+
+		for i := 0; i < policy.Limit; i++ {
+			wait, err := store.Take("key", policy)
+			assert.Nil(t, err)
+			assert.Zero(t, wait)
+		}
+
+		wait, _ := store.Peek("key", policy)
+		assert.Equal(t, time.Minute+time.Minute/3, wait)
+
+		wait, _ = store.Take("key", policy)
+		assert.Equal(t, time.Minute+time.Minute/2, wait)
+
+		// keys are counted apart
+		wait, _ = store.Take("other", policy)
+		assert.Zero(t, wait)
+	})
+
+	t.Run("Slide", func(t *testing.T) {
+
+		// four hits of the last window weigh three at a quarter into this one
+		now = now.Add(time.Minute + time.Minute/4)
+		wait, _ := store.Peek("key", policy)
+		assert.Equal(t, time.Minute/2-time.Minute/4, wait)
+
+		now = now.Add(time.Minute / 4)
+		wait, _ = store.Take("key", policy)
+		assert.Zero(t, wait)
+	})
+
+	t.Run("Expire", func(t *testing.T) {
+
+		now = now.Add(2 * time.Minute)
+		for i := 0; i < policy.Limit; i++ {
+			wait, _ := store.Take("key", policy)
+			assert.Zero(t, wait)
+		}
+
+		_, ok := store.counters["other"]
+		assert.False(t, ok)
+	})
+
+	t.Run("Unlimited", func(t *testing.T) {
+
+		for i := 0; i < 10; i++ {
+			wait, _ := store.Take("unlimited", Policy{Window: time.Minute})
+			assert.Zero(t, wait)
+		}
+	})
+}
+
+func TestRetryAfter(t *testing.T) {
+
+	assert.Equal(t, "1", RetryAfter(time.Millisecond))
+	assert.Equal(t, "60", RetryAfter(time.Minute))
+	assert.Equal(t, "61", RetryAfter(time.Minute+time.Millisecond))
+}
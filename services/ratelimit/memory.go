@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// hits are swept once they are out of every window this often
+const sweepInterval = time.Minute
+
+type counter struct {
+	start    time.Time
+	window   time.Duration
+	previous int
+	current  int
+}
+
+// Memory keeps the hits of one instance, for several instances use Mongo.
+type Memory struct {
+	mu       sync.Mutex
+	counters map[string]*counter
+	swept    time.Time
+	now      func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{counters: map[string]*counter{}, now: time.Now}
+}
+
+func (m *Memory) Take(key string, policy Policy) (time.Duration, error) {
+	return m.hit(key, policy, true)
+}
+
+func (m *Memory) Peek(key string, policy Policy) (time.Duration, error) {
+	return m.hit(key, policy, false)
+}
+
+func (m *Memory) hit(key string, policy Policy, take bool) (time.Duration, error) {
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	start := window(now, policy)
+	c, ok := m.counters[key]
+	switch {
+	case !ok:
+		c = &counter{start: start, window: policy.Window}
+		m.counters[key] = c
+	case c.start.Equal(start.Add(-policy.Window)):
+		c.start, c.previous, c.current = start, c.current, 0
+	case !c.start.Equal(start):
+		c.start, c.previous, c.current = start, 0, 0
+	}
+
+	if !take {
+		return wait(now, policy, c.previous, c.current), nil
+	}
+
+	c.current++
+	return taken(now, policy, c.previous, c.current), nil
+}
+
+func (m *Memory) sweep(now time.Time) {
+
+	if now.Sub(m.swept) < sweepInterval {
+		return
+	}
+	m.swept = now
+
+	for key, c := range m.counters {
+		if now.Sub(c.start) >= 2*c.window {
+			delete(m.counters, key)
+		}
+	}
+}
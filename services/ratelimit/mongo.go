@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/thedevsir/frame-backend/app/model"
+	"github.com/thedevsir/frame-backend/config/database"
+	"github.com/thedevsir/frame-backend/services/errors"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Mongo keeps the hits in the RateLimit collection, shared by every
+// instance.
+type Mongo struct{}
+
+func NewMongo() *Mongo {
+	return &Mongo{}
+}
+
+func (Mongo) Take(key string, policy Policy) (time.Duration, error) {
+
+	rateLimitModel := database.Connection.Model(model.RateLimitCollection)
+	now := time.Now()
+	start := window(now, policy)
+
+	change := mgo.Change{
+		Update: bson.M{
+			"$inc":         bson.M{"count": 1},
+			"$setOnInsert": bson.M{"createdAt": now, "expireAt": start.Add(2 * policy.Window)},
+		},
+		Upsert:    true,
+		ReturnNew: true,
+	}
+
+	current := &model.RateLimit{}
+	_, err := rateLimitModel.Collection.Find(bson.M{"key": key, "start": start}).Apply(change, current)
+	if mgo.IsDup(err) {
+		// another instance inserted the window first
+		_, err = rateLimitModel.Collection.Find(bson.M{"key": key, "start": start}).Apply(change, current)
+	}
+	if err != nil {
+		return 0, errors.ErrInternal
+	}
+
+	previous, err := count(key, start.Add(-policy.Window))
+	if err != nil {
+		return 0, err
+	}
+
+	return taken(now, policy, previous, current.Count), nil
+}
+
+func (Mongo) Peek(key string, policy Policy) (time.Duration, error) {
+
+	now := time.Now()
+	start := window(now, policy)
+
+	current, err := count(key, start)
+	if err != nil {
+		return 0, err
+	}
+
+	previous, err := count(key, start.Add(-policy.Window))
+	if err != nil {
+		return 0, err
+	}
+
+	return wait(now, policy, previous, current), nil
+}
+
+func count(key string, start time.Time) (int, error) {
+
+	rateLimitModel := database.Connection.Model(model.RateLimitCollection)
+	hits := &model.RateLimit{}
+
+	err := rateLimitModel.Collection.Find(bson.M{"key": key, "start": start}).One(hits)
+	switch {
+	case err == mgo.ErrNotFound:
+		return 0, nil
+	case err != nil:
+		return 0, errors.ErrInternal
+	default:
+		return hits.Count, nil
+	}
+}
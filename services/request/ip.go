@@ -0,0 +1,56 @@
+package request
+
+import (
+	"net"
+	"strings"
+
+	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/config"
+)
+
+// ClientIP is the address of the peer, unless the peer is one of the
+// TRUSTED_PROXIES. Behind them it is the right-most X-Forwarded-For hop
+// that is not a trusted proxy, the hops to its left are client supplied.
+func ClientIP(c echo.Context) string {
+
+	req := c.Request()
+
+	peer, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		peer = req.RemoteAddr
+	}
+
+	if !trustedProxy(peer) {
+		return peer
+	}
+
+	hops := strings.Split(req.Header.Get(echo.HeaderXForwardedFor), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !trustedProxy(hop) {
+			return hop
+		}
+		peer = hop
+	}
+
+	return peer
+}
+
+func trustedProxy(address string) bool {
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range config.TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
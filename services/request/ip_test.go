@@ -0,0 +1,50 @@
+package request
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/config"
+)
+
+func TestClientIP(t *testing.T) {
+
+	_, network, _ := net.ParseCIDR("10.0.0.0/8")
+	config.TrustedProxies = []*net.IPNet{network}
+	defer func() { config.TrustedProxies = nil }()
+
+	e := echo.New()
+	clientIP := func(remoteAddr, forwardedFor string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set(echo.HeaderXForwardedFor, forwardedFor)
+		}
+		return ClientIP(e.NewContext(req, httptest.NewRecorder()))
+	}
+
+	t.Run("UntrustedPeer", func(t *testing.T) {
+		assert.Equal(t, "203.0.113.7", clientIP("203.0.113.7:4000", "198.51.100.1"))
+	})
+
+	t.Run("TrustedPeer", func(t *testing.T) {
+		assert.Equal(t, "203.0.113.7", clientIP("10.0.0.1:4000", "203.0.113.7"))
+	})
+
+	t.Run("SpoofedHops", func(t *testing.T) {
+		// the client wrote the left hop, the proxies appended the rest
+		assert.Equal(t, "203.0.113.7", clientIP("10.0.0.1:4000", "198.51.100.1, 203.0.113.7, 10.0.0.2"))
+	})
+
+	t.Run("NoForwardedFor", func(t *testing.T) {
+		assert.Equal(t, "10.0.0.1", clientIP("10.0.0.1:4000", ""))
+	})
+
+	t.Run("OnlyTrustedHops", func(t *testing.T) {
+		assert.Equal(t, "10.0.0.3", clientIP("10.0.0.1:4000", "10.0.0.3, 10.0.0.2"))
+	})
+}
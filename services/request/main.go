@@ -3,15 +3,20 @@ package request
 import (
 	jwt "github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
+	"github.com/thedevsir/frame-backend/app/model"
 	"github.com/thedevsir/frame-backend/services/errors"
 	"github.com/thedevsir/frame-backend/services/response"
 )
 
 type (
+	// User is signed in with a session, or with a personal access token
+	// that only grants its scopes.
 	User struct {
 		Session string
 		SID     string
 		ID      string
+		TokenID string
+		Scopes  []string
 	}
 	Admin struct {
 		Session string
@@ -34,6 +39,14 @@ func GetInputs(c echo.Context, params interface{}) (err error) {
 
 func AuthenticatedUser(c echo.Context) User {
 
+	if token, ok := c.Get("personalToken").(*model.PersonalToken); ok {
+		return User{
+			ID:      token.UserID,
+			TokenID: token.Id.Hex(),
+			Scopes:  token.Scopes,
+		}
+	}
+
 	user := c.Get("user").(*jwt.Token)
 	claims := user.Claims.(jwt.MapClaims)
 
@@ -41,9 +54,21 @@ func AuthenticatedUser(c echo.Context) User {
 		Session: claims["session"].(string),
 		SID:     claims["sid"].(string),
 		ID:      claims["userId"].(string),
+		Scopes:  model.PersonalTokenScopes,
 	}
 }
 
+func (u User) HasScope(scope string) bool {
+
+	for _, s := range u.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
 func AuthenticatedAdmin(c echo.Context) Admin {
 
 	user := c.Get("user").(*jwt.Token)
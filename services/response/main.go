@@ -1,7 +1,6 @@
 package response
 
 import (
-	"fmt"
 	"net/http"
 
 	"github.com/labstack/echo"
@@ -55,7 +54,15 @@ func ErrorHandler(err error, c echo.Context) {
 
 	if he, ok := err.(*echo.HTTPError); ok {
 		m.ErrorCode = he.Code
-		m.Message = fmt.Sprint(he.Message)
+		// structured messages, like password policy violations, stay JSON
+		switch message := he.Message.(type) {
+		case string:
+			m.Message = message
+		case error:
+			m.Message = message.Error()
+		default:
+			m.Message = he.Message
+		}
 	}
 
 	// Send response
@@ -0,0 +1,355 @@
+package social
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/oauth"
+)
+
+const (
+	KindGitHub = "github"
+	KindOIDC   = "oidc"
+)
+
+var (
+	ErrProviderNotFound = errors.New("social: provider is not configured")
+	ErrProviderFailed   = errors.New("social: provider responded with an error")
+	ErrIdentityInvalid  = errors.New("social: provider returned no subject")
+)
+
+// Providers holds the configured upstream identity providers by name.
+var Providers = map[string]*Provider{}
+
+type (
+	// Provider is an upstream OAuth 2.0 or OpenID Connect identity provider.
+	// OIDC providers only need an Issuer, their endpoints are discovered on
+	// first use.
+	Provider struct {
+		Name         string
+		Kind         string
+		ClientID     string
+		ClientSecret string
+		Issuer       string
+		AuthURL      string
+		TokenURL     string
+		UserInfoURL  string
+		EmailsURL    string
+		Scopes       []string
+		Client       *http.Client
+
+		mutex sync.Mutex
+	}
+	// Identity is the account of a user at a provider.
+	Identity struct {
+		Subject       string
+		Username      string
+		Email         string
+		EmailVerified bool
+	}
+	discovery struct {
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		UserInfoEndpoint      string `json:"userinfo_endpoint"`
+	}
+	tokenResponse struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+)
+
+// Composer reads the providers listed in SOCIAL_PROVIDERS. Every provider is
+// configured with SOCIAL_<NAME>_CLIENT_ID and SOCIAL_<NAME>_CLIENT_SECRET,
+// providers other than github and google also need SOCIAL_<NAME>_ISSUER.
+func Composer() {
+
+	Providers = map[string]*Provider{}
+
+	for _, name := range strings.Split(config.SocialProviders, ",") {
+
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "SOCIAL_" + strings.ToUpper(name) + "_"
+		provider := preset(name)
+		provider.ClientID = os.Getenv(prefix + "CLIENT_ID")
+		provider.ClientSecret = os.Getenv(prefix + "CLIENT_SECRET")
+		if issuer := os.Getenv(prefix + "ISSUER"); issuer != "" {
+			provider.Issuer = issuer
+		}
+
+		if provider.ClientID == "" || (provider.Kind == KindOIDC && provider.Issuer == "") {
+			panic(fmt.Sprintf("social: provider %s is not fully configured", name))
+		}
+
+		Providers[name] = provider
+	}
+}
+
+func preset(name string) *Provider {
+
+	switch name {
+	case "github":
+		return &Provider{
+			Name:        name,
+			Kind:        KindGitHub,
+			AuthURL:     "https://github.com/login/oauth/authorize",
+			TokenURL:    "https://github.com/login/oauth/access_token",
+			UserInfoURL: "https://api.github.com/user",
+			EmailsURL:   "https://api.github.com/user/emails",
+			Scopes:      []string{"read:user", "user:email"},
+		}
+	case "google":
+		return &Provider{
+			Name:   name,
+			Kind:   KindOIDC,
+			Issuer: "https://accounts.google.com",
+			Scopes: []string{oauth.ScopeOpenID, oauth.ScopeProfile, oauth.ScopeEmail},
+		}
+	default:
+		return &Provider{
+			Name:   name,
+			Kind:   KindOIDC,
+			Scopes: []string{oauth.ScopeOpenID, oauth.ScopeProfile, oauth.ScopeEmail},
+		}
+	}
+}
+
+func Find(name string) (*Provider, error) {
+
+	provider, ok := Providers[name]
+	if !ok {
+		return nil, ErrProviderNotFound
+	}
+
+	return provider, nil
+}
+
+func (p *Provider) client() *http.Client {
+
+	if p.Client != nil {
+		return p.Client
+	}
+
+	return &http.Client{Timeout: 10 * time.Second}
+}
+
+func (p *Provider) getJSON(request *http.Request, value interface{}) error {
+
+	request.Header.Set("Accept", "application/json")
+
+	response, err := p.client().Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return ErrProviderFailed
+	}
+
+	return json.NewDecoder(response.Body).Decode(value)
+}
+
+// discover resolves the endpoints of an OIDC provider, a failed discovery is
+// retried on the next request.
+func (p *Provider) discover() error {
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.Kind != KindOIDC || p.AuthURL != "" {
+		return nil
+	}
+
+	request, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(p.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return err
+	}
+
+	document := discovery{}
+	if err = p.getJSON(request, &document); err != nil {
+		return err
+	}
+
+	if document.AuthorizationEndpoint == "" || document.TokenEndpoint == "" || document.UserInfoEndpoint == "" {
+		return ErrProviderFailed
+	}
+
+	p.TokenURL = document.TokenEndpoint
+	p.UserInfoURL = document.UserInfoEndpoint
+	p.AuthURL = document.AuthorizationEndpoint
+
+	return nil
+}
+
+// AuthCodeURL is where the user is sent to sign in at the provider.
+func (p *Provider) AuthCodeURL(redirectURI, state, verifier string) (string, error) {
+
+	if err := p.discover(); err != nil {
+		return "", err
+	}
+
+	return oauth.RedirectURL(p.AuthURL, url.Values{
+		"response_type":         {oauth.ResponseTypeCode},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {oauth.FormatScope(p.Scopes)},
+		"state":                 {state},
+		"code_challenge":        {oauth.CodeChallenge(verifier)},
+		"code_challenge_method": {oauth.ChallengeMethodS256},
+	})
+}
+
+// Exchange trades the authorization code for an access token.
+func (p *Provider) Exchange(code, redirectURI, verifier string) (string, error) {
+
+	if err := p.discover(); err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {oauth.GrantAuthorizationCode},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {p.ClientID},
+		"client_secret": {p.ClientSecret},
+		"code_verifier": {verifier},
+	}
+
+	request, err := http.NewRequest(http.MethodPost, p.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	token := tokenResponse{}
+	if err = p.getJSON(request, &token); err != nil {
+		return "", err
+	}
+
+	// GitHub reports errors with a 200 status
+	if token.Error != "" || token.AccessToken == "" {
+		return "", ErrProviderFailed
+	}
+
+	return token.AccessToken, nil
+}
+
+func (p *Provider) get(endpoint, accessToken string, value interface{}) error {
+
+	request, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", oauth.TokenTypeBearer+" "+accessToken)
+
+	return p.getJSON(request, value)
+}
+
+// Identity reads the user the access token belongs to.
+func (p *Provider) Identity(accessToken string) (*Identity, error) {
+
+	if err := p.discover(); err != nil {
+		return nil, err
+	}
+
+	var identity *Identity
+	var err error
+
+	if p.Kind == KindGitHub {
+		identity, err = p.githubIdentity(accessToken)
+	} else {
+		identity, err = p.oidcIdentity(accessToken)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if identity.Subject == "" {
+		return nil, ErrIdentityInvalid
+	}
+
+	identity.Email = strings.ToLower(identity.Email)
+
+	return identity, nil
+}
+
+func (p *Provider) oidcIdentity(accessToken string) (*Identity, error) {
+
+	claims := struct {
+		Subject           string      `json:"sub"`
+		PreferredUsername string      `json:"preferred_username"`
+		Email             string      `json:"email"`
+		EmailVerified     interface{} `json:"email_verified"`
+	}{}
+
+	if err := p.get(p.UserInfoURL, accessToken, &claims); err != nil {
+		return nil, err
+	}
+
+	// Some providers send email_verified as a string
+	verified := false
+	switch value := claims.EmailVerified.(type) {
+	case bool:
+		verified = value
+	case string:
+		verified, _ = strconv.ParseBool(value)
+	}
+
+	return &Identity{
+		Subject:       claims.Subject,
+		Username:      claims.PreferredUsername,
+		Email:         claims.Email,
+		EmailVerified: verified,
+	}, nil
+}
+
+func (p *Provider) githubIdentity(accessToken string) (*Identity, error) {
+
+	user := struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+	}{}
+
+	if err := p.get(p.UserInfoURL, accessToken, &user); err != nil {
+		return nil, err
+	}
+
+	identity := &Identity{Username: user.Login}
+	if user.ID != 0 {
+		identity.Subject = strconv.FormatInt(user.ID, 10)
+	}
+
+	// The profile email is optional and says nothing about verification
+	emails := []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}{}
+
+	if err := p.get(p.EmailsURL, accessToken, &emails); err != nil {
+		return nil, err
+	}
+
+	for _, email := range emails {
+		if email.Primary {
+			identity.Email = email.Email
+			identity.EmailVerified = email.Verified
+		}
+	}
+
+	return identity, nil
+}
@@ -0,0 +1,131 @@
+package social
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/services/test"
+)
+
+func TestOIDCProvider(t *testing.T) {
+
+	fake := test.NewIdentityProvider("client", "secret")
+	defer fake.Close()
+
+	fake.User = test.ProviderUser{Subject: "248289761001", Email: "Jane@Example.com", EmailVerified: true}
+	provider := &Provider{Name: "fake", Kind: KindOIDC, ClientID: "client", ClientSecret: "secret", Issuer: fake.URL, Scopes: []string{"openid", "email"}}
+
+	redirectURI := "https://app.example/social/fake/callback"
+	verifier := strings.Repeat("v", 43)
+
+	authURL, err := provider.AuthCodeURL(redirectURI, "state", verifier)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, strings.HasPrefix(authURL, fake.URL+"/authorize?"))
+
+	params, err := fake.Authorize(authURL)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "state", params.Get("state"))
+
+	t.Run("WrongVerifier", func(t *testing.T) {
+
+		params, _ := fake.Authorize(authURL)
+		_, err := provider.Exchange(params.Get("code"), redirectURI, strings.Repeat("w", 43))
+		assert.Equal(t, ErrProviderFailed, err)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+
+		token, err := provider.Exchange(params.Get("code"), redirectURI, verifier)
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		identity, err := provider.Identity(token)
+		if assert.Nil(t, err) {
+			assert.Equal(t, "248289761001", identity.Subject)
+			assert.Equal(t, "jane@example.com", identity.Email)
+			assert.True(t, identity.EmailVerified)
+		}
+	})
+
+	t.Run("InvalidToken", func(t *testing.T) {
+		_, err := provider.Identity("invalid")
+		assert.Equal(t, ErrProviderFailed, err)
+	})
+}
+
+func TestGitHubProvider(t *testing.T) {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("code") != "code" {
+			w.Write([]byte(`{"error":"bad_verification_code"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"token","token_type":"bearer"}`))
+	})
+	mux.HandleFunc("/user", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":583231,"login":"octocat","email":null}`))
+	})
+	mux.HandleFunc("/user/emails", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"email":"old@example.com","primary":false,"verified":true},{"email":"octocat@github.com","primary":true,"verified":false}]`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	provider := preset("github")
+	provider.AuthURL = server.URL + "/authorize"
+	provider.TokenURL = server.URL + "/token"
+	provider.UserInfoURL = server.URL + "/user"
+	provider.EmailsURL = server.URL + "/user/emails"
+
+	t.Run("AuthCodeURL", func(t *testing.T) {
+
+		authURL, err := provider.AuthCodeURL("https://app.example/callback", "state", strings.Repeat("v", 43))
+		if assert.Nil(t, err) {
+			query, _ := url.Parse(authURL)
+			assert.Equal(t, "read:user user:email", query.Query().Get("scope"))
+		}
+	})
+
+	t.Run("ErrorWithSuccessStatus", func(t *testing.T) {
+		_, err := provider.Exchange("wrong", "https://app.example/callback", "verifier")
+		assert.Equal(t, ErrProviderFailed, err)
+	})
+
+	t.Run("Identity", func(t *testing.T) {
+
+		token, err := provider.Exchange("code", "https://app.example/callback", "verifier")
+		if !assert.Nil(t, err) {
+			return
+		}
+
+		identity, err := provider.Identity(token)
+		if assert.Nil(t, err) {
+			assert.Equal(t, "583231", identity.Subject)
+			assert.Equal(t, "octocat", identity.Username)
+			assert.Equal(t, "octocat@github.com", identity.Email)
+			assert.False(t, identity.EmailVerified)
+		}
+	})
+}
+
+func TestFind(t *testing.T) {
+
+	Providers = map[string]*Provider{"github": preset("github")}
+
+	_, err := Find("github")
+	assert.Nil(t, err)
+
+	_, err = Find("unknown")
+	assert.Equal(t, ErrProviderNotFound, err)
+}
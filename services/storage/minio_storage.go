@@ -2,8 +2,10 @@ package storage
 
 import (
 	"io"
+	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/minio/minio-go"
 	"github.com/thedevsir/frame-backend/config"
@@ -33,6 +35,13 @@ func Composer() {
 			panic(err)
 		}
 	}
+
+	// Create private bucket, its objects are shared with presigned links
+	if config.MinioExportBucket != "" {
+		if err = createBucket(config.MinioExportBucket, ""); err != nil {
+			panic(err)
+		}
+	}
 }
 
 func createBucket(bucketName, policy string) error {
@@ -97,3 +106,35 @@ func GetURL(name, bucketName string) string {
 
 	return "http://" + path.Join(endpoint, bucketName, name)
 }
+
+// Get opens an object, the caller closes it.
+func Get(name, bucketName string) (io.ReadCloser, string, error) {
+
+	info, err := cli.StatObject(bucketName, name, minio.StatObjectOptions{})
+	if err != nil {
+		minioError := minio.ToErrorResponse(err)
+		if minioError.Code == "NoSuchKey" {
+			return nil, "", errors.ErrObjectNotFound
+		}
+		return nil, "", errors.ErrInternal
+	}
+
+	object, err := cli.GetObject(bucketName, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, "", errors.ErrInternal
+	}
+
+	return object, info.ContentType, nil
+}
+
+// GetPresignedURL makes a link that downloads an object of a private bucket
+// until it expires.
+func GetPresignedURL(name, bucketName string, expires time.Duration) (string, error) {
+
+	u, err := cli.PresignedGetObject(bucketName, name, expires, url.Values{})
+	if err != nil {
+		return "", errors.ErrInternal
+	}
+
+	return u.String(), nil
+}
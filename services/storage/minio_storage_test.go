@@ -36,6 +36,32 @@ func TestStorage(t *testing.T) {
 		assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
 	})
 
+	t.Run("Get", func(t *testing.T) {
+
+		t.Run("Success", func(t *testing.T) {
+			object, contentType, err := Get(objName, "avatar")
+			if assert.NoError(t, err) {
+				object.Close()
+				assert.Equal(t, "image/png", contentType)
+			}
+		})
+
+		t.Run("ObjectNotFound", func(t *testing.T) {
+			_, _, err := Get(objName+"_missing", "avatar")
+			assert.Equal(t, errors.ErrObjectNotFound, err)
+		})
+	})
+
+	t.Run("GetPresignedURL", func(t *testing.T) {
+		url, err := GetPresignedURL(objName, "avatar", time.Minute)
+		if assert.NoError(t, err) {
+			resp, err := http.Get(url)
+			assert.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		}
+	})
+
 	t.Run("Delete", func(t *testing.T) {
 
 		t.Run("Success", func(t *testing.T) {
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+)
+
+// Authenticator is a software WebAuthn authenticator, it creates passkeys
+// and signs assertions the way a browser and a platform authenticator do.
+type Authenticator struct {
+	RPID         string
+	Origin       string
+	CredentialID []byte
+	SignCount    uint32
+	Flags        byte
+	signer       crypto.Signer
+	coseKey      map[int]interface{}
+}
+
+const (
+	authenticatorFlags = 0x01 | 0x04 // user present, user verified
+	attestedDataFlag   = 0x40
+)
+
+// NewAuthenticator creates an authenticator with an ES256 key, or an EdDSA
+// key when eddsa is set.
+func NewAuthenticator(rpID, origin string, eddsa bool) *Authenticator {
+
+	a := &Authenticator{
+		RPID:         rpID,
+		Origin:       origin,
+		CredentialID: make([]byte, 32),
+		Flags:        authenticatorFlags,
+	}
+	rand.Read(a.CredentialID)
+
+	if eddsa {
+		public, private, _ := ed25519.GenerateKey(rand.Reader)
+		a.signer = private
+		a.coseKey = map[int]interface{}{1: 1, 3: -8, -1: 6, -2: []byte(public)}
+		return a
+	}
+
+	private, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	x, y := make([]byte, 32), make([]byte, 32)
+	private.X.FillBytes(x)
+	private.Y.FillBytes(y)
+	a.signer = private
+	a.coseKey = map[int]interface{}{1: 2, 3: -7, -1: 1, -2: x, -3: y}
+	return a
+}
+
+func (a *Authenticator) encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (a *Authenticator) clientData(ceremony, challenge string) []byte {
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"type":        ceremony,
+		"challenge":   challenge,
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+	return data
+}
+
+func (a *Authenticator) authenticatorData(flags byte) []byte {
+
+	rpIDHash := sha256.Sum256([]byte(a.RPID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, a.SignCount)
+	return append(data, counter...)
+}
+
+// Register answers a creation ceremony and returns the base64url encoded
+// clientDataJSON and attestationObject.
+func (a *Authenticator) Register(challenge string) (string, string) {
+
+	authData := a.authenticatorData(a.Flags | attestedDataFlag)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	length := make([]byte, 2)
+	binary.BigEndian.PutUint16(length, uint16(len(a.CredentialID)))
+	authData = append(authData, length...)
+	authData = append(authData, a.CredentialID...)
+	authData = append(authData, encodeCBOR(a.coseKey)...)
+
+	attestation := encodeCBOR(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+
+	return a.encode(a.clientData("webauthn.create", challenge)), a.encode(attestation)
+}
+
+// Assert answers a request ceremony and returns the base64url encoded
+// credential ID, clientDataJSON, authenticatorData and signature.
+func (a *Authenticator) Assert(challenge string) (string, string, string, string) {
+
+	a.SignCount++
+	clientData := a.clientData("webauthn.get", challenge)
+	authData := a.authenticatorData(a.Flags)
+
+	clientDataHash := sha256.Sum256(clientData)
+	signed := append(append([]byte{}, authData...), clientDataHash[:]...)
+
+	var signature []byte
+	switch signer := a.signer.(type) {
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(signer, signed)
+	default:
+		digest := sha256.Sum256(signed)
+		signature, _ = signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+
+	return a.encode(a.CredentialID), a.encode(clientData), a.encode(authData), a.encode(signature)
+}
+
+func encodeCBORHead(major byte, n uint64) []byte {
+
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n <= 0xff:
+		return []byte{major<<5 | 24, byte(n)}
+	case n <= 0xffff:
+		head := []byte{major<<5 | 25, 0, 0}
+		binary.BigEndian.PutUint16(head[1:], uint16(n))
+		return head
+	default:
+		head := []byte{major<<5 | 26, 0, 0, 0, 0}
+		binary.BigEndian.PutUint32(head[1:], uint32(n))
+		return head
+	}
+}
+
+// encodeCBOR encodes the few types the authenticator needs, map keys are
+// written in a stable order.
+func encodeCBOR(value interface{}) []byte {
+
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return encodeCBORHead(1, uint64(-1-v))
+		}
+		return encodeCBORHead(0, uint64(v))
+	case []byte:
+		return append(encodeCBORHead(2, uint64(len(v))), v...)
+	case string:
+		return append(encodeCBORHead(3, uint64(len(v))), v...)
+	case map[int]interface{}:
+		keys := make([]int, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Ints(keys)
+		out := encodeCBORHead(5, uint64(len(v)))
+		for _, k := range keys {
+			out = append(out, encodeCBOR(k)...)
+			out = append(out, encodeCBOR(v[k])...)
+		}
+		return out
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := encodeCBORHead(5, uint64(len(v)))
+		for _, k := range keys {
+			out = append(out, encodeCBOR(k)...)
+			out = append(out, encodeCBOR(v[k])...)
+		}
+		return out
+	}
+
+	panic("test: unsupported cbor value")
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 
 	"github.com/labstack/echo"
@@ -47,6 +48,16 @@ func MakeRequest(method, userJSON string) (echo.Context, *httptest.ResponseRecor
 	return c, rec
 }
 
+func MakeURLEncodedRequest(method string, form url.Values) (echo.Context, *httptest.ResponseRecorder) {
+
+	e := echo.New()
+	req := httptest.NewRequest(method, "/", strings.NewReader(form.Encode()))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationForm)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	return c, rec
+}
+
 func MakeFormdataRequest(method string, r io.Reader) (echo.Context, *httptest.ResponseRecorder) {
 
 	e := echo.New()
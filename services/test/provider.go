@@ -0,0 +1,175 @@
+package test
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type (
+	// IdentityProvider is a local OpenID Connect provider. Every
+	// authorization request is approved for the identity in User, so tests
+	// can walk through the whole social signin flow.
+	IdentityProvider struct {
+		*httptest.Server
+		ClientID     string
+		ClientSecret string
+		User         ProviderUser
+
+		mutex  sync.Mutex
+		serial int
+		codes  map[string]providerGrant
+		tokens map[string]ProviderUser
+	}
+	ProviderUser struct {
+		Subject           string `json:"sub"`
+		PreferredUsername string `json:"preferred_username,omitempty"`
+		Email             string `json:"email,omitempty"`
+		EmailVerified     bool   `json:"email_verified"`
+	}
+	providerGrant struct {
+		redirectURI string
+		challenge   string
+		user        ProviderUser
+	}
+)
+
+func NewIdentityProvider(clientID, clientSecret string) *IdentityProvider {
+
+	provider := &IdentityProvider{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		codes:        map[string]providerGrant{},
+		tokens:       map[string]ProviderUser{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.configuration)
+	mux.HandleFunc("/authorize", provider.authorize)
+	mux.HandleFunc("/token", provider.token)
+	mux.HandleFunc("/userinfo", provider.userinfo)
+	provider.Server = httptest.NewServer(mux)
+
+	return provider
+}
+
+func (p *IdentityProvider) next(prefix string) string {
+	p.serial++
+	return prefix + strconv.Itoa(p.serial)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func (p *IdentityProvider) configuration(w http.ResponseWriter, r *http.Request) {
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 p.URL,
+		"authorization_endpoint": p.URL + "/authorize",
+		"token_endpoint":         p.URL + "/token",
+		"userinfo_endpoint":      p.URL + "/userinfo",
+	})
+}
+
+func (p *IdentityProvider) authorize(w http.ResponseWriter, r *http.Request) {
+
+	query := r.URL.Query()
+	if query.Get("client_id") != p.ClientID || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	p.mutex.Lock()
+	code := p.next("code-")
+	p.codes[code] = providerGrant{
+		redirectURI: query.Get("redirect_uri"),
+		challenge:   query.Get("code_challenge"),
+		user:        p.User,
+	}
+	p.mutex.Unlock()
+
+	redirect, _ := url.Parse(query.Get("redirect_uri"))
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", query.Get("state"))
+	redirect.RawQuery = params.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+// Authorize follows the authorization URL and returns the parameters the
+// provider redirects back with.
+func (p *IdentityProvider) Authorize(authURL string) (url.Values, error) {
+
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	response, err := client.Get(authURL)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	redirect, err := response.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	return redirect.Query(), nil
+}
+
+func (p *IdentityProvider) token(w http.ResponseWriter, r *http.Request) {
+
+	r.ParseForm()
+
+	if r.PostForm.Get("client_id") != p.ClientID || r.PostForm.Get("client_secret") != p.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	code := r.PostForm.Get("code")
+	grant, ok := p.codes[code]
+	delete(p.codes, code)
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+
+	if !ok || grant.redirectURI != r.PostForm.Get("redirect_uri") || grant.challenge != challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	token := p.next("token-")
+	p.tokens[token] = grant.user
+
+	writeJSON(w, http.StatusOK, map[string]string{"access_token": token, "token_type": "Bearer"})
+}
+
+func (p *IdentityProvider) userinfo(w http.ResponseWriter, r *http.Request) {
+
+	p.mutex.Lock()
+	user, ok := p.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	p.mutex.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_token"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, user)
+}
@@ -0,0 +1,72 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// RFC 6238 appendix B, SHA1 vectors truncated to six digits
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		assert.Nil(t, err)
+		assert.Equal(t, tt.want, code)
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	now := time.Unix(1111111111, 0)
+
+	t.Run("CurrentStep", func(t *testing.T) {
+		step, ok := Validate(rfcSecret, "050471", now)
+		assert.True(t, ok)
+		assert.Equal(t, Step(now), step)
+	})
+
+	t.Run("PreviousStep", func(t *testing.T) {
+		code, _ := Code(rfcSecret, Step(now)-1)
+		step, ok := Validate(rfcSecret, code, now)
+		assert.True(t, ok)
+		assert.Equal(t, Step(now)-1, step)
+	})
+
+	t.Run("OutOfWindow", func(t *testing.T) {
+		code, _ := Code(rfcSecret, Step(now)-3)
+		_, ok := Validate(rfcSecret, code, now)
+		assert.False(t, ok)
+	})
+
+	t.Run("WrongLength", func(t *testing.T) {
+		_, ok := Validate(rfcSecret, "12345", now)
+		assert.False(t, ok)
+	})
+}
+
+func TestGenerateSecret(t *testing.T) {
+
+	secret, err := GenerateSecret()
+	assert.Nil(t, err)
+	assert.Len(t, secret, 32)
+
+	uri := URI("Frame", "amir", secret)
+	assert.True(t, strings.HasPrefix(uri, "otpauth://totp/Frame:amir?"))
+	assert.Contains(t, uri, "secret="+secret)
+}
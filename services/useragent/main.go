@@ -0,0 +1,153 @@
+package useragent
+
+import (
+	"strings"
+)
+
+const (
+	Desktop = "desktop"
+	Mobile  = "mobile"
+	Tablet  = "tablet"
+	Bot     = "bot"
+)
+
+// Agent is what a User-Agent header tells about the client, fields are
+// empty when it does not tell.
+type Agent struct {
+	Browser string
+	OS      string
+	Device  string
+}
+
+type rule struct {
+	name   string
+	tokens []string
+	// version is read after the token instead of the one that matched
+	version string
+}
+
+// browsers are matched in order, most browsers also claim to be Chrome or
+// Safari so those come last.
+var browsers = []rule{
+	{name: "Edge", tokens: []string{"Edg/", "EdgA/", "EdgiOS/", "Edge/"}},
+	{name: "Opera", tokens: []string{"OPR/", "OPT/", "Opera/"}},
+	{name: "Samsung Internet", tokens: []string{"SamsungBrowser/"}},
+	{name: "Yandex", tokens: []string{"YaBrowser/"}},
+	{name: "Vivaldi", tokens: []string{"Vivaldi/"}},
+	{name: "Firefox", tokens: []string{"Firefox/", "FxiOS/"}},
+	{name: "Chrome", tokens: []string{"Chrome/", "CriOS/"}},
+	{name: "Internet Explorer", tokens: []string{"MSIE ", "Trident/"}, version: "rv:"},
+	{name: "Safari", tokens: []string{"Safari/"}, version: "Version/"},
+}
+
+var systems = []rule{
+	{name: "iOS", tokens: []string{"iPhone", "iPad", "iPod"}},
+	{name: "Android", tokens: []string{"Android"}},
+	{name: "Windows", tokens: []string{"Windows"}},
+	{name: "ChromeOS", tokens: []string{"CrOS"}},
+	{name: "macOS", tokens: []string{"Macintosh", "Mac OS X"}},
+	{name: "Linux", tokens: []string{"Linux", "X11"}},
+}
+
+var bots = []string{"bot", "crawl", "spider", "slurp", "curl/", "wget/", "python-requests/", "go-http-client/"}
+
+func Parse(header string) Agent {
+
+	agent := Agent{}
+	if header == "" {
+		return agent
+	}
+
+	if name, ok := bot(header); ok {
+		agent.Browser = name
+		agent.Device = Bot
+		return agent
+	}
+
+	for _, browser := range browsers {
+		if version, ok := browser.match(header); ok {
+			agent.Browser = strings.TrimSpace(browser.name + " " + version)
+			break
+		}
+	}
+
+	for _, system := range systems {
+		if _, ok := system.match(header); ok {
+			agent.OS = system.name
+			break
+		}
+	}
+
+	switch {
+	case strings.Contains(header, "iPad") || strings.Contains(header, "Tablet") ||
+		agent.OS == "Android" && !strings.Contains(header, "Mobile"):
+		agent.Device = Tablet
+	case strings.Contains(header, "Mobi") || strings.Contains(header, "iPhone") || strings.Contains(header, "iPod"):
+		agent.Device = Mobile
+	case agent.Browser != "" || agent.OS != "":
+		agent.Device = Desktop
+	}
+
+	return agent
+}
+
+// bot names the crawler or the HTTP library that sent header.
+func bot(header string) (string, bool) {
+
+	fields := strings.FieldsFunc(header, func(c rune) bool {
+		return c == ' ' || c == ';' || c == '(' || c == ')'
+	})
+
+	for _, field := range fields {
+		lower := strings.ToLower(field)
+		for _, token := range bots {
+			if strings.Contains(lower, token) {
+				return strings.SplitN(field, "/", 2)[0], true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// match reports whether one of the tokens is in header, with the major
+// version that follows it.
+func (r rule) match(header string) (string, bool) {
+
+	for _, token := range r.tokens {
+		i := strings.Index(header, token)
+		if i == -1 {
+			continue
+		}
+
+		rest := header[i+len(token):]
+		if r.version != "" {
+			if j := strings.Index(header, r.version); j != -1 {
+				rest = header[j+len(r.version):]
+			}
+		}
+
+		end := strings.IndexFunc(rest, func(c rune) bool { return c < '0' || c > '9' })
+		if end == -1 {
+			end = len(rest)
+		}
+
+		return rest[:end], true
+	}
+
+	return "", false
+}
+
+// String reads like "Firefox 120 on Windows", it is empty when nothing is
+// known of the client.
+func (a Agent) String() string {
+
+	switch {
+	case a.Browser != "" && a.OS != "":
+		return a.Browser + " on " + a.OS
+	case a.Browser != "":
+		return a.Browser
+	default:
+		return a.OS
+	}
+}
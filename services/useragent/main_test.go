@@ -0,0 +1,40 @@
+package useragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+
+	cases := map[string]Agent{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36":                         {"Chrome 120", "Windows", Desktop},
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91":       {"Edge 120", "Windows", Desktop},
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:121.0) Gecko/20100101 Firefox/121.0":                                                    {"Firefox 121", "macOS", Desktop},
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15":                   {"Safari 17", "macOS", Desktop},
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Mobile/15E148 Safari/604.1": {"Safari 17", "iOS", Mobile},
+		"Mozilla/5.0 (iPad; CPU OS 17_1 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1":  {"Chrome 120", "iOS", Tablet},
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36":              {"Chrome 120", "Android", Mobile},
+		"Mozilla/5.0 (Linux; Android 13; SM-X700) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Safari/537.36":      {"Samsung Internet 23", "Android", Tablet},
+		"Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36":                          {"Chrome 120", "ChromeOS", Desktop},
+		"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 OPR/106.0.0.0":                     {"Opera 106", "Linux", Desktop},
+		"Mozilla/5.0 (Windows NT 10.0; Trident/7.0; rv:11.0) like Gecko":                                                                          {"Internet Explorer 11", "Windows", Desktop},
+		"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)":                                                                {"Googlebot", "", Bot},
+		"curl/8.4.0":       {"curl", "", Bot},
+		":::USER-AGENT:::": {},
+		"":                 {},
+	}
+
+	for header, agent := range cases {
+		assert.Equal(t, agent, Parse(header), header)
+	}
+}
+
+func TestString(t *testing.T) {
+
+	assert.Equal(t, "Firefox 121 on macOS", Agent{Browser: "Firefox 121", OS: "macOS"}.String())
+	assert.Equal(t, "curl", Agent{Browser: "curl", Device: Bot}.String())
+	assert.Equal(t, "Android", Agent{OS: "Android"}.String())
+	assert.Empty(t, Agent{}.String())
+}
@@ -0,0 +1,64 @@
+package usernames
+
+import (
+	"bufio"
+	"os"
+	"strings"
+
+	"github.com/thedevsir/frame-backend/config"
+	"github.com/thedevsir/frame-backend/services/errors"
+)
+
+// Reserved names can not be taken by users, admins can still give them.
+var Reserved = map[string]bool{}
+
+// Composer loads the reserved list, one name per line, lines starting
+// with # are comments.
+func Composer() {
+
+	if config.UsernameReservedList == "" {
+		return
+	}
+
+	reserved, err := LoadReserved(config.UsernameReservedList)
+	if err != nil {
+		panic(err)
+	}
+
+	Reserved = reserved
+}
+
+func LoadReserved(path string) (map[string]bool, error) {
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reserved := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if name := strings.TrimSpace(scanner.Text()); name != "" && !strings.HasPrefix(name, "#") {
+			reserved[Canonical(name)] = true
+		}
+	}
+
+	return reserved, scanner.Err()
+}
+
+// Canonical is the form usernames are stored and looked up in.
+func Canonical(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
+
+// Check rejects the names users can not choose for themselves, whether the
+// name is free is up to repository.CheckUsername.
+func Check(username string) error {
+
+	if Reserved[Canonical(username)] {
+		return errors.ErrUsernameReserved
+	}
+
+	return nil
+}
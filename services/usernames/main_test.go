@@ -0,0 +1,39 @@
+package usernames
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/services/errors"
+)
+
+func TestUsernames(t *testing.T) {
+
+	t.Run("Canonical", func(t *testing.T) {
+		assert.Equal(t, "amir", Canonical(" Amir "))
+	})
+
+	t.Run("LoadReserved", func(t *testing.T) {
+
+		path := filepath.Join(os.TempDir(), "reserved.txt")
+		ioutil.WriteFile(path, []byte("# comment\nAdmin\n\nroot\n"), 0600)
+		defer os.Remove(path)
+
+		reserved, err := LoadReserved(path)
+		if assert.Nil(t, err) {
+			assert.Equal(t, map[string]bool{"admin": true, "root": true}, reserved)
+		}
+	})
+
+	t.Run("Check", func(t *testing.T) {
+
+		Reserved = map[string]bool{"admin": true}
+		defer func() { Reserved = map[string]bool{} }()
+
+		assert.Equal(t, errors.ErrUsernameReserved, Check("ADMIN"))
+		assert.Nil(t, Check("admins"))
+	})
+}
@@ -0,0 +1,129 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// COSE algorithm identifiers
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+	AlgRS256 = -257
+)
+
+// COSE key parameters, RFC 8152 section 13
+const (
+	coseKty = 1
+	coseAlg = 3
+	coseCrv = -1
+	coseX   = -2
+	coseY   = -3
+	coseN   = -1
+	coseE   = -2
+
+	coseKtyOKP = 1
+	coseKtyEC2 = 2
+	coseKtyRSA = 3
+
+	coseCrvP256    = 1
+	coseCrvEd25519 = 6
+)
+
+var (
+	ErrUnsupportedKey = errors.New("credential public key is not supported")
+	ErrSignature      = errors.New("signature is not valid")
+)
+
+var SupportedAlgorithms = []int{AlgES256, AlgEdDSA, AlgRS256}
+
+type publicKey struct {
+	alg int
+	key crypto.PublicKey
+}
+
+func parsePublicKey(cose []byte) (*publicKey, error) {
+
+	value, rest, err := decodeCBOR(cose)
+	if err != nil || len(rest) != 0 {
+		return nil, ErrUnsupportedKey
+	}
+
+	params, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrUnsupportedKey
+	}
+
+	intParam := func(label int64) int64 {
+		v, _ := params[label].(int64)
+		return v
+	}
+	bytesParam := func(label int64) []byte {
+		v, _ := params[label].([]byte)
+		return v
+	}
+
+	alg := int(intParam(coseAlg))
+	switch intParam(coseKty) {
+	case coseKtyEC2:
+		x, y := bytesParam(coseX), bytesParam(coseY)
+		if alg != AlgES256 || intParam(coseCrv) != coseCrvP256 || len(x) != 32 || len(y) != 32 {
+			return nil, ErrUnsupportedKey
+		}
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, ErrUnsupportedKey
+		}
+		return &publicKey{alg, key}, nil
+	case coseKtyOKP:
+		x := bytesParam(coseX)
+		if alg != AlgEdDSA || intParam(coseCrv) != coseCrvEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, ErrUnsupportedKey
+		}
+		return &publicKey{alg, ed25519.PublicKey(x)}, nil
+	case coseKtyRSA:
+		n, e := bytesParam(coseN), bytesParam(coseE)
+		if alg != AlgRS256 || len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return nil, ErrUnsupportedKey
+		}
+		key := &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		return &publicKey{alg, key}, nil
+	}
+
+	return nil, ErrUnsupportedKey
+}
+
+func (p *publicKey) verify(data, signature []byte) error {
+
+	digest := sha256.Sum256(data)
+
+	switch key := p.key.(type) {
+	case *ecdsa.PublicKey:
+		if ecdsa.VerifyASN1(key, digest[:], signature) {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if ed25519.Verify(key, data, signature) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil {
+			return nil
+		}
+	}
+
+	return ErrSignature
+}
@@ -0,0 +1,325 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Authenticator data flags
+const (
+	FlagUserPresent  = 0x01
+	FlagUserVerified = 0x04
+	FlagAttestedData = 0x40
+	FlagExtensions   = 0x80
+)
+
+var (
+	ErrClientData        = errors.New("client data is not valid")
+	ErrAuthenticatorData = errors.New("authenticator data is not valid")
+	ErrAttestation       = errors.New("attestation object is not valid")
+	ErrUserVerification  = errors.New("user was not verified by the authenticator")
+	ErrSignCount         = errors.New("signature counter did not increase")
+)
+
+type (
+	RelyingParty struct {
+		ID      string
+		Name    string
+		Origins []string
+		Timeout time.Duration
+	}
+	Entity struct {
+		ID          string `json:"id,omitempty"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName,omitempty"`
+	}
+	CredentialParameter struct {
+		Type string `json:"type"`
+		Alg  int    `json:"alg"`
+	}
+	CredentialDescriptor struct {
+		Type string `json:"type"`
+		ID   string `json:"id"`
+	}
+	AuthenticatorSelection struct {
+		ResidentKey        string `json:"residentKey"`
+		RequireResidentKey bool   `json:"requireResidentKey"`
+		UserVerification   string `json:"userVerification"`
+	}
+	CreationOptions struct {
+		Challenge              string                 `json:"challenge"`
+		RP                     Entity                 `json:"rp"`
+		User                   Entity                 `json:"user"`
+		PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+		Timeout                int64                  `json:"timeout"`
+		Attestation            string                 `json:"attestation"`
+		AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+		ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	}
+	RequestOptions struct {
+		Challenge        string                 `json:"challenge"`
+		RPID             string                 `json:"rpId"`
+		Timeout          int64                  `json:"timeout"`
+		UserVerification string                 `json:"userVerification"`
+		AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	}
+	Credential struct {
+		ID        string
+		PublicKey []byte
+		Algorithm int
+		SignCount uint32
+	}
+	clientData struct {
+		Type      string `json:"type"`
+		Challenge string `json:"challenge"`
+		Origin    string `json:"origin"`
+	}
+	authenticatorData struct {
+		raw          []byte
+		rpIDHash     []byte
+		flags        byte
+		signCount    uint32
+		credentialID []byte
+		publicKey    []byte
+	}
+)
+
+func EncodeBase64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeBase64 accepts base64url with or without padding as browsers
+// and client libraries do not agree on it.
+func DecodeBase64(data string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(data, "="))
+}
+
+func NewChallenge() (string, error) {
+
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return "", err
+	}
+
+	return EncodeBase64(challenge), nil
+}
+
+func descriptors(credentialIDs []string) []CredentialDescriptor {
+
+	list := make([]CredentialDescriptor, 0, len(credentialIDs))
+	for _, id := range credentialIDs {
+		list = append(list, CredentialDescriptor{Type: "public-key", ID: id})
+	}
+
+	return list
+}
+
+func (rp *RelyingParty) CreationOptions(challenge string, user Entity, exclude []string) *CreationOptions {
+
+	params := make([]CredentialParameter, 0, len(SupportedAlgorithms))
+	for _, alg := range SupportedAlgorithms {
+		params = append(params, CredentialParameter{Type: "public-key", Alg: alg})
+	}
+
+	return &CreationOptions{
+		Challenge:        challenge,
+		RP:               Entity{ID: rp.ID, Name: rp.Name},
+		User:             user,
+		PubKeyCredParams: params,
+		Timeout:          int64(rp.Timeout / time.Millisecond),
+		Attestation:      "none",
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:        "required",
+			RequireResidentKey: true,
+			UserVerification:   "required",
+		},
+		ExcludeCredentials: descriptors(exclude),
+	}
+}
+
+func (rp *RelyingParty) RequestOptions(challenge string, allow []string) *RequestOptions {
+
+	return &RequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.ID,
+		Timeout:          int64(rp.Timeout / time.Millisecond),
+		UserVerification: "required",
+		AllowCredentials: descriptors(allow),
+	}
+}
+
+func (rp *RelyingParty) checkClientData(raw []byte, ceremony, challenge string) error {
+
+	data := clientData{}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return ErrClientData
+	}
+
+	if data.Type != ceremony || strings.TrimRight(data.Challenge, "=") != challenge {
+		return ErrClientData
+	}
+
+	for _, origin := range rp.Origins {
+		if data.Origin == origin {
+			return nil
+		}
+	}
+
+	return ErrClientData
+}
+
+func (rp *RelyingParty) checkAuthenticatorData(data *authenticatorData) error {
+
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(data.rpIDHash, rpIDHash[:]) {
+		return ErrAuthenticatorData
+	}
+
+	if data.flags&FlagUserPresent == 0 || data.flags&FlagUserVerified == 0 {
+		return ErrUserVerification
+	}
+
+	return nil
+}
+
+func parseAuthenticatorData(raw []byte) (*authenticatorData, error) {
+
+	if len(raw) < 37 {
+		return nil, ErrAuthenticatorData
+	}
+
+	data := &authenticatorData{
+		raw:       raw,
+		rpIDHash:  raw[:32],
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+	rest := raw[37:]
+
+	if data.flags&FlagAttestedData != 0 {
+
+		// AAGUID followed by the credential ID length
+		if len(rest) < 18 {
+			return nil, ErrAuthenticatorData
+		}
+		length := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if len(rest) < length {
+			return nil, ErrAuthenticatorData
+		}
+		data.credentialID, rest = rest[:length], rest[length:]
+
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrAuthenticatorData
+		}
+		data.publicKey, rest = rest[:len(rest)-len(after)], after
+	}
+
+	if data.flags&FlagExtensions != 0 {
+		_, after, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, ErrAuthenticatorData
+		}
+		rest = after
+	}
+
+	if len(rest) != 0 {
+		return nil, ErrAuthenticatorData
+	}
+
+	return data, nil
+}
+
+// VerifyRegistration checks a navigator.credentials.create() response.
+// Attestation statements are not verified since "none" conveyance is
+// requested, only the credential itself is trusted.
+func (rp *RelyingParty) VerifyRegistration(challenge string, clientDataJSON, attestationObject []byte) (*Credential, error) {
+
+	if err := rp.checkClientData(clientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	value, rest, err := decodeCBOR(attestationObject)
+	if err != nil || len(rest) != 0 {
+		return nil, ErrAttestation
+	}
+
+	attestation, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrAttestation
+	}
+
+	raw, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, ErrAttestation
+	}
+
+	data, err := parseAuthenticatorData(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = rp.checkAuthenticatorData(data); err != nil {
+		return nil, err
+	}
+
+	if data.flags&FlagAttestedData == 0 || len(data.credentialID) == 0 {
+		return nil, ErrAttestation
+	}
+
+	key, err := parsePublicKey(data.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:        EncodeBase64(data.credentialID),
+		PublicKey: data.publicKey,
+		Algorithm: key.alg,
+		SignCount: data.signCount,
+	}, nil
+}
+
+// VerifyAssertion checks a navigator.credentials.get() response against a
+// stored credential and returns the new signature counter.
+func (rp *RelyingParty) VerifyAssertion(challenge string, credential *Credential, clientDataJSON, authenticatorData, signature []byte) (uint32, error) {
+
+	if err := rp.checkClientData(clientDataJSON, "webauthn.get", challenge); err != nil {
+		return 0, err
+	}
+
+	data, err := parseAuthenticatorData(authenticatorData)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = rp.checkAuthenticatorData(data); err != nil {
+		return 0, err
+	}
+
+	key, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, data.raw...), clientDataHash[:]...)
+	if err = key.verify(signed, signature); err != nil {
+		return 0, err
+	}
+
+	// Authenticators without a counter always report zero
+	if (data.signCount != 0 || credential.SignCount != 0) && data.signCount <= credential.SignCount {
+		return 0, ErrSignCount
+	}
+
+	return data.signCount, nil
+}
@@ -0,0 +1,159 @@
+package webauthn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/thedevsir/frame-backend/services/test"
+)
+
+var rp = &RelyingParty{
+	ID:      "localhost",
+	Name:    "Frame",
+	Origins: []string{"http://localhost:3000"},
+	Timeout: 5 * time.Minute,
+}
+
+func decode(t *testing.T, values ...string) [][]byte {
+
+	decoded := make([][]byte, len(values))
+	for i, value := range values {
+		data, err := DecodeBase64(value)
+		assert.Nil(t, err)
+		decoded[i] = data
+	}
+	return decoded
+}
+
+func register(t *testing.T, authenticator *test.Authenticator) *Credential {
+
+	challenge, _ := NewChallenge()
+	clientData, attestation := authenticator.Register(challenge)
+	data := decode(t, clientData, attestation)
+
+	credential, err := rp.VerifyRegistration(challenge, data[0], data[1])
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	return credential
+}
+
+func TestRegistration(t *testing.T) {
+
+	t.Run("ES256", func(t *testing.T) {
+		authenticator := test.NewAuthenticator(rp.ID, rp.Origins[0], false)
+		credential := register(t, authenticator)
+		assert.Equal(t, AlgES256, credential.Algorithm)
+		assert.Equal(t, EncodeBase64(authenticator.CredentialID), credential.ID)
+	})
+
+	t.Run("EdDSA", func(t *testing.T) {
+		credential := register(t, test.NewAuthenticator(rp.ID, rp.Origins[0], true))
+		assert.Equal(t, AlgEdDSA, credential.Algorithm)
+	})
+
+	t.Run("WrongChallenge", func(t *testing.T) {
+		authenticator := test.NewAuthenticator(rp.ID, rp.Origins[0], false)
+		challenge, _ := NewChallenge()
+		clientData, attestation := authenticator.Register(challenge)
+		data := decode(t, clientData, attestation)
+
+		other, _ := NewChallenge()
+		_, err := rp.VerifyRegistration(other, data[0], data[1])
+		assert.Equal(t, ErrClientData, err)
+	})
+
+	t.Run("WrongOrigin", func(t *testing.T) {
+		authenticator := test.NewAuthenticator(rp.ID, "https://evil.example", false)
+		challenge, _ := NewChallenge()
+		clientData, attestation := authenticator.Register(challenge)
+		data := decode(t, clientData, attestation)
+
+		_, err := rp.VerifyRegistration(challenge, data[0], data[1])
+		assert.Equal(t, ErrClientData, err)
+	})
+
+	t.Run("WrongRelyingParty", func(t *testing.T) {
+		authenticator := test.NewAuthenticator("evil.example", rp.Origins[0], false)
+		challenge, _ := NewChallenge()
+		clientData, attestation := authenticator.Register(challenge)
+		data := decode(t, clientData, attestation)
+
+		_, err := rp.VerifyRegistration(challenge, data[0], data[1])
+		assert.Equal(t, ErrAuthenticatorData, err)
+	})
+
+	t.Run("UserNotVerified", func(t *testing.T) {
+		authenticator := test.NewAuthenticator(rp.ID, rp.Origins[0], false)
+		authenticator.Flags = FlagUserPresent
+		challenge, _ := NewChallenge()
+		clientData, attestation := authenticator.Register(challenge)
+		data := decode(t, clientData, attestation)
+
+		_, err := rp.VerifyRegistration(challenge, data[0], data[1])
+		assert.Equal(t, ErrUserVerification, err)
+	})
+}
+
+func TestAssertion(t *testing.T) {
+
+	for _, eddsa := range []bool{false, true} {
+
+		authenticator := test.NewAuthenticator(rp.ID, rp.Origins[0], eddsa)
+		credential := register(t, authenticator)
+
+		t.Run("Success", func(t *testing.T) {
+			challenge, _ := NewChallenge()
+			id, clientData, authData, signature := authenticator.Assert(challenge)
+			data := decode(t, clientData, authData, signature)
+
+			assert.Equal(t, credential.ID, id)
+			count, err := rp.VerifyAssertion(challenge, credential, data[0], data[1], data[2])
+			assert.Nil(t, err)
+			assert.Equal(t, authenticator.SignCount, count)
+			credential.SignCount = count
+		})
+
+		t.Run("WrongSignature", func(t *testing.T) {
+			challenge, _ := NewChallenge()
+			_, clientData, authData, signature := authenticator.Assert(challenge)
+			data := decode(t, clientData, authData, signature)
+			data[2][len(data[2])-1] ^= 0xff
+
+			_, err := rp.VerifyAssertion(challenge, credential, data[0], data[1], data[2])
+			assert.Error(t, err)
+		})
+
+		t.Run("ClonedAuthenticator", func(t *testing.T) {
+			challenge, _ := NewChallenge()
+			authenticator.SignCount = 0
+			_, clientData, authData, signature := authenticator.Assert(challenge)
+			data := decode(t, clientData, authData, signature)
+
+			_, err := rp.VerifyAssertion(challenge, credential, data[0], data[1], data[2])
+			assert.Equal(t, ErrSignCount, err)
+		})
+	}
+}
+
+func TestCBOR(t *testing.T) {
+
+	t.Run("Map", func(t *testing.T) {
+		// {1: 2, "a": [true, null, -1]}
+		value, rest, err := decodeCBOR([]byte{0xa2, 0x01, 0x02, 0x61, 0x61, 0x83, 0xf5, 0xf6, 0x20})
+		assert.Nil(t, err)
+		assert.Empty(t, rest)
+		assert.Equal(t, map[interface{}]interface{}{int64(1): int64(2), "a": []interface{}{true, nil, int64(-1)}}, value)
+	})
+
+	t.Run("Truncated", func(t *testing.T) {
+		_, _, err := decodeCBOR([]byte{0x5a, 0xff, 0xff, 0xff, 0xff, 0x00})
+		assert.Equal(t, errCBOR, err)
+	})
+
+	t.Run("IndefiniteLength", func(t *testing.T) {
+		_, _, err := decodeCBOR([]byte{0x9f, 0x01, 0xff})
+		assert.Equal(t, errCBOR, err)
+	})
+}